@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/db"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/service"
+	"errors"
+	"flag"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// AssignRole grants a role to a user, e.g. the seeded admin role to the first administrator,
+// who can then manage roles through the API.
+func AssignRole(username string, roleName string) error {
+	log.SetFormatter(&log.JSONFormatter{})
+	log.Infof("Assigning role %s to user %s", roleName, username)
+
+	if username == "" || roleName == "" {
+		return errors.New("both -username and -role are required")
+	}
+
+	database, err := db.NewDatabase()
+	if err != nil {
+		log.Error("failed to setup connection to the database")
+		return err
+	}
+
+	ctx := context.Background()
+	queries := repository.New(database.Client)
+	appUser, err := queries.GetAppUserByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &repository.NotFoundError{Key: "User not found."}
+		}
+		return err
+	}
+
+	roleService := service.NewRoleService(queries, service.NewStoreAuditLogger(queries))
+	roleService.Transactor = service.NewDBTransactor(database.Client.DB, queries)
+	// The assignment is recorded in the audit trail without an actor, as it is made from the command line.
+	err = roleService.AssignUserRole(ctx, uuid.Nil, repository.ClientInfo{}, appUser.ID, roleName)
+	if err != nil {
+		log.Error("failed to assign role")
+		return err
+	}
+
+	log.Infof("role %s assigned to user %s, effective on their next login or token refresh", roleName, username)
+	return nil
+}
+
+func main() {
+	username := flag.String("username", "", "username of the user to grant the role")
+	roleName := flag.String("role", "admin", "name of the role to grant")
+	flag.Parse()
+
+	if err := AssignRole(*username, *roleName); err != nil {
+		log.Error(err)
+		log.Fatal("Error assigning role")
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"eau-de-go/pkg/keys"
+	"eau-de-go/settings"
+	"errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// RotateKeys creates a new JWT signing key pair in the shared key store.
+// The previous key pair is kept for verification until tokens signed with it have expired.
+func RotateKeys() error {
+	log.SetFormatter(&log.JSONFormatter{})
+	log.Info("Rotating JWT signing keys")
+
+	if settings.KeyStoreBackend == keys.MemoryKeyStoreBackend {
+		return errors.New("the in-memory key store can only be rotated by the server, set KEY_STORE_BACKEND to file or s3")
+	}
+	keyStore, err := keys.GetKeyStoreForBackend(settings.KeyStoreBackend)
+	if err != nil {
+		return err
+	}
+
+	kid, err := keyStore.RotateKeyPair()
+	if err != nil {
+		log.Error("failed to rotate signing keys")
+		return err
+	}
+
+	log.Infof("rotation complete, new signing key id %s", kid)
+	return nil
+}
+
+func main() {
+	if err := RotateKeys(); err != nil {
+		log.Error(err)
+		log.Fatal("Error rotating keys")
+	}
+}
@@ -5,7 +5,12 @@ import (
 	"eau-de-go/internal/repository"
 	"eau-de-go/internal/service"
 	"eau-de-go/internal/transport/http"
+	"eau-de-go/pkg/email_util"
+	"eau-de-go/pkg/keys"
+	"eau-de-go/pkg/pagination"
+	"eau-de-go/settings"
 	log "github.com/sirupsen/logrus"
+	"time"
 )
 
 func Run() error {
@@ -20,9 +25,56 @@ func Run() error {
 
 	// database.Ping(context.Background())
 
+	keyStore, err := keys.GetKeyStoreForBackend(settings.KeyStoreBackend)
+	if err != nil {
+		log.Error("failed to setup the key store")
+		return err
+	}
+
+	// The AES key ring is loaded on startup when encryption is enabled, so that a missing key ring is refused
+	// before anything is encrypted with it.
+	var aesKeyRing *keys.AesKeyRing
+	if settings.EncryptEmailOutbox || settings.EncryptPaginationCursors {
+		aesKeyRing, err = keys.GetAesKeyRingForBackend(settings.KeyStoreBackend)
+		if err != nil {
+			log.Error("failed to load the AES key ring required by ENCRYPT_EMAIL_OUTBOX and ENCRYPT_PAGINATION_CURSORS")
+			return err
+		}
+	}
+
 	queries := repository.New(database.Client)
-	appUserService := service.NewAppUserService(queries)
-	handler := http.NewHandler(appUserService)
+	auditLogger := service.NewStoreAuditLogger(queries)
+	appUserService := service.NewAppUserService(queries, auditLogger)
+	appUserService.Transactor = service.NewDBTransactor(database.Client.DB, queries)
+	emailOutbox := service.NewEmailOutbox(queries)
+	if settings.EmailOutboxEnabled {
+		appUserService.EmailSender = emailOutbox
+		emailOutboxWorker := service.NewEmailOutboxWorker(queries, email_util.NewEmailSender())
+		if settings.EncryptEmailOutbox {
+			emailOutbox.Cipher = aesKeyRing
+			emailOutboxWorker.Cipher = aesKeyRing
+		}
+		stopEmailOutboxWorker := emailOutboxWorker.Schedule(settings.EmailOutboxPollInterval)
+		defer stopEmailOutboxWorker()
+	}
+	roleService := service.NewRoleService(queries, auditLogger)
+	roleService.Transactor = appUserService.Transactor
+	adminUserService := service.NewAdminUserService(appUserService, auditLogger)
+	auditService := service.NewAuditService(queries, auditLogger)
+	if settings.EncryptPaginationCursors {
+		cursorCodec := pagination.NewCursorCodec(aesKeyRing)
+		adminUserService.CursorCodec = cursorCodec
+		auditService.CursorCodec = cursorCodec
+	}
+	handler := http.NewHandler(appUserService, roleService, adminUserService, auditService, emailOutbox, keyStore)
+
+	if settings.JwtKeyRotationInterval > 0 {
+		stopKeyRotation := keys.ScheduleKeyRotation(keyStore, settings.JwtKeyRotationInterval)
+		defer stopKeyRotation()
+	}
+
+	stopRefreshTokenPurge := service.ScheduleRefreshTokenPurge(queries, settings.JwtLeeway, time.Hour)
+	defer stopRefreshTokenPurge()
 
 	if err := handler.Serve(); err != nil {
 		log.Error("failed to gracefully serve our application")
@@ -221,6 +221,306 @@ func (q *Queries) ListAppUser(ctx context.Context) ([]AppUser, error) {
 	return items, nil
 }
 
+const listAppUserPageByDateJoinedAsc = `-- name: ListAppUserPageByDateJoinedAsc :many
+SELECT id, username, email, email_verified, password, last_login, first_name, last_name, is_staff, is_active, date_joined FROM app_user
+WHERE ($1::boolean IS NULL OR is_active = $1)
+  AND ($2::boolean IS NULL OR is_staff = $2)
+  AND ($3::boolean IS NULL OR email_verified = $3)
+  AND ($4::timestamptz IS NULL OR date_joined >= $4)
+  AND ($5::timestamptz IS NULL OR date_joined < $5)
+  AND ($6::text IS NULL
+    OR username COLLATE "default" ILIKE $6
+    OR email COLLATE "default" ILIKE $6
+    OR first_name ILIKE $6
+    OR last_name ILIKE $6)
+  AND ($7::uuid IS NULL
+    OR (date_joined, id) > ($8::timestamptz, $7))
+ORDER BY date_joined, id
+LIMIT $9::int
+`
+
+type ListAppUserPageByDateJoinedAscParams struct {
+	IsActive        sql.NullBool   `json:"is_active"`
+	IsStaff         sql.NullBool   `json:"is_staff"`
+	EmailVerified   sql.NullBool   `json:"email_verified"`
+	JoinedAfter     sql.NullTime   `json:"joined_after"`
+	JoinedBefore    sql.NullTime   `json:"joined_before"`
+	Search          sql.NullString `json:"search"`
+	AfterID         uuid.NullUUID  `json:"after_id"`
+	AfterDateJoined sql.NullTime   `json:"after_date_joined"`
+	PageSize        int32          `json:"page_size"`
+}
+
+func (q *Queries) ListAppUserPageByDateJoinedAsc(ctx context.Context, arg ListAppUserPageByDateJoinedAscParams) ([]AppUser, error) {
+	rows, err := q.db.QueryContext(ctx, listAppUserPageByDateJoinedAsc,
+		arg.IsActive,
+		arg.IsStaff,
+		arg.EmailVerified,
+		arg.JoinedAfter,
+		arg.JoinedBefore,
+		arg.Search,
+		arg.AfterID,
+		arg.AfterDateJoined,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AppUser
+	for rows.Next() {
+		var i AppUser
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.EmailVerified,
+			&i.Password,
+			&i.LastLogin,
+			&i.FirstName,
+			&i.LastName,
+			&i.IsStaff,
+			&i.IsActive,
+			&i.DateJoined,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAppUserPageByDateJoinedDesc = `-- name: ListAppUserPageByDateJoinedDesc :many
+SELECT id, username, email, email_verified, password, last_login, first_name, last_name, is_staff, is_active, date_joined FROM app_user
+WHERE ($1::boolean IS NULL OR is_active = $1)
+  AND ($2::boolean IS NULL OR is_staff = $2)
+  AND ($3::boolean IS NULL OR email_verified = $3)
+  AND ($4::timestamptz IS NULL OR date_joined >= $4)
+  AND ($5::timestamptz IS NULL OR date_joined < $5)
+  AND ($6::text IS NULL
+    OR username COLLATE "default" ILIKE $6
+    OR email COLLATE "default" ILIKE $6
+    OR first_name ILIKE $6
+    OR last_name ILIKE $6)
+  AND ($7::uuid IS NULL
+    OR (date_joined, id) < ($8::timestamptz, $7))
+ORDER BY date_joined DESC, id DESC
+LIMIT $9::int
+`
+
+type ListAppUserPageByDateJoinedDescParams struct {
+	IsActive        sql.NullBool   `json:"is_active"`
+	IsStaff         sql.NullBool   `json:"is_staff"`
+	EmailVerified   sql.NullBool   `json:"email_verified"`
+	JoinedAfter     sql.NullTime   `json:"joined_after"`
+	JoinedBefore    sql.NullTime   `json:"joined_before"`
+	Search          sql.NullString `json:"search"`
+	AfterID         uuid.NullUUID  `json:"after_id"`
+	AfterDateJoined sql.NullTime   `json:"after_date_joined"`
+	PageSize        int32          `json:"page_size"`
+}
+
+func (q *Queries) ListAppUserPageByDateJoinedDesc(ctx context.Context, arg ListAppUserPageByDateJoinedDescParams) ([]AppUser, error) {
+	rows, err := q.db.QueryContext(ctx, listAppUserPageByDateJoinedDesc,
+		arg.IsActive,
+		arg.IsStaff,
+		arg.EmailVerified,
+		arg.JoinedAfter,
+		arg.JoinedBefore,
+		arg.Search,
+		arg.AfterID,
+		arg.AfterDateJoined,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AppUser
+	for rows.Next() {
+		var i AppUser
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.EmailVerified,
+			&i.Password,
+			&i.LastLogin,
+			&i.FirstName,
+			&i.LastName,
+			&i.IsStaff,
+			&i.IsActive,
+			&i.DateJoined,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAppUserPageByUsernameAsc = `-- name: ListAppUserPageByUsernameAsc :many
+SELECT id, username, email, email_verified, password, last_login, first_name, last_name, is_staff, is_active, date_joined FROM app_user
+WHERE ($1::boolean IS NULL OR is_active = $1)
+  AND ($2::boolean IS NULL OR is_staff = $2)
+  AND ($3::boolean IS NULL OR email_verified = $3)
+  AND ($4::timestamptz IS NULL OR date_joined >= $4)
+  AND ($5::timestamptz IS NULL OR date_joined < $5)
+  AND ($6::text IS NULL
+    OR username COLLATE "default" ILIKE $6
+    OR email COLLATE "default" ILIKE $6
+    OR first_name ILIKE $6
+    OR last_name ILIKE $6)
+  AND ($7::uuid IS NULL
+    OR (username, id) > ($8::text, $7))
+ORDER BY username, id
+LIMIT $9::int
+`
+
+type ListAppUserPageByUsernameAscParams struct {
+	IsActive      sql.NullBool   `json:"is_active"`
+	IsStaff       sql.NullBool   `json:"is_staff"`
+	EmailVerified sql.NullBool   `json:"email_verified"`
+	JoinedAfter   sql.NullTime   `json:"joined_after"`
+	JoinedBefore  sql.NullTime   `json:"joined_before"`
+	Search        sql.NullString `json:"search"`
+	AfterID       uuid.NullUUID  `json:"after_id"`
+	AfterUsername sql.NullString `json:"after_username"`
+	PageSize      int32          `json:"page_size"`
+}
+
+func (q *Queries) ListAppUserPageByUsernameAsc(ctx context.Context, arg ListAppUserPageByUsernameAscParams) ([]AppUser, error) {
+	rows, err := q.db.QueryContext(ctx, listAppUserPageByUsernameAsc,
+		arg.IsActive,
+		arg.IsStaff,
+		arg.EmailVerified,
+		arg.JoinedAfter,
+		arg.JoinedBefore,
+		arg.Search,
+		arg.AfterID,
+		arg.AfterUsername,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AppUser
+	for rows.Next() {
+		var i AppUser
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.EmailVerified,
+			&i.Password,
+			&i.LastLogin,
+			&i.FirstName,
+			&i.LastName,
+			&i.IsStaff,
+			&i.IsActive,
+			&i.DateJoined,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAppUserPageByUsernameDesc = `-- name: ListAppUserPageByUsernameDesc :many
+SELECT id, username, email, email_verified, password, last_login, first_name, last_name, is_staff, is_active, date_joined FROM app_user
+WHERE ($1::boolean IS NULL OR is_active = $1)
+  AND ($2::boolean IS NULL OR is_staff = $2)
+  AND ($3::boolean IS NULL OR email_verified = $3)
+  AND ($4::timestamptz IS NULL OR date_joined >= $4)
+  AND ($5::timestamptz IS NULL OR date_joined < $5)
+  AND ($6::text IS NULL
+    OR username COLLATE "default" ILIKE $6
+    OR email COLLATE "default" ILIKE $6
+    OR first_name ILIKE $6
+    OR last_name ILIKE $6)
+  AND ($7::uuid IS NULL
+    OR (username, id) < ($8::text, $7))
+ORDER BY username DESC, id DESC
+LIMIT $9::int
+`
+
+type ListAppUserPageByUsernameDescParams struct {
+	IsActive      sql.NullBool   `json:"is_active"`
+	IsStaff       sql.NullBool   `json:"is_staff"`
+	EmailVerified sql.NullBool   `json:"email_verified"`
+	JoinedAfter   sql.NullTime   `json:"joined_after"`
+	JoinedBefore  sql.NullTime   `json:"joined_before"`
+	Search        sql.NullString `json:"search"`
+	AfterID       uuid.NullUUID  `json:"after_id"`
+	AfterUsername sql.NullString `json:"after_username"`
+	PageSize      int32          `json:"page_size"`
+}
+
+func (q *Queries) ListAppUserPageByUsernameDesc(ctx context.Context, arg ListAppUserPageByUsernameDescParams) ([]AppUser, error) {
+	rows, err := q.db.QueryContext(ctx, listAppUserPageByUsernameDesc,
+		arg.IsActive,
+		arg.IsStaff,
+		arg.EmailVerified,
+		arg.JoinedAfter,
+		arg.JoinedBefore,
+		arg.Search,
+		arg.AfterID,
+		arg.AfterUsername,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AppUser
+	for rows.Next() {
+		var i AppUser
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.EmailVerified,
+			&i.Password,
+			&i.LastLogin,
+			&i.FirstName,
+			&i.LastName,
+			&i.IsStaff,
+			&i.IsActive,
+			&i.DateJoined,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const setUserEmailUnverified = `-- name: SetUserEmailUnverified :one
 UPDATE app_user
 SET email_verified = false
@@ -273,6 +573,37 @@ func (q *Queries) SetUserEmailVerified(ctx context.Context, id uuid.UUID) (AppUs
 	return i, err
 }
 
+const setUserStaffStatus = `-- name: SetUserStaffStatus :one
+UPDATE app_user
+SET is_staff = $1
+WHERE id = $2
+    RETURNING id, username, email, email_verified, password, last_login, first_name, last_name, is_staff, is_active, date_joined
+`
+
+type SetUserStaffStatusParams struct {
+	IsStaff bool      `json:"is_staff"`
+	ID      uuid.UUID `json:"id"`
+}
+
+func (q *Queries) SetUserStaffStatus(ctx context.Context, arg SetUserStaffStatusParams) (AppUser, error) {
+	row := q.db.QueryRowContext(ctx, setUserStaffStatus, arg.IsStaff, arg.ID)
+	var i AppUser
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.EmailVerified,
+		&i.Password,
+		&i.LastLogin,
+		&i.FirstName,
+		&i.LastName,
+		&i.IsStaff,
+		&i.IsActive,
+		&i.DateJoined,
+	)
+	return i, err
+}
+
 const updateAppUser = `-- name: UpdateAppUser :one
 UPDATE app_user
 SET first_name = coalesce($1, first_name),
@@ -306,6 +637,38 @@ func (q *Queries) UpdateAppUser(ctx context.Context, arg UpdateAppUserParams) (A
 	return i, err
 }
 
+const updateAppUserEmail = `-- name: UpdateAppUserEmail :one
+UPDATE app_user
+SET email = $1,
+    email_verified = false
+WHERE id = $2
+    RETURNING id, username, email, email_verified, password, last_login, first_name, last_name, is_staff, is_active, date_joined
+`
+
+type UpdateAppUserEmailParams struct {
+	Email string    `json:"email"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateAppUserEmail(ctx context.Context, arg UpdateAppUserEmailParams) (AppUser, error) {
+	row := q.db.QueryRowContext(ctx, updateAppUserEmail, arg.Email, arg.ID)
+	var i AppUser
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.EmailVerified,
+		&i.Password,
+		&i.LastLogin,
+		&i.FirstName,
+		&i.LastName,
+		&i.IsStaff,
+		&i.IsActive,
+		&i.DateJoined,
+	)
+	return i, err
+}
+
 const updateAppUserLastLoginNow = `-- name: UpdateAppUserLastLoginNow :one
 UPDATE app_user
 SET last_login = current_timestamp(0)
@@ -362,3 +725,34 @@ func (q *Queries) UpdateAppUserPassword(ctx context.Context, arg UpdateAppUserPa
 	)
 	return i, err
 }
+
+const updateAppUserUsername = `-- name: UpdateAppUserUsername :one
+UPDATE app_user
+SET username = $1
+WHERE id = $2
+    RETURNING id, username, email, email_verified, password, last_login, first_name, last_name, is_staff, is_active, date_joined
+`
+
+type UpdateAppUserUsernameParams struct {
+	Username string    `json:"username"`
+	ID       uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateAppUserUsername(ctx context.Context, arg UpdateAppUserUsernameParams) (AppUser, error) {
+	row := q.db.QueryRowContext(ctx, updateAppUserUsername, arg.Username, arg.ID)
+	var i AppUser
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.EmailVerified,
+		&i.Password,
+		&i.LastLogin,
+		&i.FirstName,
+		&i.LastName,
+		&i.IsStaff,
+		&i.IsActive,
+		&i.DateJoined,
+	)
+	return i, err
+}
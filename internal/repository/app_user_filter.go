@@ -0,0 +1,15 @@
+package repository
+
+import "time"
+
+// AppUserFilter narrows down a user listing, nil fields and an empty search term do not filter.
+type AppUserFilter struct {
+	IsActive      *bool
+	IsStaff       *bool
+	EmailVerified *bool
+	// JoinedAfter and JoinedBefore bound date_joined, inclusive and exclusive respectively.
+	JoinedAfter  *time.Time
+	JoinedBefore *time.Time
+	// Search matches users whose username, email, first name or last name starts with it, regardless of case.
+	Search string
+}
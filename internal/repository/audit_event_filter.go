@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// AuditEventFilter narrows down an audit event listing, nil fields and an empty action do not filter.
+type AuditEventFilter struct {
+	// UserId matches the events performed by the user and the events targeting the user.
+	UserId *uuid.UUID
+	Action string
+	// Since and Until bound created_at, inclusive and exclusive respectively.
+	Since *time.Time
+	Until *time.Time
+}
@@ -0,0 +1,7 @@
+package repository
+
+// ClientInfo describes the client a request originated from, as captured by the transport layer.
+type ClientInfo struct {
+	UserAgent string
+	IpAddress string
+}
@@ -1,6 +1,9 @@
 package repository
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type DuplicateKeyError struct {
 	Key string
@@ -16,6 +19,22 @@ func (e *IncorrectUserCredentialError) Error() string {
 	return "Incorrect credentials"
 }
 
+type LockedAccountError struct {
+	LockedUntil time.Time
+}
+
+func (e *LockedAccountError) Error() string {
+	return fmt.Sprintf("Account is locked after too many failed login attempts, try again after %s", e.LockedUntil.UTC().Format(time.RFC3339))
+}
+
+type LoginThrottledError struct {
+	RetryAfter time.Time
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("Too many login attempts, try again after %s", e.RetryAfter.UTC().Format(time.RFC3339))
+}
+
 type InactiveUserError struct {
 	Username string
 }
@@ -23,3 +42,45 @@ type InactiveUserError struct {
 func (e *InactiveUserError) Error() string {
 	return fmt.Sprintf("User %s is inactive", e.Username)
 }
+
+type NotFoundError struct {
+	Key string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Key
+}
+
+type InvalidVerificationTokenError struct{}
+
+func (e *InvalidVerificationTokenError) Error() string {
+	return "Invalid or expired token"
+}
+
+type SameEmailError struct{}
+
+func (e *SameEmailError) Error() string {
+	return "New email address is the same as the current one"
+}
+
+type SameUsernameError struct{}
+
+func (e *SameUsernameError) Error() string {
+	return "New username is the same as the current one"
+}
+
+type UsernameChangeCooldownError struct {
+	RetryAfter time.Time
+}
+
+func (e *UsernameChangeCooldownError) Error() string {
+	return fmt.Sprintf("Username was changed recently, it can be changed again after %s", e.RetryAfter.UTC().Format(time.RFC3339))
+}
+
+type SelfAdministrationError struct {
+	Action string
+}
+
+func (e *SelfAdministrationError) Error() string {
+	return fmt.Sprintf("Staff members cannot %s their own account", e.Action)
+}
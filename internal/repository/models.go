@@ -6,11 +6,19 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type AccountLoginFailure struct {
+	UserID         uuid.UUID    `json:"user_id"`
+	FailedAttempts int32        `json:"failed_attempts"`
+	LastFailedAt   time.Time    `json:"last_failed_at"`
+	LockedUntil    sql.NullTime `json:"locked_until"`
+}
+
 type AppUser struct {
 	ID            uuid.UUID    `json:"id"`
 	Username      string       `json:"username"`
@@ -24,3 +32,92 @@ type AppUser struct {
 	IsActive      bool         `json:"is_active"`
 	DateJoined    time.Time    `json:"date_joined"`
 }
+
+type AuditEvent struct {
+	ID        uuid.UUID       `json:"id"`
+	ActorID   uuid.NullUUID   `json:"actor_id"`
+	TargetID  uuid.NullUUID   `json:"target_id"`
+	Action    string          `json:"action"`
+	IpAddress string          `json:"ip_address"`
+	UserAgent string          `json:"user_agent"`
+	Metadata  json.RawMessage `json:"metadata"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type IpLoginFailure struct {
+	IpAddress       string    `json:"ip_address"`
+	FailedAttempts  int32     `json:"failed_attempts"`
+	WindowStartedAt time.Time `json:"window_started_at"`
+}
+
+type Permission struct {
+	Codename    string `json:"codename"`
+	Description string `json:"description"`
+}
+
+type RefreshToken struct {
+	Jti       uuid.UUID    `json:"jti"`
+	UserID    uuid.UUID    `json:"user_id"`
+	FamilyID  uuid.UUID    `json:"family_id"`
+	IssuedAt  time.Time    `json:"issued_at"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	RevokedAt sql.NullTime `json:"revoked_at"`
+}
+
+type Role struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RolePermission struct {
+	RoleID     uuid.UUID `json:"role_id"`
+	Permission string    `json:"permission"`
+}
+
+type UserRole struct {
+	UserID    uuid.UUID `json:"user_id"`
+	RoleID    uuid.UUID `json:"role_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserSession struct {
+	ID              uuid.UUID    `json:"id"`
+	UserID          uuid.UUID    `json:"user_id"`
+	UserAgent       string       `json:"user_agent"`
+	IpAddress       string       `json:"ip_address"`
+	CreatedAt       time.Time    `json:"created_at"`
+	LastRefreshedAt time.Time    `json:"last_refreshed_at"`
+	RevokedAt       sql.NullTime `json:"revoked_at"`
+}
+
+type UsernameHistory struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type VerificationToken struct {
+	TokenHash  string       `json:"token_hash"`
+	UserID     uuid.UUID    `json:"user_id"`
+	Purpose    string       `json:"purpose"`
+	Email      string       `json:"email"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ExpiresAt  time.Time    `json:"expires_at"`
+	ConsumedAt sql.NullTime `json:"consumed_at"`
+}
+
+type EmailOutbox struct {
+	ID            uuid.UUID       `json:"id"`
+	Message       json.RawMessage `json:"message"`
+	Status        string          `json:"status"`
+	Attempts      int32           `json:"attempts"`
+	LastError     string          `json:"last_error"`
+	CreatedAt     time.Time       `json:"created_at"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LockedUntil   sql.NullTime    `json:"locked_until"`
+	SentAt        sql.NullTime    `json:"sent_at"`
+	LockedBy      uuid.NullUUID   `json:"locked_by"`
+}
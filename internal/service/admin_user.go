@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/pkg/pagination"
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// AdminUserService lets staff members manage the accounts of other users.
+// Every action is recorded in the audit trail with the staff member as the actor and the managed user as the target.
+type AdminUserService struct {
+	AppUserService *AppUserService
+	AuditLogger    AuditLogger
+	CursorCodec    *pagination.CursorCodec
+}
+
+func NewAdminUserService(appUserService *AppUserService, auditLogger AuditLogger) *AdminUserService {
+	return &AdminUserService{
+		AppUserService: appUserService,
+		AuditLogger:    auditLogger,
+		CursorCodec:    pagination.NewCursorCodec(nil),
+	}
+}
+
+// ListUsers lists a page of the users matching the filter.
+func (service *AdminUserService) ListUsers(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, filter repository.AppUserFilter, pageRequest pagination.PageRequest) (pagination.Page[repository.AppUser], error) {
+	page, err := service.listUserPage(ctx, filter, pageRequest)
+	if err != nil {
+		return pagination.Page[repository.AppUser]{}, err
+	}
+
+	service.AuditLogger.LogAuditEvent(ctx, AuditEntry{
+		ActorId:    actorId,
+		Action:     AdminUsersListedAction,
+		ClientInfo: clientInfo,
+		Metadata: map[string]interface{}{
+			"search": filter.Search,
+			"sort":   pageRequest.Sort,
+		},
+	})
+	return page, nil
+}
+
+func (service *AdminUserService) GetUser(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error) {
+	dao, err := service.getUser(ctx, userId)
+	if err != nil {
+		return repository.AppUser{}, err
+	}
+
+	service.AuditLogger.LogAuditEvent(ctx, AuditEntry{
+		ActorId:    actorId,
+		TargetId:   userId,
+		Action:     AdminUserViewedAction,
+		ClientInfo: clientInfo,
+	})
+	return dao, nil
+}
+
+func (service *AdminUserService) ActivateUser(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error) {
+	dao, err := service.AppUserService.AppUserStore.ActivateUser(ctx, userId)
+	if err != nil {
+		return repository.AppUser{}, userNotFoundOrError(err)
+	}
+
+	service.AuditLogger.LogAuditEvent(ctx, AuditEntry{
+		ActorId:    actorId,
+		TargetId:   userId,
+		Action:     AdminUserActivatedAction,
+		ClientInfo: clientInfo,
+	})
+	return dao, nil
+}
+
+// DeactivateUser prevents the user from logging in, and logs the user out of all devices.
+// The user is deactivated, logged out and the action recorded in a single transaction,
+// so that a user is never left deactivated with their refresh tokens still valid.
+func (service *AdminUserService) DeactivateUser(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error) {
+	if actorId == userId {
+		return repository.AppUser{}, &repository.SelfAdministrationError{Action: "deactivate"}
+	}
+
+	var dao repository.AppUser
+	err := service.withTx(ctx, func(txService *AppUserService, auditLogger AuditLogger) error {
+		var err error
+		dao, err = txService.AppUserStore.DeactivateUser(ctx, userId)
+		if err != nil {
+			return userNotFoundOrError(err)
+		}
+
+		err = txService.RevokeAllRefreshTokens(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		auditLogger.LogAuditEvent(ctx, AuditEntry{
+			ActorId:    actorId,
+			TargetId:   userId,
+			Action:     AdminUserDeactivatedAction,
+			ClientInfo: clientInfo,
+		})
+		return nil
+	})
+	if err != nil {
+		return repository.AppUser{}, err
+	}
+	return dao, nil
+}
+
+func (service *AdminUserService) SetUserEmailVerified(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error) {
+	dao, err := service.AppUserService.AppUserStore.SetUserEmailVerified(ctx, userId)
+	if err != nil {
+		return repository.AppUser{}, userNotFoundOrError(err)
+	}
+
+	service.AuditLogger.LogAuditEvent(ctx, AuditEntry{
+		ActorId:    actorId,
+		TargetId:   userId,
+		Action:     AdminEmailVerifiedAction,
+		ClientInfo: clientInfo,
+		Metadata: map[string]interface{}{
+			"email": dao.Email,
+		},
+	})
+	return dao, nil
+}
+
+// ResetUserPassword emails the user a password reset link and logs the user out of all devices,
+// staff members never see or choose the password of another user.
+func (service *AdminUserService) ResetUserPassword(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) error {
+	dao, err := service.getUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if !service.AppUserService.DoesUserHaveAppAccess(ctx, dao) {
+		return &repository.InactiveUserError{Username: dao.Username}
+	}
+
+	err = service.AppUserService.RevokeAllRefreshTokens(ctx, userId)
+	if err != nil {
+		return err
+	}
+	err = service.AppUserService.sendPasswordResetEmail(ctx, dao)
+	if err != nil {
+		return err
+	}
+
+	service.AuditLogger.LogAuditEvent(ctx, AuditEntry{
+		ActorId:    actorId,
+		TargetId:   userId,
+		Action:     AdminPasswordResetAction,
+		ClientInfo: clientInfo,
+	})
+	return nil
+}
+
+func (service *AdminUserService) SetUserStaffStatus(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID, isStaff bool) (repository.AppUser, error) {
+	if actorId == userId && !isStaff {
+		return repository.AppUser{}, &repository.SelfAdministrationError{Action: "revoke staff status of"}
+	}
+
+	dao, err := service.AppUserService.AppUserStore.SetUserStaffStatus(ctx, repository.SetUserStaffStatusParams{
+		IsStaff: isStaff,
+		ID:      userId,
+	})
+	if err != nil {
+		return repository.AppUser{}, userNotFoundOrError(err)
+	}
+
+	service.AuditLogger.LogAuditEvent(ctx, AuditEntry{
+		ActorId:    actorId,
+		TargetId:   userId,
+		Action:     AdminStaffStatusChangeAction,
+		ClientInfo: clientInfo,
+		Metadata: map[string]interface{}{
+			"is_staff": isStaff,
+		},
+	})
+	return dao, nil
+}
+
+// UnlockUser clears the failed login attempts of the user, unlocking an account locked after too many of them.
+func (service *AdminUserService) UnlockUser(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error) {
+	dao, err := service.getUser(ctx, userId)
+	if err != nil {
+		return repository.AppUser{}, err
+	}
+
+	err = service.AppUserService.LoginThrottleStore.DeleteAccountLoginFailure(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		return repository.AppUser{}, err
+	}
+
+	service.AuditLogger.LogAuditEvent(ctx, AuditEntry{
+		ActorId:    actorId,
+		TargetId:   userId,
+		Action:     AdminUserUnlockedAction,
+		ClientInfo: clientInfo,
+	})
+	return dao, nil
+}
+
+// withTx runs fn with the AppUserService bound to a single transaction, along with an audit logger recording
+// to the audit_event table within the transaction, so that an action and its audit event are committed together.
+func (service *AdminUserService) withTx(ctx context.Context, fn func(txService *AppUserService, auditLogger AuditLogger) error) error {
+	return service.AppUserService.withTxStore(ctx, func(txService *AppUserService, store TxStore) error {
+		auditLogger := service.AuditLogger
+		if _, ok := auditLogger.(*StoreAuditLogger); ok && store != nil {
+			auditLogger = NewStoreAuditLogger(store)
+		}
+		return fn(txService, auditLogger)
+	})
+}
+
+func (service *AdminUserService) getUser(ctx context.Context, userId uuid.UUID) (repository.AppUser, error) {
+	dao, err := service.AppUserService.AppUserStore.GetAppUserById(ctx, userId)
+	if err != nil {
+		return repository.AppUser{}, userNotFoundOrError(err)
+	}
+	return dao, nil
+}
+
+func userNotFoundOrError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return &repository.NotFoundError{Key: "User not found."}
+	}
+	log.Error(err)
+	return err
+}
@@ -2,15 +2,20 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"eau-de-go/internal/repository"
 	"eau-de-go/pkg/email_util"
 	"eau-de-go/pkg/jwt_util"
 	"eau-de-go/pkg/password_util"
+	"eau-de-go/pkg/username_util"
+	"eau-de-go/settings"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	log "github.com/sirupsen/logrus"
-	"net/url"
+	"strings"
+	"time"
 )
 
 type AppUserStore interface {
@@ -18,25 +23,65 @@ type AppUserStore interface {
 	CreateAppUser(ctx context.Context, appUser repository.CreateAppUserParams) (repository.AppUser, error)
 	UpdateAppUser(ctx context.Context, appUser repository.UpdateAppUserParams) (repository.AppUser, error)
 	UpdateAppUserPassword(ctx context.Context, appUser repository.UpdateAppUserPasswordParams) (repository.AppUser, error)
+	UpdateAppUserEmail(ctx context.Context, arg repository.UpdateAppUserEmailParams) (repository.AppUser, error)
+	UpdateAppUserUsername(ctx context.Context, arg repository.UpdateAppUserUsernameParams) (repository.AppUser, error)
 	GetAppUserByUsername(ctx context.Context, username string) (repository.AppUser, error)
+	GetAppUserByEmailAddr(ctx context.Context, email string) (repository.AppUser, error)
 	SetUserEmailVerified(ctx context.Context, userId uuid.UUID) (repository.AppUser, error)
 	SetUserEmailUnverified(ctx context.Context, userId uuid.UUID) (repository.AppUser, error)
+	SetUserStaffStatus(ctx context.Context, arg repository.SetUserStaffStatusParams) (repository.AppUser, error)
+	ActivateUser(ctx context.Context, userId uuid.UUID) (repository.AppUser, error)
+	DeactivateUser(ctx context.Context, userId uuid.UUID) (repository.AppUser, error)
+	ListAppUserPageByDateJoinedAsc(ctx context.Context, arg repository.ListAppUserPageByDateJoinedAscParams) ([]repository.AppUser, error)
+	ListAppUserPageByDateJoinedDesc(ctx context.Context, arg repository.ListAppUserPageByDateJoinedDescParams) ([]repository.AppUser, error)
+	ListAppUserPageByUsernameAsc(ctx context.Context, arg repository.ListAppUserPageByUsernameAscParams) ([]repository.AppUser, error)
+	ListAppUserPageByUsernameDesc(ctx context.Context, arg repository.ListAppUserPageByUsernameDescParams) ([]repository.AppUser, error)
 	UpdateAppUserLastLoginNow(ctx context.Context, userId uuid.UUID) (repository.AppUser, error)
 }
 
 type AppUserService struct {
-	AppUserStore  AppUserStore
-	JwtUtil       jwt_util.JwtUtil
-	EmailVerifier email_util.EmailTokenVerifier
-	EmailSender   email_util.EmailSender
+	AppUserStore           AppUserStore
+	RefreshTokenStore      RefreshTokenStore
+	UserSessionStore       UserSessionStore
+	VerificationTokenStore VerificationTokenStore
+	UsernameHistoryStore   UsernameHistoryStore
+	RoleStore              RoleStore
+	LoginThrottleStore     LoginThrottleStore
+	AuditLogger            AuditLogger
+	JwtUtil                jwt_util.JwtUtil
+	EmailSender            email_util.EmailSender
+	// Transactor runs the changes to the user along with the emails about them in a single transaction, see withTx.
+	Transactor Transactor
+	// notifications holds back the notifications sent within a transaction until it commits, see notifyUser.
+	notifications *deferredEmailSender
 }
 
-func NewAppUserService(appUserStore AppUserStore) *AppUserService {
+// AppUserServiceStore is the persistence of the AppUserService, implemented by repository.Queries.
+type AppUserServiceStore interface {
+	AppUserStore
+	RefreshTokenStore
+	UserSessionStore
+	VerificationTokenStore
+	UsernameHistoryStore
+	RoleStore
+	LoginThrottleStore
+}
+
+func NewAppUserService(store AppUserServiceStore, auditLogger AuditLogger) *AppUserService {
+	jwtUtil := jwt_util.NewJwtUtil()
+	jwtUtil.RevocationChecker = NewRefreshTokenRevocationChecker(store)
+
 	return &AppUserService{
-		AppUserStore:  appUserStore,
-		JwtUtil:       jwt_util.NewJwtUtil(),
-		EmailVerifier: email_util.NewEmailTokenVerifier(),
-		EmailSender:   email_util.NewEmailSender(),
+		AppUserStore:           store,
+		RefreshTokenStore:      store,
+		UserSessionStore:       store,
+		VerificationTokenStore: store,
+		UsernameHistoryStore:   store,
+		RoleStore:              store,
+		LoginThrottleStore:     store,
+		AuditLogger:            auditLogger,
+		JwtUtil:                jwtUtil,
+		EmailSender:            email_util.NewEmailSender(),
 	}
 }
 
@@ -55,55 +100,202 @@ func (service *AppUserService) CreateAppUser(ctx context.Context, appUserParams
 	}
 	appUserParams.Email = validatedEmail
 
-	dao, err := service.AppUserStore.CreateAppUser(ctx, appUserParams)
-	if err != nil { // TODO: Refactor this error handling
-		var dbErr *pq.Error
-		if errors.As(err, &dbErr) {
-			if dbErr.Code.Name() == "unique_violation" {
-				return repository.AppUser{}, &repository.DuplicateKeyError{Key: "Duplicate user already exist."}
+	err = username_util.ValidateUsernameNotReserved(appUserParams.Username)
+	if err != nil {
+		return repository.AppUser{}, err
+	}
+	err = service.checkUsernameAvailable(ctx, uuid.Nil, appUserParams.Username)
+	if err != nil {
+		return repository.AppUser{}, err
+	}
+
+	link, err := email_util.FrontendUrl("/", nil)
+	if err != nil {
+		log.Error(err)
+	}
+
+	var dao repository.AppUser
+	err = service.withTx(ctx, func(txService *AppUserService) error {
+		var err error
+		dao, err = txService.AppUserStore.CreateAppUser(ctx, appUserParams)
+		if err != nil { // TODO: Refactor this error handling
+			var dbErr *pq.Error
+			if errors.As(err, &dbErr) && dbErr.Code.Name() == "unique_violation" {
+				return &repository.DuplicateKeyError{Key: "Duplicate user already exist."}
 			}
-		} else {
 			log.Error(err)
-			return repository.AppUser{}, err
+			return err
 		}
+		txService.notifyUser(dao, email_util.WelcomeEmailTemplate, email_util.TemplateData{Link: link})
+		return nil
+	})
+	if err != nil {
+		return repository.AppUser{}, err
 	}
 	return dao, nil
 }
 
-func (service *AppUserService) SendUserEmailVerification(ctx context.Context, emailAddress string) error {
+// SendUserEmailVerification emails a single-use email verification token to the user's email address.
+func (service *AppUserService) SendUserEmailVerification(ctx context.Context, userId uuid.UUID, emailAddress string) error {
+	return service.withTx(ctx, func(txService *AppUserService) error {
+		token, err := txService.createVerificationToken(ctx, userId, emailAddress, EmailVerificationPurpose, settings.EmailVerificationTokenLife)
+		if err != nil {
+			return err
+		}
+		link, err := tokenLink(verifyEmailPath, token)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		err = txService.EmailSender.SendTemplatedEmail(emailAddress, email_util.VerificationEmailTemplate, email_util.TemplateData{
+			Link:      link,
+			ExpiresIn: settings.EmailVerificationTokenLife,
+		})
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		return nil
+	})
+}
 
-	token, err := service.EmailVerifier.CreateToken(emailAddress)
-	urlSafeToken := url.QueryEscape(token)
+// VerifyEmailVerificationToken consumes the token and marks the user's email address as verified,
+// provided that the token was issued to the user for their current email address.
+func (service *AppUserService) VerifyEmailVerificationToken(ctx context.Context, userId uuid.UUID, userEmailAddress string, token string, clientInfo repository.ClientInfo) (bool, error) {
+	verificationToken, err := service.consumeVerificationToken(ctx, token, EmailVerificationPurpose)
+	if err != nil {
+		return false, err
+	}
+
+	if verificationToken.UserID != userId || !strings.EqualFold(verificationToken.Email, userEmailAddress) {
+		return false, errors.New("email address does not match")
+	}
+
+	_, err = service.AppUserStore.SetUserEmailVerified(ctx, userId)
 	if err != nil {
 		log.Error(err)
+		return false, err
+	}
+	service.logAuditEvent(ctx, AuditEntry{
+		ActorId:    userId,
+		TargetId:   userId,
+		Action:     UserEmailVerifiedAction,
+		ClientInfo: clientInfo,
+		Metadata: map[string]interface{}{
+			"email": verificationToken.Email,
+		},
+	})
+	return true, nil
+}
+
+// RequestEmailChange emails a confirmation token to the new email address of the user, after checking the user's password.
+// The user keeps their current email address until the new one is confirmed with ConfirmEmailChange.
+func (service *AppUserService) RequestEmailChange(ctx context.Context, userId uuid.UUID, password string, newEmailAddress string) error {
+	validatedEmail, err := email_util.ValidateEmailAddress(newEmailAddress)
+	if err != nil {
 		return err
 	}
-	//TODO: front end url from settings
-	err = service.EmailSender.SendSingleEmail(emailAddress, "Email Verification", urlSafeToken)
+
+	dao, err := service.AppUserStore.GetAppUserById(ctx, userId)
 	if err != nil {
 		log.Error(err)
 		return err
 	}
-	return nil
+	err = password_util.CheckPassword(password, []byte(dao.Password))
+	if err != nil {
+		return &repository.IncorrectUserCredentialError{}
+	}
+	// Email addresses are unique regardless of case, so changing only the case of the address is not a change.
+	if strings.EqualFold(dao.Email, validatedEmail) {
+		return &repository.SameEmailError{}
+	}
+	err = service.checkEmailAvailable(ctx, validatedEmail)
+	if err != nil {
+		return err
+	}
+
+	return service.withTx(ctx, func(txService *AppUserService) error {
+		token, err := txService.createVerificationToken(ctx, userId, validatedEmail, EmailChangePurpose, settings.EmailVerificationTokenLife)
+		if err != nil {
+			return err
+		}
+		link, err := tokenLink(confirmEmailChangePath, token)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		err = txService.EmailSender.SendTemplatedEmail(validatedEmail, email_util.EmailChangeEmailTemplate, email_util.TemplateData{
+			Name:      dao.FirstName,
+			Link:      link,
+			ExpiresIn: settings.EmailVerificationTokenLife,
+		})
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		return nil
+	})
 }
 
-func (service *AppUserService) VerifyEmailVerificationToken(ctx context.Context, userId uuid.UUID, userEmailAddress string, token string) (bool, error) {
-	verifiedEmail, err := service.EmailVerifier.VerifyToken(token)
+// ConfirmEmailChange consumes the email change token issued to the user, and replaces the user's email address
+// with the confirmed one. The new address is marked as verified, since the token was received there,
+// and the previous address is notified of the change.
+func (service *AppUserService) ConfirmEmailChange(ctx context.Context, userId uuid.UUID, token string) (repository.AppUser, error) {
+	var dao repository.AppUser
+	err := service.withTx(ctx, func(txService *AppUserService) error {
+		verificationToken, err := txService.consumeVerificationToken(ctx, token, EmailChangePurpose)
+		if err != nil {
+			return err
+		}
+		if verificationToken.UserID != userId {
+			return &repository.InvalidVerificationTokenError{}
+		}
+
+		previous, err := txService.AppUserStore.GetAppUserById(ctx, userId)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		_, err = txService.AppUserStore.UpdateAppUserEmail(ctx, repository.UpdateAppUserEmailParams{
+			ID:    userId,
+			Email: verificationToken.Email,
+		})
+		if err != nil {
+			var dbErr *pq.Error
+			if errors.As(err, &dbErr) && dbErr.Code.Name() == "unique_violation" {
+				return &repository.DuplicateKeyError{Key: "Email address already in use."}
+			}
+			log.Error(err)
+			return err
+		}
+		dao, err = txService.AppUserStore.SetUserEmailVerified(ctx, userId)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		txService.sendSecurityAlert(previous, fmt.Sprintf("The email address of your account was changed to %s.", dao.Email))
+		return nil
+	})
 	if err != nil {
-		log.Error(err)
-		return false, err
+		return repository.AppUser{}, err
 	}
+	return dao, nil
+}
 
-	if verifiedEmail != userEmailAddress {
-		return false, errors.New("email address does not match")
+// checkEmailAvailable returns DuplicateKeyError if the email address is used by an account.
+// The lookup relies on the case-insensitive collation of app_user.email, so addresses differing only in case are taken as well.
+func (service *AppUserService) checkEmailAvailable(ctx context.Context, emailAddress string) error {
+	_, err := service.AppUserStore.GetAppUserByEmailAddr(ctx, emailAddress)
+	if err == nil {
+		return &repository.DuplicateKeyError{Key: "Email address already in use."}
 	}
-
-	_, err = service.AppUserStore.SetUserEmailVerified(ctx, userId)
-	if err != nil {
+	if !errors.Is(err, sql.ErrNoRows) {
 		log.Error(err)
-		return false, err
+		return err
 	}
-	return true, nil
+	return nil
 }
 
 func (service *AppUserService) UpdateAppUser(ctx context.Context, appUserParams repository.UpdateAppUserParams) (repository.AppUser, error) {
@@ -115,7 +307,7 @@ func (service *AppUserService) UpdateAppUser(ctx context.Context, appUserParams
 	return dao, nil
 }
 
-func (service *AppUserService) UpdateAppUserPassword(ctx context.Context, userId uuid.UUID, oldPassword string, newPassword string) (repository.AppUser, error) {
+func (service *AppUserService) UpdateAppUserPassword(ctx context.Context, userId uuid.UUID, oldPassword string, newPassword string, clientInfo repository.ClientInfo) (repository.AppUser, error) {
 
 	if oldPassword == newPassword {
 		return repository.AppUser{}, &password_util.SamePasswordError{}
@@ -139,14 +331,132 @@ func (service *AppUserService) UpdateAppUserPassword(ctx context.Context, userId
 		ID:       userId,
 		Password: string(hashedNewPassword),
 	}
-	dao, err = service.AppUserStore.UpdateAppUserPassword(ctx, appUserParams)
+	err = service.withTx(ctx, func(txService *AppUserService) error {
+		dao, err = txService.AppUserStore.UpdateAppUserPassword(ctx, appUserParams)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		txService.sendSecurityAlert(dao, "The password of your account was changed.")
+		return nil
+	})
 	if err != nil {
-		log.Error(err)
 		return repository.AppUser{}, err
 	}
+	service.logAuditEvent(ctx, AuditEntry{
+		ActorId:    userId,
+		TargetId:   userId,
+		Action:     UserPasswordChangedAction,
+		ClientInfo: clientInfo,
+	})
 	return dao, nil
 }
 
+// RequestPasswordReset emails a password reset token to the user with the email address.
+// Unknown and inactive users are ignored without an error, so that callers cannot tell whether an account exists.
+func (service *AppUserService) RequestPasswordReset(ctx context.Context, emailAddress string) error {
+	validatedEmail, err := email_util.ValidateEmailAddress(emailAddress)
+	if err != nil {
+		return nil
+	}
+
+	dao, err := service.AppUserStore.GetAppUserByEmailAddr(ctx, validatedEmail)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Error(err)
+		}
+		return nil
+	}
+	if !service.DoesUserHaveAppAccess(ctx, dao) {
+		return nil
+	}
+	return service.sendPasswordResetEmail(ctx, dao)
+}
+
+// sendPasswordResetEmail emails the user a link to choose a new password.
+func (service *AppUserService) sendPasswordResetEmail(ctx context.Context, dao repository.AppUser) error {
+	return service.withTx(ctx, func(txService *AppUserService) error {
+		token, err := txService.createVerificationToken(ctx, dao.ID, dao.Email, PasswordResetPurpose, settings.PasswordResetTokenLife)
+		if err != nil {
+			return err
+		}
+		link, err := tokenLink(resetPasswordPath, token)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		err = txService.EmailSender.SendTemplatedEmail(dao.Email, email_util.PasswordResetEmailTemplate, email_util.TemplateData{
+			Name:      dao.FirstName,
+			Link:      link,
+			ExpiresIn: settings.PasswordResetTokenLife,
+		})
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		return nil
+	})
+}
+
+// ConfirmPasswordReset consumes the reset token, sets the new password of the user the token was issued for
+// and revokes all of the user's sessions.
+// The password is validated before the token is consumed, so that a weak password does not use up the token.
+func (service *AppUserService) ConfirmPasswordReset(ctx context.Context, token string, newPassword string, clientInfo repository.ClientInfo) error {
+	err := password_util.ValidatePassword(newPassword)
+	if err != nil {
+		return &password_util.WeakPasswordError{Key: err.Error()}
+	}
+
+	hashedNewPassword, err := HashPasswordFunc(newPassword)
+	if err != nil {
+		return err
+	}
+
+	var dao repository.AppUser
+	err = service.withTx(ctx, func(txService *AppUserService) error {
+		verificationToken, err := txService.consumeVerificationToken(ctx, token, PasswordResetPurpose)
+		if err != nil {
+			return err
+		}
+
+		dao, err = txService.AppUserStore.GetAppUserById(ctx, verificationToken.UserID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return &repository.InvalidVerificationTokenError{}
+			}
+			log.Error(err)
+			return err
+		}
+
+		_, err = txService.AppUserStore.UpdateAppUserPassword(ctx, repository.UpdateAppUserPasswordParams{
+			ID:       dao.ID,
+			Password: string(hashedNewPassword),
+		})
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		err = txService.RevokeAllRefreshTokens(ctx, dao.ID)
+		if err != nil {
+			return err
+		}
+		txService.sendSecurityAlert(dao, "The password of your account was reset, and all of your sessions were signed out.")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	service.logAuditEvent(ctx, AuditEntry{
+		ActorId:    dao.ID,
+		TargetId:   dao.ID,
+		Action:     UserPasswordResetAction,
+		ClientInfo: clientInfo,
+	})
+	return nil
+}
+
 func (service *AppUserService) GetAppUserById(ctx context.Context, id uuid.UUID) (repository.AppUser, error) {
 	dao, err := service.AppUserStore.GetAppUserById(ctx, id)
 	if err != nil {
@@ -165,31 +475,102 @@ func (service *AppUserService) GetAppUserByUsername(ctx context.Context, usernam
 	return dao, nil
 }
 
-func (service *AppUserService) Login(ctx context.Context, username string, password string) (repository.AppUser, error) {
+// Login checks the credentials of the user, failed attempts are recorded in the audit trail with the user they targeted.
+// Attempts are counted per IP address and per account before the password is checked, see login_throttle.go,
+// throttled attempts are rejected with LoginThrottledError and attempts to a locked account with LockedAccountError.
+func (service *AppUserService) Login(ctx context.Context, username string, password string, clientInfo repository.ClientInfo) (repository.AppUser, error) {
+	now := time.Now()
+	var loginThrottledError *repository.LoginThrottledError
+	err := service.beginIpLoginAttempt(ctx, clientInfo.IpAddress, now)
+	if err != nil {
+		if errors.As(err, &loginThrottledError) {
+			service.logFailedLogin(ctx, uuid.Nil, username, "ip_throttled", clientInfo)
+		}
+		return repository.AppUser{}, err
+	}
+
 	dao, err := service.AppUserStore.GetAppUserByUsername(ctx, username)
 	if err != nil {
+		service.logFailedLogin(ctx, uuid.Nil, username, "unknown_user", clientInfo)
 		return repository.AppUser{}, &repository.IncorrectUserCredentialError{}
 	}
+
+	failedAttempts, err := service.beginAccountLoginAttempt(ctx, dao.ID, now)
+	if err != nil {
+		var lockedAccountError *repository.LockedAccountError
+		switch {
+		case errors.As(err, &lockedAccountError):
+			service.logFailedLogin(ctx, dao.ID, username, "account_locked", clientInfo)
+		case errors.As(err, &loginThrottledError):
+			service.logFailedLogin(ctx, dao.ID, username, "throttled", clientInfo)
+		}
+		return repository.AppUser{}, err
+	}
+
 	if err := password_util.CheckPassword(password, []byte(dao.Password)); err != nil {
-		return repository.AppUser{}, &repository.IncorrectUserCredentialError{}
+		service.logFailedLogin(ctx, dao.ID, username, "incorrect_password", clientInfo)
+		return repository.AppUser{}, service.lockAccountAfterFailedAttempts(ctx, dao, failedAttempts, clientInfo, now)
 	}
+	service.releaseIpLoginAttempt(ctx, clientInfo.IpAddress)
+	err = service.LoginThrottleStore.DeleteAccountLoginFailure(ctx, dao.ID)
+	if err != nil {
+		log.Error(err)
+	}
+
 	_, err = service.AppUserStore.UpdateAppUserLastLoginNow(ctx, dao.ID)
 	if err != nil {
 		log.Error(err)
 	}
 
 	if !service.DoesUserHaveAppAccess(ctx, dao) {
+		service.logFailedLogin(ctx, dao.ID, username, "inactive_user", clientInfo)
 		return repository.AppUser{}, &repository.InactiveUserError{Username: dao.Username}
 	}
 
+	service.logAuditEvent(ctx, AuditEntry{
+		ActorId:    dao.ID,
+		TargetId:   dao.ID,
+		Action:     UserLoginAction,
+		ClientInfo: clientInfo,
+	})
 	return dao, nil
 }
 
+func (service *AppUserService) logFailedLogin(ctx context.Context, userId uuid.UUID, username string, reason string, clientInfo repository.ClientInfo) {
+	service.logAuditEvent(ctx, AuditEntry{
+		TargetId:   userId,
+		Action:     UserLoginFailedAction,
+		ClientInfo: clientInfo,
+		Metadata: map[string]interface{}{
+			"username": username,
+			"reason":   reason,
+		},
+	})
+}
+
+// logAuditEvent records the action in the audit trail, it is a no-op for services without an audit logger.
+func (service *AppUserService) logAuditEvent(ctx context.Context, entry AuditEntry) {
+	if service.AuditLogger == nil {
+		return
+	}
+	service.AuditLogger.LogAuditEvent(ctx, entry)
+}
+
 func (service *AppUserService) DoesUserHaveAppAccess(ctx context.Context, user repository.AppUser) bool {
 	return user.IsActive
 }
 
-func (service *AppUserService) makeTokenClaimMap(appUser repository.AppUser) map[string]interface{} {
+// makeTokenClaimMap returns the claims describing the user in their tokens, including the permissions granted by the user's roles.
+func (service *AppUserService) makeTokenClaimMap(ctx context.Context, appUser repository.AppUser) (map[string]interface{}, error) {
+	permissions, err := service.RoleStore.ListUserPermissions(ctx, appUser.ID)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if permissions == nil {
+		permissions = []string{}
+	}
+
 	claims := make(map[string]interface{})
 	claims["id"] = appUser.ID
 	claims["username"] = appUser.Username
@@ -201,13 +582,29 @@ func (service *AppUserService) makeTokenClaimMap(appUser repository.AppUser) map
 	claims["last_login"] = appUser.LastLogin
 	claims["date_joined"] = appUser.DateJoined
 	claims["email_verified"] = appUser.EmailVerified
-	return claims
+	claims["permissions"] = permissions
+	return claims, nil
 }
 
-func (service *AppUserService) GetAppUserTokens(appUser repository.AppUser) (string, map[string]interface{}, string, map[string]interface{}, error) {
-	claims := service.makeTokenClaimMap(appUser)
+// GetAppUserTokens starts a new session for the user on the given client, and issues its first refresh token along with an access token.
+func (service *AppUserService) GetAppUserTokens(ctx context.Context, appUser repository.AppUser, clientInfo repository.ClientInfo) (string, map[string]interface{}, string, map[string]interface{}, error) {
+	claims, err := service.makeTokenClaimMap(ctx, appUser)
+	if err != nil {
+		return "", nil, "", nil, err
+	}
+
+	session, err := service.UserSessionStore.CreateUserSession(ctx, repository.CreateUserSessionParams{
+		ID:        uuid.New(),
+		UserID:    appUser.ID,
+		UserAgent: clientInfo.UserAgent,
+		IpAddress: clientInfo.IpAddress,
+	})
+	if err != nil {
+		log.Error(err)
+		return "", nil, "", nil, err
+	}
 
-	refreshToken, refreshTokenClaims, err := service.JwtUtil.CreateRefreshToken(claims)
+	refreshToken, refreshTokenClaims, err := service.issueRefreshToken(ctx, claims, appUser.ID, session.ID)
 	if err != nil {
 		log.Error(err)
 		return "", nil, "", nil, err
@@ -221,40 +618,163 @@ func (service *AppUserService) GetAppUserTokens(appUser repository.AppUser) (str
 	return refreshToken, refreshTokenClaims, accessToken, accessTokenClaims, nil
 }
 
-func (service *AppUserService) RefreshToken(ctx context.Context, refreshToken string) (string, map[string]interface{}, repository.AppUser, error) {
-	refreshTokenClaims, err := service.JwtUtil.DecodeToken(jwt_util.Refresh, refreshToken)
+// issueRefreshToken creates a refresh token and persists it as a member of the given token family.
+func (service *AppUserService) issueRefreshToken(ctx context.Context, claims map[string]interface{}, userId uuid.UUID, familyId uuid.UUID) (string, map[string]interface{}, error) {
+	refreshToken, refreshTokenClaims, err := service.JwtUtil.CreateRefreshToken(claims)
+	if err != nil {
+		return "", nil, err
+	}
+
+	jtiStr, ok := refreshTokenClaims["jti"].(string)
+	if !ok {
+		return "", nil, &jwt_util.InvalidTokenError{}
+	}
+	jti, err := uuid.Parse(jtiStr)
+	if err != nil {
+		return "", nil, &jwt_util.InvalidTokenError{}
+	}
+	iat, ok := refreshTokenClaims["iat"].(int64)
+	if !ok {
+		return "", nil, &jwt_util.InvalidTokenError{}
+	}
+	exp, ok := refreshTokenClaims["exp"].(int64)
+	if !ok {
+		return "", nil, &jwt_util.InvalidTokenError{}
+	}
+
+	_, err = service.RefreshTokenStore.CreateRefreshToken(ctx, repository.CreateRefreshTokenParams{
+		Jti:       jti,
+		UserID:    userId,
+		FamilyID:  familyId,
+		IssuedAt:  time.Unix(iat, 0),
+		ExpiresAt: time.Unix(exp, 0),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return refreshToken, refreshTokenClaims, nil
+}
+
+// revokeRefreshTokenFamily revokes the session and every refresh token issued in the same family as the given jti.
+// It is used when an already rotated refresh token is presented again, which indicates the token was stolen.
+func (service *AppUserService) revokeRefreshTokenFamily(ctx context.Context, jti string, clientInfo repository.ClientInfo) {
+	jtiUuid, err := uuid.Parse(jti)
+	if err != nil {
+		return
+	}
+	storedToken, err := service.RefreshTokenStore.GetRefreshTokenByJti(ctx, jtiUuid)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	log.Warnf("Refresh token reuse detected for user %s, revoking token family %s", storedToken.UserID, storedToken.FamilyID)
+	service.logAuditEvent(ctx, AuditEntry{
+		TargetId:   storedToken.UserID,
+		Action:     UserRefreshTokenReuseAction,
+		ClientInfo: clientInfo,
+		Metadata: map[string]interface{}{
+			"session_id": storedToken.FamilyID,
+		},
+	})
+	_, err = service.revokeSession(ctx, storedToken.UserID, storedToken.FamilyID)
 	if err != nil {
 		log.Error(err)
-		return "", nil, repository.AppUser{}, &jwt_util.InvalidTokenError{}
+	}
+}
+
+// RefreshToken exchanges a refresh token for a new access token, rotating the refresh token in the process.
+// Returns the new refresh token, its claims, the new access token, its claims, and the user.
+func (service *AppUserService) RefreshToken(ctx context.Context, refreshToken string, clientInfo repository.ClientInfo) (string, map[string]interface{}, string, map[string]interface{}, repository.AppUser, error) {
+	refreshTokenClaims, err := service.JwtUtil.DecodeToken(ctx, jwt_util.Refresh, refreshToken)
+	if err != nil {
+		log.Error(err)
+		var revokedTokenError *jwt_util.RevokedTokenError
+		if errors.As(err, &revokedTokenError) {
+			service.revokeRefreshTokenFamily(ctx, revokedTokenError.Jti, clientInfo)
+		}
+		return "", nil, "", nil, repository.AppUser{}, &jwt_util.InvalidTokenError{}
 	}
 
 	idStr, ok := refreshTokenClaims["id"].(string)
 	if !ok {
-		return "", nil, repository.AppUser{}, &jwt_util.InvalidTokenError{}
+		return "", nil, "", nil, repository.AppUser{}, &jwt_util.InvalidTokenError{}
 	}
 
 	userId, err := uuid.Parse(idStr)
 	if err != nil {
 		log.Error(err)
-		return "", nil, repository.AppUser{}, &jwt_util.InvalidTokenError{}
+		return "", nil, "", nil, repository.AppUser{}, &jwt_util.InvalidTokenError{}
+	}
+
+	jtiStr, ok := refreshTokenClaims["jti"].(string)
+	if !ok {
+		return "", nil, "", nil, repository.AppUser{}, &jwt_util.InvalidTokenError{}
+	}
+
+	jti, err := uuid.Parse(jtiStr)
+	if err != nil {
+		log.Error(err)
+		return "", nil, "", nil, repository.AppUser{}, &jwt_util.InvalidTokenError{}
 	}
 
 	appUser, err := service.GetAppUserById(ctx, userId)
 	if err != nil {
 		log.Error(err)
-		return "", nil, repository.AppUser{}, err
+		return "", nil, "", nil, repository.AppUser{}, err
 
 	}
 
 	if !service.DoesUserHaveAppAccess(ctx, appUser) {
-		return "", nil, repository.AppUser{}, &repository.InactiveUserError{Username: appUser.Username}
+		return "", nil, "", nil, repository.AppUser{}, &repository.InactiveUserError{Username: appUser.Username}
+	}
+
+	// The old token is revoked, the new token is issued and the session is touched in a single transaction,
+	// so that a failure part way through neither leaves the session without a usable token nor issues a token twice.
+	var rotatedToken repository.RefreshToken
+	var tokenClaims, newRefreshTokenClaims map[string]interface{}
+	var newRefreshToken string
+	reused := false
+	err = service.withTx(ctx, func(txService *AppUserService) error {
+		var err error
+		rotatedToken, err = txService.RefreshTokenStore.RevokeRefreshToken(ctx, jti)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				reused = true
+				return &jwt_util.InvalidTokenError{}
+			}
+			return err
+		}
+
+		tokenClaims, err = txService.makeTokenClaimMap(ctx, appUser)
+		if err != nil {
+			return err
+		}
+		newRefreshToken, newRefreshTokenClaims, err = txService.issueRefreshToken(ctx, tokenClaims, appUser.ID, rotatedToken.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		_, err = txService.UserSessionStore.TouchUserSession(ctx, repository.TouchUserSessionParams{
+			ID:        rotatedToken.FamilyID,
+			UserAgent: clientInfo.UserAgent,
+			IpAddress: clientInfo.IpAddress,
+		})
+		return err
+	})
+	if err != nil {
+		if reused {
+			// The token was rotated by a concurrent request after it was validated, treat it as reuse.
+			service.revokeRefreshTokenFamily(ctx, jtiStr, clientInfo)
+			return "", nil, "", nil, repository.AppUser{}, err
+		}
+		log.Error(err)
+		return "", nil, "", nil, repository.AppUser{}, err
 	}
 
-	tokenClaims := service.makeTokenClaimMap(appUser)
-	accessToken, claims, err := service.JwtUtil.CreateAccessToken(tokenClaims)
+	accessToken, accessTokenClaims, err := service.JwtUtil.CreateAccessToken(tokenClaims)
 	if err != nil {
 		log.Error(err)
-		return "", nil, repository.AppUser{}, err
+		return "", nil, "", nil, repository.AppUser{}, err
 	}
 
 	_, err = service.AppUserStore.UpdateAppUserLastLoginNow(ctx, appUser.ID)
@@ -262,5 +782,65 @@ func (service *AppUserService) RefreshToken(ctx context.Context, refreshToken st
 		log.Error(err)
 	}
 
-	return accessToken, claims, appUser, nil
+	service.logAuditEvent(ctx, AuditEntry{
+		ActorId:    appUser.ID,
+		TargetId:   appUser.ID,
+		Action:     UserTokenRefreshedAction,
+		ClientInfo: clientInfo,
+		Metadata: map[string]interface{}{
+			"session_id": rotatedToken.FamilyID,
+		},
+	})
+	return newRefreshToken, newRefreshTokenClaims, accessToken, accessTokenClaims, appUser, nil
+}
+
+// Logout revokes the given refresh token and the session it belongs to.
+// Tokens that are invalid or already revoked are ignored, so that logging out is idempotent.
+func (service *AppUserService) Logout(ctx context.Context, refreshToken string) error {
+	refreshTokenClaims, err := service.JwtUtil.DecodeToken(ctx, jwt_util.Refresh, refreshToken)
+	if err != nil {
+		return nil
+	}
+
+	jtiStr, ok := refreshTokenClaims["jti"].(string)
+	if !ok {
+		return nil
+	}
+
+	jti, err := uuid.Parse(jtiStr)
+	if err != nil {
+		return nil
+	}
+
+	revokedToken, err := service.RefreshTokenStore.RevokeRefreshToken(ctx, jti)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		log.Error(err)
+		return err
+	}
+
+	_, err = service.revokeSession(ctx, revokedToken.UserID, revokedToken.FamilyID)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// RevokeAllRefreshTokens revokes every outstanding refresh token of the user, logging the user out of all devices.
+func (service *AppUserService) RevokeAllRefreshTokens(ctx context.Context, userId uuid.UUID) error {
+	err := service.RefreshTokenStore.RevokeAllUserRefreshTokens(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	err = service.UserSessionStore.RevokeAllUserSessions(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/pkg/pagination"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// Fields user listings can be sorted by, prefixed with - for descending order.
+const (
+	AppUserSortDateJoined = "date_joined"
+	AppUserSortUsername   = "username"
+)
+
+// DefaultAppUserSort lists the most recently joined users first.
+const DefaultAppUserSort = "-" + AppUserSortDateJoined
+
+// listUserPage queries the page of users after the cursor of the page request, using keyset pagination so that
+// the cost of a page does not grow with its position in the listing.
+// Each sort direction has its own query, so that the keyset predicate and ordering can use the (field, id) indexes.
+func (service *AdminUserService) listUserPage(ctx context.Context, filter repository.AppUserFilter, pageRequest pagination.PageRequest) (pagination.Page[repository.AppUser], error) {
+	pageRequest.Limit = pagination.ClampLimit(pageRequest.Limit)
+	if pageRequest.Sort == "" {
+		pageRequest.Sort = DefaultAppUserSort
+	}
+	field, descending, err := pagination.ParseSort(pageRequest.Sort, AppUserSortDateJoined, AppUserSortUsername)
+	if err != nil {
+		return pagination.Page[repository.AppUser]{}, err
+	}
+
+	var cursor pagination.Cursor
+	if pageRequest.Cursor != "" {
+		cursor, err = service.CursorCodec.Decode(pageRequest.Cursor, pageRequest.Sort)
+		if err != nil {
+			return pagination.Page[repository.AppUser]{}, err
+		}
+	}
+
+	store := service.AppUserService.AppUserStore
+	afterId := uuid.NullUUID{UUID: cursor.ID, Valid: cursor.ID != uuid.Nil}
+	pageSize := int32(pageRequest.Limit + 1)
+	var users []repository.AppUser
+	var cursorOf func(repository.AppUser) pagination.Cursor
+	switch field {
+	case AppUserSortUsername:
+		params := repository.ListAppUserPageByUsernameAscParams{
+			IsActive:      nullBool(filter.IsActive),
+			IsStaff:       nullBool(filter.IsStaff),
+			EmailVerified: nullBool(filter.EmailVerified),
+			JoinedAfter:   nullTime(filter.JoinedAfter),
+			JoinedBefore:  nullTime(filter.JoinedBefore),
+			Search:        prefixPattern(filter.Search),
+			AfterID:       afterId,
+			AfterUsername: sql.NullString{String: cursor.Key, Valid: afterId.Valid},
+			PageSize:      pageSize,
+		}
+		if descending {
+			users, err = store.ListAppUserPageByUsernameDesc(ctx, repository.ListAppUserPageByUsernameDescParams(params))
+		} else {
+			users, err = store.ListAppUserPageByUsernameAsc(ctx, params)
+		}
+		cursorOf = func(user repository.AppUser) pagination.Cursor {
+			return pagination.Cursor{Sort: pageRequest.Sort, Key: user.Username, ID: user.ID}
+		}
+	default:
+		var afterDateJoined time.Time
+		if afterId.Valid {
+			afterDateJoined, err = time.Parse(time.RFC3339Nano, cursor.Key)
+			if err != nil {
+				return pagination.Page[repository.AppUser]{}, &pagination.InvalidCursorError{}
+			}
+		}
+		params := repository.ListAppUserPageByDateJoinedAscParams{
+			IsActive:        nullBool(filter.IsActive),
+			IsStaff:         nullBool(filter.IsStaff),
+			EmailVerified:   nullBool(filter.EmailVerified),
+			JoinedAfter:     nullTime(filter.JoinedAfter),
+			JoinedBefore:    nullTime(filter.JoinedBefore),
+			Search:          prefixPattern(filter.Search),
+			AfterID:         afterId,
+			AfterDateJoined: sql.NullTime{Time: afterDateJoined, Valid: afterId.Valid},
+			PageSize:        pageSize,
+		}
+		if descending {
+			users, err = store.ListAppUserPageByDateJoinedDesc(ctx, repository.ListAppUserPageByDateJoinedDescParams(params))
+		} else {
+			users, err = store.ListAppUserPageByDateJoinedAsc(ctx, params)
+		}
+		cursorOf = func(user repository.AppUser) pagination.Cursor {
+			return pagination.Cursor{Sort: pageRequest.Sort, Key: user.DateJoined.Format(time.RFC3339Nano), ID: user.ID}
+		}
+	}
+	if err != nil {
+		log.Error(err)
+		return pagination.Page[repository.AppUser]{}, err
+	}
+
+	return pagination.NewPage(service.CursorCodec, users, pageRequest.Limit, cursorOf)
+}
+
+func nullBool(value *bool) sql.NullBool {
+	if value == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *value, Valid: true}
+}
+
+func nullTime(value *time.Time) sql.NullTime {
+	if value == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *value, Valid: true}
+}
+
+// prefixPattern returns the LIKE pattern matching values starting with the prefix, or null for an empty prefix.
+func prefixPattern(prefix string) sql.NullString {
+	if prefix == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: escapeLikePattern(prefix) + "%", Valid: true}
+}
+
+// escapeLikePattern escapes the wildcards of a LIKE pattern, so that user input is matched literally.
+func escapeLikePattern(pattern string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(pattern)
+}
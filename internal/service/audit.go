@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"eau-de-go/internal/repository"
+	"encoding/json"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"unicode/utf8"
+)
+
+// Actions recorded in the audit trail.
+const (
+	UserLoginAction             = "user.login"
+	UserLoginFailedAction       = "user.login_failed"
+	UserTokenRefreshedAction    = "user.token_refreshed"
+	UserRefreshTokenReuseAction = "user.refresh_token_reused"
+	UserPasswordChangedAction   = "user.password_changed"
+	UserPasswordResetAction     = "user.password_reset"
+	UserEmailVerifiedAction     = "user.email_verified"
+	UserAccountLockedAction     = "user.account_locked"
+	UserAccountUnlockedAction   = "user.account_unlocked"
+
+	AdminUsersListedAction       = "admin.users_listed"
+	AdminUserViewedAction        = "admin.user_viewed"
+	AdminUserActivatedAction     = "admin.user_activated"
+	AdminUserDeactivatedAction   = "admin.user_deactivated"
+	AdminEmailVerifiedAction     = "admin.email_verified"
+	AdminPasswordResetAction     = "admin.password_reset"
+	AdminStaffStatusChangeAction = "admin.staff_status_changed"
+	AdminAuditEventsListedAction = "admin.audit_events_listed"
+	AdminUserUnlockedAction      = "admin.user_unlocked"
+	AdminRoleCreatedAction       = "admin.role_created"
+	AdminRoleDeletedAction       = "admin.role_deleted"
+	AdminUserRoleAssignedAction  = "admin.user_role_assigned"
+	AdminUserRoleRevokedAction   = "admin.user_role_revoked"
+)
+
+// Lengths of the varchar columns of the audit_event table, longer values are truncated so that the event is still recorded.
+const (
+	auditActionMaxLength    = 64
+	auditIpAddressMaxLength = 45
+	auditUserAgentMaxLength = 512
+)
+
+// AuditEntry is an action to record in the audit trail, uuid.Nil is recorded as an unknown actor or target.
+type AuditEntry struct {
+	ActorId    uuid.UUID
+	TargetId   uuid.UUID
+	Action     string
+	ClientInfo repository.ClientInfo
+	Metadata   map[string]interface{}
+}
+
+// AuditLogger records security relevant actions.
+// The action has already been performed when it is logged, so implementations handle their own failures.
+type AuditLogger interface {
+	LogAuditEvent(ctx context.Context, entry AuditEntry)
+}
+
+type AuditEventStore interface {
+	CreateAuditEvent(ctx context.Context, arg repository.CreateAuditEventParams) (repository.AuditEvent, error)
+	ListAuditEventPage(ctx context.Context, arg repository.ListAuditEventPageParams) ([]repository.AuditEvent, error)
+}
+
+// StoreAuditLogger appends audit events to the append-only audit_event table.
+type StoreAuditLogger struct {
+	AuditEventStore AuditEventStore
+}
+
+func NewStoreAuditLogger(auditEventStore AuditEventStore) *StoreAuditLogger {
+	return &StoreAuditLogger{AuditEventStore: auditEventStore}
+}
+
+func (logger *StoreAuditLogger) LogAuditEvent(ctx context.Context, entry AuditEntry) {
+	metadata := entry.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadataJson, err := json.Marshal(metadata)
+	if err != nil {
+		log.Errorf("Error marshalling metadata of audit event %s: %v", entry.Action, err)
+		metadataJson = []byte("{}")
+	}
+
+	_, err = logger.AuditEventStore.CreateAuditEvent(ctx, repository.CreateAuditEventParams{
+		ActorID:   uuid.NullUUID{UUID: entry.ActorId, Valid: entry.ActorId != uuid.Nil},
+		TargetID:  uuid.NullUUID{UUID: entry.TargetId, Valid: entry.TargetId != uuid.Nil},
+		Action:    truncateAuditField(entry.Action, auditActionMaxLength),
+		IpAddress: truncateAuditField(entry.ClientInfo.IpAddress, auditIpAddressMaxLength),
+		UserAgent: truncateAuditField(entry.ClientInfo.UserAgent, auditUserAgentMaxLength),
+		Metadata:  metadataJson,
+	})
+	if err != nil {
+		log.Errorf("Error recording audit event %s by %s on %s: %v", entry.Action, entry.ActorId, entry.TargetId, err)
+	}
+}
+
+// truncateAuditField fits client supplied values into their column, replacing invalid UTF-8 that the database would reject
+// and truncating to the maximum number of characters of the column.
+func truncateAuditField(value string, maxLength int) string {
+	value = strings.ToValidUTF8(value, "\uFFFD")
+	if utf8.RuneCountInString(value) <= maxLength {
+		return value
+	}
+	return string([]rune(value)[:maxLength])
+}
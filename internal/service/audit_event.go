@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/pkg/pagination"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// auditEventSort is the only order of audit event listings, the most recent events first.
+const auditEventSort = "-created_at"
+
+// AuditService lets staff members query the audit trail.
+type AuditService struct {
+	AuditEventStore AuditEventStore
+	AuditLogger     AuditLogger
+	CursorCodec     *pagination.CursorCodec
+}
+
+func NewAuditService(auditEventStore AuditEventStore, auditLogger AuditLogger) *AuditService {
+	return &AuditService{
+		AuditEventStore: auditEventStore,
+		AuditLogger:     auditLogger,
+		CursorCodec:     pagination.NewCursorCodec(nil),
+	}
+}
+
+// ListAuditEvents lists a page of the events matching the filter, most recent first, the sort of the page request is ignored.
+// Reading the audit trail is itself recorded in the audit trail.
+func (service *AuditService) ListAuditEvents(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, filter repository.AuditEventFilter, pageRequest pagination.PageRequest) (pagination.Page[repository.AuditEvent], error) {
+	pageRequest.Limit = pagination.ClampLimit(pageRequest.Limit)
+
+	var cursor pagination.Cursor
+	var afterCreatedAt time.Time
+	var err error
+	if pageRequest.Cursor != "" {
+		cursor, err = service.CursorCodec.Decode(pageRequest.Cursor, auditEventSort)
+		if err != nil {
+			return pagination.Page[repository.AuditEvent]{}, err
+		}
+		afterCreatedAt, err = time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return pagination.Page[repository.AuditEvent]{}, &pagination.InvalidCursorError{}
+		}
+	}
+
+	var userId uuid.NullUUID
+	if filter.UserId != nil {
+		userId = uuid.NullUUID{UUID: *filter.UserId, Valid: true}
+	}
+	afterId := uuid.NullUUID{UUID: cursor.ID, Valid: cursor.ID != uuid.Nil}
+	events, err := service.AuditEventStore.ListAuditEventPage(ctx, repository.ListAuditEventPageParams{
+		UserID:         userId,
+		Action:         sql.NullString{String: filter.Action, Valid: filter.Action != ""},
+		Since:          nullTime(filter.Since),
+		Until:          nullTime(filter.Until),
+		AfterID:        afterId,
+		AfterCreatedAt: sql.NullTime{Time: afterCreatedAt, Valid: afterId.Valid},
+		PageSize:       int32(pageRequest.Limit + 1),
+	})
+	if err != nil {
+		log.Error(err)
+		return pagination.Page[repository.AuditEvent]{}, err
+	}
+
+	page, err := pagination.NewPage(service.CursorCodec, events, pageRequest.Limit, func(event repository.AuditEvent) pagination.Cursor {
+		return pagination.Cursor{Sort: auditEventSort, Key: event.CreatedAt.Format(time.RFC3339Nano), ID: event.ID}
+	})
+	if err != nil {
+		return pagination.Page[repository.AuditEvent]{}, err
+	}
+
+	service.AuditLogger.LogAuditEvent(ctx, AuditEntry{
+		ActorId:    actorId,
+		TargetId:   userId.UUID,
+		Action:     AdminAuditEventsListedAction,
+		ClientInfo: clientInfo,
+		Metadata: map[string]interface{}{
+			"action": filter.Action,
+		},
+	})
+	return page, nil
+}
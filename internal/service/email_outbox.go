@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/pkg/email_util"
+	"eau-de-go/settings"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// Delivery status of emails in the outbox.
+const (
+	EmailPendingStatus = "pending"
+	EmailSentStatus    = "sent"
+	EmailDeadStatus    = "dead"
+)
+
+type EmailOutboxStore interface {
+	EnqueueEmail(ctx context.Context, message json.RawMessage) (repository.EmailOutbox, error)
+	GetEmailOutboxById(ctx context.Context, id uuid.UUID) (repository.EmailOutbox, error)
+	ClaimDueEmails(ctx context.Context, arg repository.ClaimDueEmailsParams) ([]repository.EmailOutbox, error)
+	MarkEmailSent(ctx context.Context, arg repository.MarkEmailSentParams) (repository.EmailOutbox, error)
+	MarkEmailFailed(ctx context.Context, arg repository.MarkEmailFailedParams) (repository.EmailOutbox, error)
+	DeleteEmailOutboxBefore(ctx context.Context, createdAt time.Time) (int64, error)
+}
+
+// MessageCipher encrypts the messages stored in the outbox, e.g. *keys.AesKeyRing.
+type MessageCipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// EmailOutbox is an email_util.EmailSender that enqueues messages into the email_outbox table instead of sending them,
+// so that sending an email does not block the request and a failing mail server does not lose the email.
+// Messages are delivered by the EmailOutboxWorker. When a Cipher is set, messages are stored encrypted,
+// the worker must then be given a Cipher holding the same keys.
+type EmailOutbox struct {
+	Store  EmailOutboxStore
+	Cipher MessageCipher
+}
+
+func NewEmailOutbox(store EmailOutboxStore) *EmailOutbox {
+	return &EmailOutbox{Store: store}
+}
+
+// Enqueue stores the message in the outbox. An outbox with a store bound to a transaction, e.g. queries.WithTx(tx),
+// only enqueues the message if the transaction commits, see AppUserService.withTx.
+func (outbox *EmailOutbox) Enqueue(ctx context.Context, message email_util.Message) (repository.EmailOutbox, error) {
+	if _, err := message.Recipients(); err != nil {
+		return repository.EmailOutbox{}, err
+	}
+	messageJson, err := json.Marshal(message)
+	if err != nil {
+		return repository.EmailOutbox{}, err
+	}
+	if outbox.Cipher != nil {
+		ciphertext, err := outbox.Cipher.Encrypt(messageJson)
+		if err != nil {
+			return repository.EmailOutbox{}, err
+		}
+		// The ciphertext is stored as a base64 JSON string, see decodeEmailMessage.
+		messageJson, err = json.Marshal(ciphertext)
+		if err != nil {
+			return repository.EmailOutbox{}, err
+		}
+	}
+	return outbox.Store.EnqueueEmail(ctx, messageJson)
+}
+
+// decodeEmailMessage returns the message enqueued by Enqueue. Encrypted messages are stored as a base64 JSON string,
+// while messages enqueued without a Cipher are stored as a JSON object.
+func decodeEmailMessage(cipher MessageCipher, data json.RawMessage) (email_util.Message, error) {
+	var message email_util.Message
+	var ciphertext []byte
+	if err := json.Unmarshal(data, &ciphertext); err == nil {
+		if cipher == nil {
+			return message, errors.New("the message is encrypted and no cipher is configured")
+		}
+		data, err = cipher.Decrypt(ciphertext)
+		if err != nil {
+			return message, err
+		}
+	}
+	err := json.Unmarshal(data, &message)
+	return message, err
+}
+
+// Send enqueues the message, to be sent by the EmailOutboxWorker
+func (outbox *EmailOutbox) Send(message email_util.Message) error {
+	_, err := outbox.Enqueue(context.Background(), message)
+	return err
+}
+
+// SendSingleEmail enqueues a plain text email to a single user
+func (outbox *EmailOutbox) SendSingleEmail(recipientEmail string, mailSubject string, mailBody string) error {
+	return outbox.Send(email_util.Message{
+		To:       []string{recipientEmail},
+		Subject:  mailSubject,
+		TextBody: mailBody,
+	})
+}
+
+// SendMassEmail enqueues a plain text email to multiple users, as a separate message to each user
+func (outbox *EmailOutbox) SendMassEmail(recipientEmails []string, mailSubject string, mailBody string) error {
+	var errs []error
+	for _, recipientEmail := range recipientEmails {
+		err := outbox.SendSingleEmail(recipientEmail, mailSubject, mailBody)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("enqueueing to %s: %w", recipientEmail, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SendTemplatedEmail renders the named email template and enqueues it to a single user
+func (outbox *EmailOutbox) SendTemplatedEmail(recipientEmail string, templateName string, data email_util.TemplateData) error {
+	renderedEmail, err := email_util.RenderTemplate(templateName, data)
+	if err != nil {
+		return err
+	}
+	return outbox.Send(email_util.Message{
+		To:       []string{recipientEmail},
+		Subject:  renderedEmail.Subject,
+		TextBody: renderedEmail.TextBody,
+		HtmlBody: renderedEmail.HtmlBody,
+	})
+}
+
+// GetDeliveryStatus returns the enqueued email, with its delivery status, number of attempts and last error.
+func (outbox *EmailOutbox) GetDeliveryStatus(ctx context.Context, id uuid.UUID) (repository.EmailOutbox, error) {
+	email, err := outbox.Store.GetEmailOutboxById(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.EmailOutbox{}, &repository.NotFoundError{Key: "Email not found."}
+		}
+		log.Error(err)
+		return repository.EmailOutbox{}, err
+	}
+	return email, nil
+}
+
+// EmailOutboxWorker delivers the emails enqueued in the outbox. Failed deliveries are retried with exponential backoff,
+// and emails that still fail after MaxAttempts are dead-lettered, keeping the last error for inspection.
+// Sent and dead-lettered emails are deleted once they are older than Retention.
+type EmailOutboxWorker struct {
+	// ID identifies the worker as the holder of the lock of the emails it claimed.
+	ID     uuid.UUID
+	Store  EmailOutboxStore
+	Sender email_util.EmailSender
+	// Cipher decrypts the messages enqueued by an EmailOutbox with a Cipher.
+	Cipher      MessageCipher
+	MaxAttempts int
+	BatchSize   int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// LockDuration is how long claimed emails are hidden from other workers, emails claimed by a worker
+	// that stopped before delivering them are picked up again once the lock expires.
+	LockDuration time.Duration
+	// Retention is how long sent and dead-lettered emails are kept, they are kept forever when 0.
+	Retention time.Duration
+}
+
+func NewEmailOutboxWorker(store EmailOutboxStore, sender email_util.EmailSender) *EmailOutboxWorker {
+	return &EmailOutboxWorker{
+		ID:           uuid.New(),
+		Store:        store,
+		Sender:       sender,
+		MaxAttempts:  settings.EmailOutboxMaxAttempts,
+		BatchSize:    settings.EmailOutboxBatchSize,
+		BaseDelay:    settings.EmailOutboxRetryBaseDelay,
+		MaxDelay:     settings.EmailOutboxRetryMaxDelay,
+		LockDuration: 5 * time.Minute,
+		Retention:    settings.EmailOutboxRetention,
+	}
+}
+
+// RetryDelay returns the delay before the next delivery attempt after the given number of failed attempts,
+// doubling from BaseDelay with every attempt up to MaxDelay.
+func (worker *EmailOutboxWorker) RetryDelay(failedAttempts int) time.Duration {
+	delay := worker.BaseDelay
+	for i := 1; i < failedAttempts; i++ {
+		delay *= 2
+		if delay >= worker.MaxDelay {
+			return worker.MaxDelay
+		}
+	}
+	return min(delay, worker.MaxDelay)
+}
+
+// ProcessBatch claims the emails due for delivery and attempts to deliver them, returning the number of emails claimed.
+func (worker *EmailOutboxWorker) ProcessBatch(ctx context.Context) (int, error) {
+	emails, err := worker.Store.ClaimDueEmails(ctx, repository.ClaimDueEmailsParams{
+		LockedUntil: sql.NullTime{Time: time.Now().Add(worker.LockDuration), Valid: true},
+		LockedBy:    uuid.NullUUID{UUID: worker.ID, Valid: true},
+		BatchSize:   int32(worker.BatchSize),
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, email := range emails {
+		worker.deliver(ctx, email)
+	}
+	return len(emails), nil
+}
+
+// PurgeEmails deletes the sent and dead-lettered emails older than Retention, returning the number of emails deleted.
+func (worker *EmailOutboxWorker) PurgeEmails(ctx context.Context) (int64, error) {
+	if worker.Retention <= 0 {
+		return 0, nil
+	}
+	return worker.Store.DeleteEmailOutboxBefore(ctx, time.Now().Add(-worker.Retention))
+}
+
+// deliver sends the claimed email and records the outcome, provided that the worker still holds the lock of the email.
+// Emails whose lock expired before they were sent are left to the worker claiming them next.
+func (worker *EmailOutboxWorker) deliver(ctx context.Context, email repository.EmailOutbox) {
+	if email.LockedUntil.Valid && !time.Now().Before(email.LockedUntil.Time) {
+		log.Warnf("Lock of email %s expired before it was sent, leaving it to the next worker", email.ID)
+		return
+	}
+	lockedBy := uuid.NullUUID{UUID: worker.ID, Valid: true}
+
+	message, err := decodeEmailMessage(worker.Cipher, email.Message)
+	if err == nil {
+		err = worker.Sender.Send(message)
+	}
+	if err == nil {
+		_, err := worker.Store.MarkEmailSent(ctx, repository.MarkEmailSentParams{
+			ID:       email.ID,
+			LockedBy: lockedBy,
+		})
+		worker.logMarkError(email, "sent", err)
+		return
+	}
+
+	failedAttempts := int(email.Attempts) + 1
+	status := EmailPendingStatus
+	if failedAttempts >= worker.MaxAttempts {
+		status = EmailDeadStatus
+		log.Errorf("Giving up on email %s after %d attempts: %v", email.ID, failedAttempts, err)
+	} else {
+		log.Warnf("Error sending email %s, attempt %d: %v", email.ID, failedAttempts, err)
+	}
+	_, err = worker.Store.MarkEmailFailed(ctx, repository.MarkEmailFailedParams{
+		Status:        status,
+		LastError:     err.Error(),
+		NextAttemptAt: time.Now().Add(worker.RetryDelay(failedAttempts)),
+		ID:            email.ID,
+		LockedBy:      lockedBy,
+	})
+	worker.logMarkError(email, "failed", err)
+}
+
+// logMarkError logs the error of marking the email, no row being updated means that the lock of the email
+// expired and the email was claimed by another worker, which records the outcome of its own attempt instead.
+func (worker *EmailOutboxWorker) logMarkError(email repository.EmailOutbox, outcome string, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		log.Warnf("Lost the lock of email %s to another worker, not marking it as %s", email.ID, outcome)
+	default:
+		log.Errorf("Error marking email %s as %s: %v", email.ID, outcome, err)
+	}
+}
+
+// Schedule processes the outbox at the given interval, until the returned stop function is called.
+// Full batches are followed by the next batch right away, so that a backlog is drained without waiting for the interval.
+// Old emails are purged once an hour.
+func (worker *EmailOutboxWorker) Schedule(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		var lastPurgedAt time.Time
+		for {
+			select {
+			case <-ticker.C:
+				if time.Since(lastPurgedAt) >= time.Hour {
+					lastPurgedAt = time.Now()
+					if purged, err := worker.PurgeEmails(ctx); err != nil {
+						log.Errorf("Error purging email outbox: %v", err)
+					} else if purged > 0 {
+						log.Infof("Purged %d emails from the outbox", purged)
+					}
+				}
+				for {
+					claimed, err := worker.ProcessBatch(ctx)
+					if err != nil {
+						log.Errorf("Error processing email outbox: %v", err)
+					}
+					if err != nil || claimed < worker.BatchSize || ctx.Err() != nil {
+						break
+					}
+				}
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return cancel
+}
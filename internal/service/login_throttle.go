@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/pkg/email_util"
+	"eau-de-go/settings"
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+type LoginThrottleStore interface {
+	GetAccountLoginFailure(ctx context.Context, userID uuid.UUID) (repository.AccountLoginFailure, error)
+	BeginAccountLoginAttempt(ctx context.Context, arg repository.BeginAccountLoginAttemptParams) (repository.AccountLoginFailure, error)
+	LockAccount(ctx context.Context, arg repository.LockAccountParams) (repository.AccountLoginFailure, error)
+	DeleteAccountLoginFailure(ctx context.Context, userID uuid.UUID) error
+	GetIpLoginFailure(ctx context.Context, ipAddress string) (repository.IpLoginFailure, error)
+	BeginIpLoginAttempt(ctx context.Context, arg repository.BeginIpLoginAttemptParams) (repository.IpLoginFailure, error)
+	ReleaseIpLoginAttempt(ctx context.Context, ipAddress string) error
+}
+
+// loginDelay is how long to wait after the last of the failed login attempts before the next attempt,
+// doubling with every failed attempt up to LOGIN_DELAY_MAX_SECONDS.
+func loginDelay(failedAttempts int32) time.Duration {
+	if failedAttempts <= 0 {
+		return 0
+	}
+	delay := settings.LoginDelayBase
+	for i := int32(1); i < failedAttempts && delay < settings.LoginDelayMax; i++ {
+		delay *= 2
+	}
+	return min(delay, settings.LoginDelayMax)
+}
+
+// beginIpLoginAttempt counts a login attempt from the IP address before the password is checked, and rejects it
+// once the IP address made too many attempts in the current window.
+// The attempt is counted atomically with the check, so that concurrent attempts cannot exceed the limit.
+func (service *AppUserService) beginIpLoginAttempt(ctx context.Context, ipAddress string, now time.Time) error {
+	if ipAddress == "" || settings.LoginIpMaxFailedAttempts <= 0 {
+		return nil
+	}
+	_, err := service.LoginThrottleStore.BeginIpLoginAttempt(ctx, repository.BeginIpLoginAttemptParams{
+		IpAddress:   ipAddress,
+		AttemptedAt: now,
+		WindowStart: now.Add(-settings.LoginIpWindow),
+		MaxAttempts: int32(settings.LoginIpMaxFailedAttempts),
+	})
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Error(err)
+		return err
+	}
+
+	failure, err := service.LoginThrottleStore.GetIpLoginFailure(ctx, ipAddress)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	return &repository.LoginThrottledError{RetryAfter: failure.WindowStartedAt.Add(settings.LoginIpWindow)}
+}
+
+// releaseIpLoginAttempt uncounts a successful login attempt from the IP address.
+func (service *AppUserService) releaseIpLoginAttempt(ctx context.Context, ipAddress string) {
+	if ipAddress == "" || settings.LoginIpMaxFailedAttempts <= 0 {
+		return
+	}
+	err := service.LoginThrottleStore.ReleaseIpLoginAttempt(ctx, ipAddress)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// beginAccountLoginAttempt counts a login attempt to the user's account before the password is checked, and returns
+// the number of attempts counted in the current window. Attempts to a locked account are rejected with
+// LockedAccountError, and attempts made before the progressive delay since the last attempt elapsed with
+// LoginThrottledError. The password is not checked for rejected attempts, so that they cannot be used to guess it.
+// The attempt is counted atomically with the check, so that concurrent attempts cannot bypass the delay or the lockout.
+func (service *AppUserService) beginAccountLoginAttempt(ctx context.Context, userId uuid.UUID, now time.Time) (int32, error) {
+	failure, err := service.LoginThrottleStore.BeginAccountLoginAttempt(ctx, repository.BeginAccountLoginAttemptParams{
+		UserID:           userId,
+		AttemptedAt:      now,
+		WindowStart:      now.Add(-settings.LoginFailureWindow),
+		DelayBaseSeconds: settings.LoginDelayBase.Seconds(),
+		DelayMaxSeconds:  settings.LoginDelayMax.Seconds(),
+	})
+	if err == nil {
+		if settings.LoginMaxFailedAttempts > 0 && int(failure.FailedAttempts) > settings.LoginMaxFailedAttempts {
+			// Attempts made concurrently with the attempt that locks the account are rejected as well.
+			return failure.FailedAttempts, &repository.LoginThrottledError{RetryAfter: now.Add(loginDelay(failure.FailedAttempts - 1))}
+		}
+		return failure.FailedAttempts, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Error(err)
+		return 0, err
+	}
+
+	failure, err = service.LoginThrottleStore.GetAccountLoginFailure(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+	if failure.LockedUntil.Valid {
+		return failure.FailedAttempts, &repository.LockedAccountError{LockedUntil: failure.LockedUntil.Time}
+	}
+	return failure.FailedAttempts, &repository.LoginThrottledError{RetryAfter: failure.LastFailedAt.Add(loginDelay(failure.FailedAttempts))}
+}
+
+// lockAccountAfterFailedAttempts locks the user's account once LOGIN_MAX_FAILED_ATTEMPTS attempts failed within
+// LOGIN_FAILURE_WINDOW_MINUTES of each other. Returns the error to report the failed attempt with.
+func (service *AppUserService) lockAccountAfterFailedAttempts(ctx context.Context, appUser repository.AppUser, failedAttempts int32, clientInfo repository.ClientInfo, now time.Time) error {
+	if settings.LoginMaxFailedAttempts <= 0 || int(failedAttempts) < settings.LoginMaxFailedAttempts {
+		return &repository.IncorrectUserCredentialError{}
+	}
+
+	lockedUntil := now.Add(settings.LoginLockoutDuration)
+	_, err := service.LoginThrottleStore.LockAccount(ctx, repository.LockAccountParams{
+		LockedUntil: sql.NullTime{Time: lockedUntil, Valid: true},
+		UserID:      appUser.ID,
+	})
+	if err != nil {
+		log.Error(err)
+		return &repository.IncorrectUserCredentialError{}
+	}
+
+	log.Warnf("Locking account of user %s after %d failed login attempts", appUser.ID, failedAttempts)
+	service.logAuditEvent(ctx, AuditEntry{
+		TargetId:   appUser.ID,
+		Action:     UserAccountLockedAction,
+		ClientInfo: clientInfo,
+		Metadata: map[string]interface{}{
+			"failed_attempts": failedAttempts,
+			"locked_until":    lockedUntil,
+		},
+	})
+	service.sendAccountLockedEmail(ctx, appUser)
+	return &repository.LockedAccountError{LockedUntil: lockedUntil}
+}
+
+// sendAccountLockedEmail notifies the user that their account was locked, with a link to unlock it right away.
+func (service *AppUserService) sendAccountLockedEmail(ctx context.Context, appUser repository.AppUser) {
+	_ = service.withTx(ctx, func(txService *AppUserService) error {
+		token, err := txService.createVerificationToken(ctx, appUser.ID, appUser.Email, AccountUnlockPurpose, settings.LoginLockoutDuration)
+		if err != nil {
+			return err
+		}
+		link, err := tokenLink(unlockAccountPath, token)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		txService.notifyUser(appUser, email_util.AccountLockedEmailTemplate, email_util.TemplateData{
+			Link:      link,
+			ExpiresIn: settings.LoginLockoutDuration,
+		})
+		return nil
+	})
+}
+
+// UnlockAccount consumes the unlock token emailed to the user when their account was locked, and unlocks the account.
+func (service *AppUserService) UnlockAccount(ctx context.Context, token string, clientInfo repository.ClientInfo) error {
+	verificationToken, err := service.consumeVerificationToken(ctx, token, AccountUnlockPurpose)
+	if err != nil {
+		return err
+	}
+
+	err = service.LoginThrottleStore.DeleteAccountLoginFailure(ctx, verificationToken.UserID)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	service.logAuditEvent(ctx, AuditEntry{
+		ActorId:    verificationToken.UserID,
+		TargetId:   verificationToken.UserID,
+		Action:     UserAccountUnlockedAction,
+		ClientInfo: clientInfo,
+	})
+	return nil
+}
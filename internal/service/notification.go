@@ -0,0 +1,97 @@
+package service
+
+import (
+	"eau-de-go/internal/repository"
+	"eau-de-go/pkg/email_util"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"net/url"
+)
+
+// Frontend pages linked to from emails.
+const (
+	verifyEmailPath        = "/verify-email"
+	confirmEmailChangePath = "/confirm-email-change"
+	resetPasswordPath      = "/reset-password"
+	forgotPasswordPath     = "/forgot-password"
+	unlockAccountPath      = "/unlock-account"
+)
+
+// tokenLink builds the frontend link to the page at path, carrying the token.
+func tokenLink(path string, token string) (string, error) {
+	return email_util.FrontendUrl(path, url.Values{"token": {token}})
+}
+
+// notifyUser sends the templated notification email to the user. Notifications are best effort,
+// failing to send one is logged and does not fail the operation the user is notified about.
+// Within a transaction, the notification is sent once the transaction commits, so that a failure to enqueue it
+// cannot abort the transaction and no notification is sent about changes that are rolled back.
+func (service *AppUserService) notifyUser(appUser repository.AppUser, templateName string, data email_util.TemplateData) {
+	data.Name = appUser.FirstName
+	var sender email_util.EmailSender = service.EmailSender
+	if service.notifications != nil {
+		sender = service.notifications
+	}
+	err := sender.SendTemplatedEmail(appUser.Email, templateName, data)
+	if err != nil {
+		log.Errorf("Error sending %s email: %v", templateName, err)
+	}
+}
+
+// deferredEmailSender is an email_util.EmailSender holding back the emails sent through it until they are flushed,
+// used to send emails only once the transaction they are sent in commits.
+type deferredEmailSender struct {
+	sends []func(sender email_util.EmailSender) error
+}
+
+func (deferred *deferredEmailSender) Send(message email_util.Message) error {
+	deferred.sends = append(deferred.sends, func(sender email_util.EmailSender) error {
+		return sender.Send(message)
+	})
+	return nil
+}
+
+func (deferred *deferredEmailSender) SendSingleEmail(recipientEmail string, mailSubject string, mailBody string) error {
+	deferred.sends = append(deferred.sends, func(sender email_util.EmailSender) error {
+		return sender.SendSingleEmail(recipientEmail, mailSubject, mailBody)
+	})
+	return nil
+}
+
+func (deferred *deferredEmailSender) SendMassEmail(recipientEmails []string, mailSubject string, mailBody string) error {
+	deferred.sends = append(deferred.sends, func(sender email_util.EmailSender) error {
+		return sender.SendMassEmail(recipientEmails, mailSubject, mailBody)
+	})
+	return nil
+}
+
+func (deferred *deferredEmailSender) SendTemplatedEmail(recipientEmail string, templateName string, data email_util.TemplateData) error {
+	deferred.sends = append(deferred.sends, func(sender email_util.EmailSender) error {
+		return sender.SendTemplatedEmail(recipientEmail, templateName, data)
+	})
+	return nil
+}
+
+// flush sends the held back emails with the sender, returning the errors of the emails that could not be sent.
+func (deferred *deferredEmailSender) flush(sender email_util.EmailSender) error {
+	var errs []error
+	for _, send := range deferred.sends {
+		if err := send(sender); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	deferred.sends = nil
+	return errors.Join(errs...)
+}
+
+// sendSecurityAlert notifies the user of a security sensitive change to their account.
+func (service *AppUserService) sendSecurityAlert(appUser repository.AppUser, event string) {
+	link, err := email_util.FrontendUrl(forgotPasswordPath, nil)
+	if err != nil {
+		log.Error(err)
+	}
+	service.notifyUser(appUser, email_util.SecurityAlertEmailTemplate, email_util.TemplateData{
+		Link:  link,
+		Event: event,
+	})
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/pkg/jwt_util"
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+type RefreshTokenStore interface {
+	CreateRefreshToken(ctx context.Context, arg repository.CreateRefreshTokenParams) (repository.RefreshToken, error)
+	GetRefreshTokenByJti(ctx context.Context, jti uuid.UUID) (repository.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, jti uuid.UUID) (repository.RefreshToken, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeAllUserRefreshTokens(ctx context.Context, userID uuid.UUID) error
+	DeleteStaleRefreshTokens(ctx context.Context, expiresAt time.Time) (int64, error)
+}
+
+// refreshTokenRevocationChecker checks refresh token jti against the persisted refresh token store,
+// so that rotated or revoked refresh tokens are rejected by jwt_util.
+// Access tokens are not persisted and are never considered revoked.
+type refreshTokenRevocationChecker struct {
+	RefreshTokenStore RefreshTokenStore
+}
+
+func NewRefreshTokenRevocationChecker(refreshTokenStore RefreshTokenStore) jwt_util.RevocationChecker {
+	return &refreshTokenRevocationChecker{RefreshTokenStore: refreshTokenStore}
+}
+
+func (c *refreshTokenRevocationChecker) IsRevoked(ctx context.Context, tokenType jwt_util.TokenType, jti string) (bool, error) {
+	if tokenType != jwt_util.Refresh {
+		return false, nil
+	}
+
+	jtiUuid, err := uuid.Parse(jti)
+	if err != nil {
+		return true, nil
+	}
+
+	storedToken, err := c.RefreshTokenStore.GetRefreshTokenByJti(ctx, jtiUuid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	return storedToken.RevokedAt.Valid, nil
+}
+
+// PurgeRefreshTokens deletes the refresh tokens that expired more than leeway ago, along with the revoked refresh tokens
+// of revoked sessions, returning the number of tokens deleted. Revoked refresh tokens of sessions that are still active
+// are kept until they expire, so that their reuse is still detected.
+func PurgeRefreshTokens(ctx context.Context, refreshTokenStore RefreshTokenStore, leeway time.Duration) (int64, error) {
+	return refreshTokenStore.DeleteStaleRefreshTokens(ctx, time.Now().Add(-leeway))
+}
+
+// ScheduleRefreshTokenPurge purges the stale refresh tokens, see PurgeRefreshTokens, every interval until the returned function is called.
+func ScheduleRefreshTokenPurge(refreshTokenStore RefreshTokenStore, leeway time.Duration, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				purged, err := PurgeRefreshTokens(ctx, refreshTokenStore, leeway)
+				if err != nil {
+					log.Errorf("Error purging refresh tokens: %v", err)
+					continue
+				}
+				if purged > 0 {
+					log.Infof("Purged %d refresh tokens", purged)
+				}
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return cancel
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+)
+
+// Permissions checked by the API, granted to users through their roles.
+const (
+	UsersReadPermission   = "users.read"
+	UsersWritePermission  = "users.write"
+	RolesManagePermission = "roles.manage"
+	AuditReadPermission   = "audit.read"
+)
+
+type RoleStore interface {
+	CreateRole(ctx context.Context, arg repository.CreateRoleParams) (repository.Role, error)
+	GetRoleByName(ctx context.Context, name string) (repository.Role, error)
+	ListRoles(ctx context.Context) ([]repository.Role, error)
+	DeleteRole(ctx context.Context, id uuid.UUID) error
+	ListPermissions(ctx context.Context) ([]repository.Permission, error)
+	AddRolePermission(ctx context.Context, arg repository.AddRolePermissionParams) error
+	ListRolePermissions(ctx context.Context, roleID uuid.UUID) ([]string, error)
+	AssignUserRole(ctx context.Context, arg repository.AssignUserRoleParams) error
+	RevokeUserRole(ctx context.Context, arg repository.RevokeUserRoleParams) error
+	ListUserRoles(ctx context.Context, userID uuid.UUID) ([]repository.Role, error)
+	ListUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error)
+}
+
+// RoleService manages roles, the permissions they grant and their assignment to users.
+// Permissions are embedded in access tokens when they are issued, so changes apply to a user on their next token refresh.
+// Changes to roles and their assignment are recorded in the audit trail within the transaction making them.
+type RoleService struct {
+	RoleStore   RoleStore
+	AuditLogger AuditLogger
+	// Transactor creates a role along with its permissions in a single transaction.
+	Transactor Transactor
+}
+
+func NewRoleService(roleStore RoleStore, auditLogger AuditLogger) *RoleService {
+	return &RoleService{RoleStore: roleStore, AuditLogger: auditLogger}
+}
+
+func (service *RoleService) ListRoles(ctx context.Context) ([]repository.Role, error) {
+	roles, err := service.RoleStore.ListRoles(ctx)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (service *RoleService) ListPermissions(ctx context.Context) ([]repository.Permission, error) {
+	permissions, err := service.RoleStore.ListPermissions(ctx)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// CreateRole creates a role granting the permissions.
+// Unknown permissions are rejected with NotFoundError before the role is created,
+// and the role is created along with its permissions in a single transaction, so that no role is left without some of its permissions.
+func (service *RoleService) CreateRole(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, name string, description string, permissions []string) (repository.Role, error) {
+	err := service.checkPermissionsExist(ctx, permissions)
+	if err != nil {
+		return repository.Role{}, err
+	}
+
+	var role repository.Role
+	err = service.withTx(ctx, func(txService *RoleService) error {
+		var err error
+		role, err = txService.RoleStore.CreateRole(ctx, repository.CreateRoleParams{
+			Name:        name,
+			Description: description,
+		})
+		if err != nil {
+			var dbErr *pq.Error
+			if errors.As(err, &dbErr) && dbErr.Code.Name() == "unique_violation" {
+				return &repository.DuplicateKeyError{Key: "Role already exists."}
+			}
+			log.Error(err)
+			return err
+		}
+
+		for _, permission := range permissions {
+			err = txService.addRolePermission(ctx, role.ID, permission)
+			if err != nil {
+				return err
+			}
+		}
+
+		txService.AuditLogger.LogAuditEvent(ctx, AuditEntry{
+			ActorId:    actorId,
+			TargetId:   role.ID,
+			Action:     AdminRoleCreatedAction,
+			ClientInfo: clientInfo,
+			Metadata:   map[string]interface{}{"role": name, "permissions": permissions},
+		})
+		return nil
+	})
+	if err != nil {
+		return repository.Role{}, err
+	}
+	return role, nil
+}
+
+// checkPermissionsExist returns NotFoundError if any of the permissions is unknown.
+func (service *RoleService) checkPermissionsExist(ctx context.Context, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	known, err := service.RoleStore.ListPermissions(ctx)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	knownCodenames := make(map[string]bool, len(known))
+	for _, permission := range known {
+		knownCodenames[permission.Codename] = true
+	}
+	for _, permission := range permissions {
+		if !knownCodenames[permission] {
+			return &repository.NotFoundError{Key: fmt.Sprintf("Permission %s not found.", permission)}
+		}
+	}
+	return nil
+}
+
+// withTx runs fn with a copy of the service whose store is bound to a single transaction.
+// Audit events logged to the audit_event table by fn are recorded within the transaction as well,
+// so that they are only recorded if the changes they are about are committed, and the changes are rolled back
+// if they cannot be recorded. Services without a Transactor run fn with the service itself.
+func (service *RoleService) withTx(ctx context.Context, fn func(txService *RoleService) error) error {
+	if service.Transactor == nil {
+		return fn(service)
+	}
+	return service.Transactor.ExecTx(ctx, func(store TxStore) error {
+		txService := &RoleService{RoleStore: store, AuditLogger: service.AuditLogger}
+		if _, ok := service.AuditLogger.(*StoreAuditLogger); ok {
+			txService.AuditLogger = NewStoreAuditLogger(store)
+		}
+		return fn(txService)
+	})
+}
+
+func (service *RoleService) DeleteRole(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, roleName string) error {
+	return service.withTx(ctx, func(txService *RoleService) error {
+		role, err := txService.getRole(ctx, roleName)
+		if err != nil {
+			return err
+		}
+		err = txService.RoleStore.DeleteRole(ctx, role.ID)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		txService.AuditLogger.LogAuditEvent(ctx, AuditEntry{
+			ActorId:    actorId,
+			TargetId:   role.ID,
+			Action:     AdminRoleDeletedAction,
+			ClientInfo: clientInfo,
+			Metadata:   map[string]interface{}{"role": roleName},
+		})
+		return nil
+	})
+}
+
+func (service *RoleService) GetRolePermissions(ctx context.Context, roleName string) ([]string, error) {
+	role, err := service.getRole(ctx, roleName)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := service.RoleStore.ListRolePermissions(ctx, role.ID)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// AssignUserRole grants the role to the user, assigning a role the user already has is not an error.
+func (service *RoleService) AssignUserRole(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID, roleName string) error {
+	return service.withTx(ctx, func(txService *RoleService) error {
+		role, err := txService.getRole(ctx, roleName)
+		if err != nil {
+			return err
+		}
+		err = txService.RoleStore.AssignUserRole(ctx, repository.AssignUserRoleParams{
+			UserID: userId,
+			RoleID: role.ID,
+		})
+		if err != nil {
+			var dbErr *pq.Error
+			if errors.As(err, &dbErr) && dbErr.Code.Name() == "foreign_key_violation" {
+				return &repository.NotFoundError{Key: "User not found."}
+			}
+			log.Error(err)
+			return err
+		}
+
+		txService.AuditLogger.LogAuditEvent(ctx, AuditEntry{
+			ActorId:    actorId,
+			TargetId:   userId,
+			Action:     AdminUserRoleAssignedAction,
+			ClientInfo: clientInfo,
+			Metadata:   map[string]interface{}{"role": roleName},
+		})
+		return nil
+	})
+}
+
+func (service *RoleService) RevokeUserRole(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID, roleName string) error {
+	return service.withTx(ctx, func(txService *RoleService) error {
+		role, err := txService.getRole(ctx, roleName)
+		if err != nil {
+			return err
+		}
+		err = txService.RoleStore.RevokeUserRole(ctx, repository.RevokeUserRoleParams{
+			UserID: userId,
+			RoleID: role.ID,
+		})
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		txService.AuditLogger.LogAuditEvent(ctx, AuditEntry{
+			ActorId:    actorId,
+			TargetId:   userId,
+			Action:     AdminUserRoleRevokedAction,
+			ClientInfo: clientInfo,
+			Metadata:   map[string]interface{}{"role": roleName},
+		})
+		return nil
+	})
+}
+
+func (service *RoleService) ListUserRoles(ctx context.Context, userId uuid.UUID) ([]repository.Role, error) {
+	roles, err := service.RoleStore.ListUserRoles(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (service *RoleService) getRole(ctx context.Context, roleName string) (repository.Role, error) {
+	role, err := service.RoleStore.GetRoleByName(ctx, roleName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.Role{}, &repository.NotFoundError{Key: "Role not found."}
+		}
+		log.Error(err)
+		return repository.Role{}, err
+	}
+	return role, nil
+}
+
+func (service *RoleService) addRolePermission(ctx context.Context, roleId uuid.UUID, permission string) error {
+	err := service.RoleStore.AddRolePermission(ctx, repository.AddRolePermissionParams{
+		RoleID:     roleId,
+		Permission: permission,
+	})
+	if err != nil {
+		var dbErr *pq.Error
+		if errors.As(err, &dbErr) && dbErr.Code.Name() == "foreign_key_violation" {
+			return &repository.NotFoundError{Key: "Permission not found."}
+		}
+		log.Error(err)
+		return err
+	}
+	return nil
+}
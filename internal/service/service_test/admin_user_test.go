@@ -0,0 +1,246 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/service"
+	"eau-de-go/pkg/email_util"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+var adminClientInfo = repository.ClientInfo{UserAgent: "test-agent", IpAddress: "127.0.0.1"}
+
+func TestAdminGetUserNotFound(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, userId).Return(repository.AppUser{}, sql.ErrNoRows)
+	mockAuditStore := new(MockAuditEventStore)
+
+	s := service.NewAdminUserService(&service.AppUserService{AppUserStore: mockStore}, service.NewStoreAuditLogger(mockAuditStore))
+
+	_, err := s.GetUser(ctx, uuid.New(), adminClientInfo, userId)
+
+	assert.IsType(t, &repository.NotFoundError{}, err)
+	mockAuditStore.AssertNotCalled(t, "CreateAuditEvent", mock.Anything, mock.Anything)
+}
+
+func TestAdminDeactivateUser(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	userId := uuid.New()
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("DeactivateUser", ctx, userId).Return(repository.AppUser{ID: userId, IsActive: false}, nil)
+	mockRefreshTokenStore := new(MockRefreshTokenStore)
+	mockRefreshTokenStore.On("RevokeAllUserRefreshTokens", ctx, userId).Return(nil)
+	mockSessionStore := new(MockUserSessionStore)
+	mockSessionStore.On("RevokeAllUserSessions", ctx, userId).Return(nil)
+	mockAuditStore := new(MockAuditEventStore)
+	mockAuditStore.On("CreateAuditEvent", ctx, matchAuditEvent(actorId, userId, service.AdminUserDeactivatedAction)).Return(repository.AuditEvent{}, nil)
+
+	s := service.NewAdminUserService(&service.AppUserService{AppUserStore: mockStore, RefreshTokenStore: mockRefreshTokenStore, UserSessionStore: mockSessionStore}, service.NewStoreAuditLogger(mockAuditStore))
+
+	result, err := s.DeactivateUser(ctx, actorId, adminClientInfo, userId)
+
+	assert.NoError(t, err)
+	assert.False(t, result.IsActive)
+	mockStore.AssertExpectations(t)
+	mockRefreshTokenStore.AssertExpectations(t)
+	mockSessionStore.AssertExpectations(t)
+	mockAuditStore.AssertExpectations(t)
+}
+
+func TestAdminDeactivateUser_WithinTransaction(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	userId := uuid.New()
+
+	txStore := new(MockAppUserStore)
+	txStore.On("DeactivateUser", ctx, userId).Return(repository.AppUser{ID: userId, IsActive: false}, nil)
+	txRefreshTokenStore := new(MockRefreshTokenStore)
+	txRefreshTokenStore.On("RevokeAllUserRefreshTokens", ctx, userId).Return(nil)
+	txSessionStore := new(MockUserSessionStore)
+	txSessionStore.On("RevokeAllUserSessions", ctx, userId).Return(nil)
+	txAuditStore := new(MockAuditEventStore)
+	txAuditStore.On("CreateAuditEvent", ctx, matchAuditEvent(actorId, userId, service.AdminUserDeactivatedAction)).Return(repository.AuditEvent{}, nil)
+	transactor := &mockTransactor{store: &mockTxStore{
+		mockAppUserServiceStore: mockAppUserServiceStore{
+			MockAppUserStore:      txStore,
+			MockRefreshTokenStore: txRefreshTokenStore,
+			MockUserSessionStore:  txSessionStore,
+		},
+		MockAuditEventStore: txAuditStore,
+	}}
+	mockStore := new(MockAppUserStore)
+	auditStore := new(MockAuditEventStore)
+
+	s := service.NewAdminUserService(&service.AppUserService{AppUserStore: mockStore, Transactor: transactor}, service.NewStoreAuditLogger(auditStore))
+
+	_, err := s.DeactivateUser(ctx, actorId, adminClientInfo, userId)
+
+	assert.NoError(t, err)
+	assert.False(t, transactor.rolledBack)
+	txStore.AssertExpectations(t)
+	txRefreshTokenStore.AssertExpectations(t)
+	txSessionStore.AssertExpectations(t)
+	txAuditStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "DeactivateUser", mock.Anything, mock.Anything)
+	auditStore.AssertNotCalled(t, "CreateAuditEvent", mock.Anything, mock.Anything)
+}
+
+func TestAdminDeactivateUser_RollsBackWhenRevokingTokensFails(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	userId := uuid.New()
+
+	txStore := new(MockAppUserStore)
+	txStore.On("DeactivateUser", ctx, userId).Return(repository.AppUser{ID: userId, IsActive: false}, nil)
+	txRefreshTokenStore := new(MockRefreshTokenStore)
+	txRefreshTokenStore.On("RevokeAllUserRefreshTokens", ctx, userId).Return(errors.New("revoke error"))
+	txAuditStore := new(MockAuditEventStore)
+	transactor := &mockTransactor{store: &mockTxStore{
+		mockAppUserServiceStore: mockAppUserServiceStore{
+			MockAppUserStore:      txStore,
+			MockRefreshTokenStore: txRefreshTokenStore,
+		},
+		MockAuditEventStore: txAuditStore,
+	}}
+
+	s := service.NewAdminUserService(&service.AppUserService{AppUserStore: new(MockAppUserStore), Transactor: transactor}, service.NewStoreAuditLogger(new(MockAuditEventStore)))
+
+	_, err := s.DeactivateUser(ctx, actorId, adminClientInfo, userId)
+
+	assert.Error(t, err)
+	assert.True(t, transactor.rolledBack, "Expected the deactivation to be rolled back along with the token revocation")
+	txAuditStore.AssertNotCalled(t, "CreateAuditEvent", mock.Anything, mock.Anything)
+}
+
+func TestAdminDeactivateOwnAccount(t *testing.T) {
+	actorId := uuid.New()
+	mockStore := new(MockAppUserStore)
+
+	s := service.NewAdminUserService(&service.AppUserService{AppUserStore: mockStore}, service.NewStoreAuditLogger(new(MockAuditEventStore)))
+
+	_, err := s.DeactivateUser(context.Background(), actorId, adminClientInfo, actorId)
+
+	assert.IsType(t, &repository.SelfAdministrationError{}, err)
+	mockStore.AssertNotCalled(t, "DeactivateUser", mock.Anything, mock.Anything)
+}
+
+func TestAdminAuditFailureDoesNotFailAction(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	userId := uuid.New()
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("ActivateUser", ctx, userId).Return(repository.AppUser{ID: userId, IsActive: true}, nil)
+	mockAuditStore := new(MockAuditEventStore)
+	mockAuditStore.On("CreateAuditEvent", ctx, mock.Anything).Return(repository.AuditEvent{}, sql.ErrConnDone)
+
+	s := service.NewAdminUserService(&service.AppUserService{AppUserStore: mockStore}, service.NewStoreAuditLogger(mockAuditStore))
+
+	result, err := s.ActivateUser(ctx, actorId, adminClientInfo, userId)
+
+	assert.NoError(t, err)
+	assert.True(t, result.IsActive)
+}
+
+func TestAdminResetUserPassword(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	appUser := repository.AppUser{ID: uuid.New(), Email: "test@example.com", IsActive: true}
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, appUser.ID).Return(appUser, nil)
+	mockRefreshTokenStore := new(MockRefreshTokenStore)
+	mockRefreshTokenStore.On("RevokeAllUserRefreshTokens", ctx, appUser.ID).Return(nil)
+	mockSessionStore := new(MockUserSessionStore)
+	mockSessionStore.On("RevokeAllUserSessions", ctx, appUser.ID).Return(nil)
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("InvalidateUserVerificationTokens", ctx, mock.Anything).Return(nil)
+	mockTokenStore.On("CreateVerificationToken", ctx, mock.MatchedBy(func(arg repository.CreateVerificationTokenParams) bool {
+		return arg.UserID == appUser.ID && arg.Purpose == service.PasswordResetPurpose
+	})).Return(repository.VerificationToken{}, nil)
+	mockSender := new(MockEmailSender)
+	mockSender.On("SendTemplatedEmail", appUser.Email, email_util.PasswordResetEmailTemplate, mock.Anything).Return(nil)
+	mockAuditStore := new(MockAuditEventStore)
+	mockAuditStore.On("CreateAuditEvent", ctx, matchAuditEvent(actorId, appUser.ID, service.AdminPasswordResetAction)).Return(repository.AuditEvent{}, nil)
+
+	s := service.NewAdminUserService(&service.AppUserService{
+		AppUserStore:           mockStore,
+		RefreshTokenStore:      mockRefreshTokenStore,
+		UserSessionStore:       mockSessionStore,
+		VerificationTokenStore: mockTokenStore,
+		EmailSender:            mockSender,
+	}, service.NewStoreAuditLogger(mockAuditStore))
+
+	err := s.ResetUserPassword(ctx, actorId, adminClientInfo, appUser.ID)
+
+	assert.NoError(t, err)
+	mockRefreshTokenStore.AssertExpectations(t)
+	mockTokenStore.AssertExpectations(t)
+	mockSender.AssertExpectations(t)
+	mockAuditStore.AssertExpectations(t)
+}
+
+func TestAdminSetUserStaffStatus(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	userId := uuid.New()
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("SetUserStaffStatus", ctx, repository.SetUserStaffStatusParams{IsStaff: true, ID: userId}).Return(repository.AppUser{ID: userId, IsStaff: true}, nil)
+	mockAuditStore := new(MockAuditEventStore)
+	mockAuditStore.On("CreateAuditEvent", ctx, mock.MatchedBy(func(arg repository.CreateAuditEventParams) bool {
+		return arg.Action == service.AdminStaffStatusChangeAction && string(arg.Metadata) == `{"is_staff":true}`
+	})).Return(repository.AuditEvent{}, nil)
+
+	s := service.NewAdminUserService(&service.AppUserService{AppUserStore: mockStore}, service.NewStoreAuditLogger(mockAuditStore))
+
+	result, err := s.SetUserStaffStatus(ctx, actorId, adminClientInfo, userId, true)
+
+	assert.NoError(t, err)
+	assert.True(t, result.IsStaff)
+	mockAuditStore.AssertExpectations(t)
+}
+
+func TestAdminRevokeOwnStaffStatus(t *testing.T) {
+	actorId := uuid.New()
+	mockStore := new(MockAppUserStore)
+
+	s := service.NewAdminUserService(&service.AppUserService{AppUserStore: mockStore}, service.NewStoreAuditLogger(new(MockAuditEventStore)))
+
+	_, err := s.SetUserStaffStatus(context.Background(), actorId, adminClientInfo, actorId, false)
+
+	assert.IsType(t, &repository.SelfAdministrationError{}, err)
+	mockStore.AssertNotCalled(t, "SetUserStaffStatus", mock.Anything, mock.Anything)
+}
+
+func TestAdminUnlockUser(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	user := repository.AppUser{ID: uuid.New()}
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, user.ID).Return(user, nil)
+	mockThrottleStore := new(MockLoginThrottleStore)
+	mockThrottleStore.On("DeleteAccountLoginFailure", ctx, user.ID).Return(nil)
+	mockAuditLogger := new(MockAuditLogger)
+	mockAuditLogger.On("LogAuditEvent", ctx, matchAuditEntry(actorId, user.ID, service.AdminUserUnlockedAction)).Return()
+
+	s := service.NewAdminUserService(&service.AppUserService{AppUserStore: mockStore, LoginThrottleStore: mockThrottleStore}, mockAuditLogger)
+
+	result, err := s.UnlockUser(ctx, actorId, adminClientInfo, user.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, result.ID)
+	mockThrottleStore.AssertExpectations(t)
+	mockAuditLogger.AssertExpectations(t)
+}
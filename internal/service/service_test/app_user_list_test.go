@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"context"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/service"
+	"eau-de-go/pkg/pagination"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func newTestUsers(count int) []repository.AppUser {
+	joined := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	users := make([]repository.AppUser, 0, count)
+	for i := 0; i < count; i++ {
+		users = append(users, repository.AppUser{ID: uuid.New(), Username: "user" + string(rune('a'+i)), DateJoined: joined.Add(-time.Duration(i) * time.Hour)})
+	}
+	return users
+}
+
+func TestAdminListUsersFirstPage(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	isActive := true
+	users := newTestUsers(3)
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("ListAppUserPageByDateJoinedDesc", ctx, mock.MatchedBy(func(arg repository.ListAppUserPageByDateJoinedDescParams) bool {
+		return arg.IsActive.Valid && arg.IsActive.Bool &&
+			!arg.IsStaff.Valid &&
+			arg.Search.String == `jo\_%` &&
+			!arg.AfterID.Valid &&
+			arg.PageSize == 3
+	})).Return(users, nil)
+	mockAuditStore := new(MockAuditEventStore)
+	mockAuditStore.On("CreateAuditEvent", ctx, matchAuditEvent(actorId, uuid.Nil, service.AdminUsersListedAction)).Return(repository.AuditEvent{}, nil)
+
+	s := service.NewAdminUserService(&service.AppUserService{AppUserStore: mockStore}, service.NewStoreAuditLogger(mockAuditStore))
+
+	page, err := s.ListUsers(ctx, actorId, adminClientInfo, repository.AppUserFilter{IsActive: &isActive, Search: "jo_"}, pagination.PageRequest{Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, users[:2], page.Items)
+	assert.NotEmpty(t, page.NextCursor)
+	mockStore.AssertExpectations(t)
+	mockAuditStore.AssertExpectations(t)
+}
+
+func TestAdminListUsersNextPage(t *testing.T) {
+	ctx := context.Background()
+	users := newTestUsers(3)
+	codec := pagination.NewCursorCodec(nil)
+	cursor, _ := codec.Encode(pagination.Cursor{Sort: "username", Key: users[1].Username, ID: users[1].ID})
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("ListAppUserPageByUsernameAsc", ctx, mock.MatchedBy(func(arg repository.ListAppUserPageByUsernameAscParams) bool {
+		return arg.AfterID.UUID == users[1].ID &&
+			arg.AfterUsername.String == users[1].Username
+	})).Return(users[2:], nil)
+	mockAuditStore := new(MockAuditEventStore)
+	mockAuditStore.On("CreateAuditEvent", ctx, mock.Anything).Return(repository.AuditEvent{}, nil)
+
+	s := service.NewAdminUserService(&service.AppUserService{AppUserStore: mockStore}, service.NewStoreAuditLogger(mockAuditStore))
+
+	page, err := s.ListUsers(ctx, uuid.New(), adminClientInfo, repository.AppUserFilter{}, pagination.PageRequest{Cursor: cursor, Limit: 2, Sort: "username"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, users[2:], page.Items)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestAdminListUsersCursorOfOtherSort(t *testing.T) {
+	codec := pagination.NewCursorCodec(nil)
+	cursor, _ := codec.Encode(pagination.Cursor{Sort: "username", Key: "john", ID: uuid.New()})
+	mockStore := new(MockAppUserStore)
+
+	s := service.NewAdminUserService(&service.AppUserService{AppUserStore: mockStore}, service.NewStoreAuditLogger(new(MockAuditEventStore)))
+
+	_, err := s.ListUsers(context.Background(), uuid.New(), adminClientInfo, repository.AppUserFilter{}, pagination.PageRequest{Cursor: cursor, Sort: "-date_joined"})
+
+	assert.IsType(t, &pagination.InvalidCursorError{}, err)
+	mockStore.AssertNotCalled(t, "ListAppUserPageByDateJoinedDesc", mock.Anything, mock.Anything)
+}
+
+func TestAdminListUsersInvalidSort(t *testing.T) {
+	s := service.NewAdminUserService(&service.AppUserService{AppUserStore: new(MockAppUserStore)}, service.NewStoreAuditLogger(new(MockAuditEventStore)))
+
+	_, err := s.ListUsers(context.Background(), uuid.New(), adminClientInfo, repository.AppUserFilter{}, pagination.PageRequest{Sort: "password"})
+
+	assert.IsType(t, &pagination.InvalidPageRequestError{}, err)
+}
@@ -8,12 +8,16 @@ import (
 	"eau-de-go/pkg/email_util"
 	"eau-de-go/pkg/jwt_util"
 	"eau-de-go/pkg/password_util"
+	"eau-de-go/pkg/username_util"
 	"errors"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 )
 
 type MockAppUserStore struct {
@@ -25,6 +29,16 @@ func (m *MockAppUserStore) UpdateAppUser(ctx context.Context, appUser repository
 	return args.Get(0).(repository.AppUser), args.Error(1)
 }
 
+func (m *MockAppUserStore) UpdateAppUserEmail(ctx context.Context, arg repository.UpdateAppUserEmailParams) (repository.AppUser, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.AppUser), args.Error(1)
+}
+
+func (m *MockAppUserStore) UpdateAppUserUsername(ctx context.Context, arg repository.UpdateAppUserUsernameParams) (repository.AppUser, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.AppUser), args.Error(1)
+}
+
 func (m *MockAppUserStore) UpdateAppUserPassword(ctx context.Context, appUser repository.UpdateAppUserPasswordParams) (repository.AppUser, error) {
 	args := m.Called(ctx, appUser)
 	return args.Get(0).(repository.AppUser), args.Error(1)
@@ -35,6 +49,11 @@ func (m *MockAppUserStore) GetAppUserByUsername(ctx context.Context, username st
 	return args.Get(0).(repository.AppUser), args.Error(1)
 }
 
+func (m *MockAppUserStore) GetAppUserByEmailAddr(ctx context.Context, email string) (repository.AppUser, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).(repository.AppUser), args.Error(1)
+}
+
 func (m *MockAppUserStore) GetAppUserById(ctx context.Context, id uuid.UUID) (repository.AppUser, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(repository.AppUser), args.Error(1)
@@ -55,23 +74,55 @@ func (m *MockAppUserStore) SetUserEmailUnverified(ctx context.Context, userId uu
 	return args.Get(0).(repository.AppUser), args.Error(1)
 }
 
-func (m *MockAppUserStore) UpdateAppUserLastLoginNow(ctx context.Context, userId uuid.UUID) (repository.AppUser, error) {
+func (m *MockAppUserStore) SetUserStaffStatus(ctx context.Context, arg repository.SetUserStaffStatusParams) (repository.AppUser, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.AppUser), args.Error(1)
+}
+
+func (m *MockAppUserStore) ActivateUser(ctx context.Context, userId uuid.UUID) (repository.AppUser, error) {
 	args := m.Called(ctx, userId)
 	return args.Get(0).(repository.AppUser), args.Error(1)
 }
 
-type MockEmailVerifier struct {
-	mock.Mock
+func (m *MockAppUserStore) DeactivateUser(ctx context.Context, userId uuid.UUID) (repository.AppUser, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).(repository.AppUser), args.Error(1)
+}
+
+func (m *MockAppUserStore) ListAppUserPageByDateJoinedAsc(ctx context.Context, arg repository.ListAppUserPageByDateJoinedAscParams) ([]repository.AppUser, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]repository.AppUser), args.Error(1)
 }
 
-func (m *MockEmailVerifier) CreateToken(email string) (string, error) {
-	args := m.Called(email)
-	return args.String(0), args.Error(1)
+func (m *MockAppUserStore) ListAppUserPageByDateJoinedDesc(ctx context.Context, arg repository.ListAppUserPageByDateJoinedDescParams) ([]repository.AppUser, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]repository.AppUser), args.Error(1)
 }
 
-func (m *MockEmailVerifier) VerifyToken(tokenString string) (string, error) {
-	args := m.Called(tokenString)
-	return args.String(0), args.Error(1)
+func (m *MockAppUserStore) ListAppUserPageByUsernameAsc(ctx context.Context, arg repository.ListAppUserPageByUsernameAscParams) ([]repository.AppUser, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]repository.AppUser), args.Error(1)
+}
+
+func (m *MockAppUserStore) ListAppUserPageByUsernameDesc(ctx context.Context, arg repository.ListAppUserPageByUsernameDescParams) ([]repository.AppUser, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]repository.AppUser), args.Error(1)
+}
+
+func (m *MockAppUserStore) UpdateAppUserLastLoginNow(ctx context.Context, userId uuid.UUID) (repository.AppUser, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).(repository.AppUser), args.Error(1)
+}
+
+// mockAppUserServiceStore composes the mock stores into the store of NewAppUserService.
+type mockAppUserServiceStore struct {
+	*MockAppUserStore
+	*MockRefreshTokenStore
+	*MockUserSessionStore
+	*MockVerificationTokenStore
+	*MockUsernameHistoryStore
+	*MockRoleStore
+	*MockLoginThrottleStore
 }
 
 type MockEmailSender struct {
@@ -83,6 +134,11 @@ func (m *MockEmailSender) makeMailBytes(toAddresses []string, mailSubject string
 	return args.Get(0).([]byte)
 }
 
+func (m *MockEmailSender) Send(message email_util.Message) error {
+	args := m.Called(message)
+	return args.Error(0)
+}
+
 func (m *MockEmailSender) SendSingleEmail(recipientEmail string, mailSubject string, mailBody string) error {
 	args := m.Called(recipientEmail, mailSubject, mailBody)
 	return args.Error(0)
@@ -93,12 +149,17 @@ func (m *MockEmailSender) SendMassEmail(recipientEmails []string, mailSubject st
 	return args.Error(0)
 }
 
+func (m *MockEmailSender) SendTemplatedEmail(recipientEmail string, templateName string, data email_util.TemplateData) error {
+	args := m.Called(recipientEmail, templateName, data)
+	return args.Error(0)
+}
+
 type MockJwtUtil struct {
 	mock.Mock
 }
 
-func (m *MockJwtUtil) DecodeToken(tokenType jwt_util.TokenType, token string) (map[string]interface{}, error) {
-	args := m.Called(tokenType, token)
+func (m *MockJwtUtil) DecodeToken(ctx context.Context, tokenType jwt_util.TokenType, token string) (map[string]interface{}, error) {
+	args := m.Called(ctx, tokenType, token)
 	return args.Get(0).(map[string]interface{}), args.Error(1)
 }
 
@@ -119,7 +180,8 @@ func (m *MockJwtUtil) CopyTokenClaims(claims map[string]interface{}) map[string]
 
 func TestCreateAppUser(t *testing.T) {
 	mockStore := new(MockAppUserStore)
-	aps := service.NewAppUserService(mockStore)
+	mockHistoryStore := new(MockUsernameHistoryStore)
+	aps := service.NewAppUserService(&mockAppUserServiceStore{MockAppUserStore: mockStore, MockUsernameHistoryStore: mockHistoryStore}, nil)
 	service.HashPasswordFunc = func(password string) ([]byte, error) {
 		return []byte(password), nil
 	}
@@ -130,17 +192,70 @@ func TestCreateAppUser(t *testing.T) {
 		Email:    "testuser@example.com",
 	}
 
-	mockStore.On("CreateAppUser", mock.Anything, userParams).Return(repository.AppUser{}, nil)
+	mockStore.On("GetAppUserByUsername", mock.Anything, userParams.Username).Return(repository.AppUser{}, sql.ErrNoRows)
+	mockHistoryStore.On("IsUsernameReserved", mock.Anything, mock.MatchedBy(func(arg repository.IsUsernameReservedParams) bool {
+		return arg.Username == userParams.Username && arg.UserID == uuid.Nil
+	})).Return(false, nil)
+	mockStore.On("CreateAppUser", mock.Anything, userParams).Return(repository.AppUser{Email: userParams.Email}, nil)
+	mockSender := new(MockEmailSender)
+	mockSender.On("SendTemplatedEmail", userParams.Email, email_util.WelcomeEmailTemplate, mock.Anything).Return(nil)
+	aps.EmailSender = mockSender
 
 	_, err := aps.CreateAppUser(context.Background(), userParams)
 	assert.NoError(t, err)
 
 	mockStore.AssertExpectations(t)
+	mockSender.AssertExpectations(t)
+}
+
+func TestCreateAppUserWithoutUsernameCharsetRules(t *testing.T) {
+	mockStore := new(MockAppUserStore)
+	mockHistoryStore := new(MockUsernameHistoryStore)
+	aps := service.NewAppUserService(&mockAppUserServiceStore{MockAppUserStore: mockStore, MockUsernameHistoryStore: mockHistoryStore}, nil)
+	service.HashPasswordFunc = func(password string) ([]byte, error) {
+		return []byte(password), nil
+	}
+
+	userParams := repository.CreateAppUserParams{
+		Username: "john@example.com",
+		Password: "testPassword",
+		Email:    "john@example.com",
+	}
+
+	mockStore.On("GetAppUserByUsername", mock.Anything, userParams.Username).Return(repository.AppUser{}, sql.ErrNoRows)
+	mockHistoryStore.On("IsUsernameReserved", mock.Anything, mock.Anything).Return(false, nil)
+	mockStore.On("CreateAppUser", mock.Anything, userParams).Return(repository.AppUser{Email: userParams.Email}, nil)
+	mockSender := new(MockEmailSender)
+	mockSender.On("SendTemplatedEmail", userParams.Email, email_util.WelcomeEmailTemplate, mock.Anything).Return(nil)
+	aps.EmailSender = mockSender
+
+	_, err := aps.CreateAppUser(context.Background(), userParams)
+
+	assert.NoError(t, err, "Expected the username change rules not to apply at sign-up")
+	mockStore.AssertExpectations(t)
+}
+
+func TestCreateAppUserReservedUsername(t *testing.T) {
+	mockStore := new(MockAppUserStore)
+	aps := service.NewAppUserService(&mockAppUserServiceStore{MockAppUserStore: mockStore}, nil)
+	service.HashPasswordFunc = func(password string) ([]byte, error) {
+		return []byte(password), nil
+	}
+
+	_, err := aps.CreateAppUser(context.Background(), repository.CreateAppUserParams{
+		Username: "Admin",
+		Password: "testPassword",
+		Email:    "admin@example.com",
+	})
+
+	var invalidUsernameError *username_util.InvalidUsernameError
+	assert.ErrorAs(t, err, &invalidUsernameError)
+	mockStore.AssertNotCalled(t, "CreateAppUser", mock.Anything, mock.Anything)
 }
 
 func TestGetAppUserById(t *testing.T) {
 	mockStore := new(MockAppUserStore)
-	aps := service.NewAppUserService(mockStore)
+	aps := service.NewAppUserService(&mockAppUserServiceStore{MockAppUserStore: mockStore}, nil)
 
 	id := uuid.New()
 	mockStore.On("GetAppUserById", mock.Anything, id).Return(repository.AppUser{}, nil)
@@ -153,7 +268,7 @@ func TestGetAppUserById(t *testing.T) {
 
 func TestUpdateAppUser(t *testing.T) {
 	mockStore := new(MockAppUserStore)
-	aps := service.NewAppUserService(mockStore)
+	aps := service.NewAppUserService(&mockAppUserServiceStore{MockAppUserStore: mockStore}, nil)
 
 	userParams := repository.UpdateAppUserParams{
 		ID:       uuid.New(),
@@ -170,7 +285,7 @@ func TestUpdateAppUser(t *testing.T) {
 
 func TestUpdateAppUserPassword(t *testing.T) {
 	mockStore := new(MockAppUserStore)
-	aps := service.NewAppUserService(mockStore)
+	aps := service.NewAppUserService(&mockAppUserServiceStore{MockAppUserStore: mockStore}, nil)
 
 	id := uuid.New()
 	oldPassword := "oldPassword"
@@ -178,17 +293,21 @@ func TestUpdateAppUserPassword(t *testing.T) {
 	newPassword := "newPassword"
 
 	mockStore.On("GetAppUserById", mock.Anything, id).Return(repository.AppUser{Password: string(hashedOldPassword)}, nil)
-	mockStore.On("UpdateAppUserPassword", mock.Anything, mock.AnythingOfType("repository.UpdateAppUserPasswordParams")).Return(repository.AppUser{}, nil)
+	mockStore.On("UpdateAppUserPassword", mock.Anything, mock.AnythingOfType("repository.UpdateAppUserPasswordParams")).Return(repository.AppUser{Email: "test@example.com"}, nil)
+	mockSender := new(MockEmailSender)
+	mockSender.On("SendTemplatedEmail", "test@example.com", email_util.SecurityAlertEmailTemplate, mock.Anything).Return(nil)
+	aps.EmailSender = mockSender
 
-	_, err := aps.UpdateAppUserPassword(context.Background(), id, oldPassword, newPassword)
+	_, err := aps.UpdateAppUserPassword(context.Background(), id, oldPassword, newPassword, repository.ClientInfo{})
 	assert.NoError(t, err)
 
 	mockStore.AssertExpectations(t)
+	mockSender.AssertExpectations(t)
 }
 
 func TestLoginWithValidCredentials(t *testing.T) {
 	mockStore := new(MockAppUserStore)
-	s := service.AppUserService{AppUserStore: mockStore}
+	s := service.AppUserService{AppUserStore: mockStore, LoginThrottleStore: newMockLoginThrottleStore()}
 	username := "user"
 	password := "P4ssword!123"
 	passwordHash, err := password_util.HashPassword(password)
@@ -198,7 +317,7 @@ func TestLoginWithValidCredentials(t *testing.T) {
 	mockStore.On("GetAppUserByUsername", mock.Anything, username).Return(user, nil)
 	mockStore.On("UpdateAppUserLastLoginNow", mock.Anything, user.ID).Return(user, nil)
 
-	result, err := s.Login(context.Background(), username, password)
+	result, err := s.Login(context.Background(), username, password, repository.ClientInfo{})
 
 	assert.NoError(t, err)
 	assert.Equal(t, username, result.Username)
@@ -207,11 +326,11 @@ func TestLoginWithValidCredentials(t *testing.T) {
 
 func TestLoginWithInvalidUsername(t *testing.T) {
 	mockStore := new(MockAppUserStore)
-	s := service.AppUserService{AppUserStore: mockStore}
+	s := service.AppUserService{AppUserStore: mockStore, LoginThrottleStore: newMockLoginThrottleStore()}
 
 	mockStore.On("GetAppUserByUsername", mock.Anything, "invalid").Return(repository.AppUser{}, errors.New("user not found"))
 
-	_, err := s.Login(context.Background(), "invalid", "password")
+	_, err := s.Login(context.Background(), "invalid", "password", repository.ClientInfo{})
 
 	assert.Error(t, err)
 	mockStore.AssertExpectations(t)
@@ -220,7 +339,7 @@ func TestLoginWithInvalidUsername(t *testing.T) {
 
 func TestLoginWithInvalidPassword(t *testing.T) {
 	mockStore := new(MockAppUserStore)
-	s := service.AppUserService{AppUserStore: mockStore}
+	s := service.AppUserService{AppUserStore: mockStore, LoginThrottleStore: newMockLoginThrottleStore()}
 	username := "user"
 	password := "P4ssword!123"
 	passwordHash, err := password_util.HashPassword(password)
@@ -229,7 +348,7 @@ func TestLoginWithInvalidPassword(t *testing.T) {
 	user := repository.AppUser{ID: uuid.New(), Username: username, Password: string(passwordHash)}
 	mockStore.On("GetAppUserByUsername", mock.Anything, username).Return(user, nil)
 
-	result, err := s.Login(context.Background(), username, "wrong password")
+	result, err := s.Login(context.Background(), username, "wrong password", repository.ClientInfo{})
 
 	assert.Empty(t, result)
 	assert.Error(t, err)
@@ -239,7 +358,7 @@ func TestLoginWithInvalidPassword(t *testing.T) {
 
 func TestInactiveUserCannotLogIn(t *testing.T) {
 	mockStore := new(MockAppUserStore)
-	s := service.AppUserService{AppUserStore: mockStore}
+	s := service.AppUserService{AppUserStore: mockStore, LoginThrottleStore: newMockLoginThrottleStore()}
 	username := "user"
 	password := "P4ssword!123"
 	passwordHash, err := password_util.HashPassword(password)
@@ -249,7 +368,7 @@ func TestInactiveUserCannotLogIn(t *testing.T) {
 	mockStore.On("GetAppUserByUsername", mock.Anything, username).Return(user, nil)
 	mockStore.On("UpdateAppUserLastLoginNow", mock.Anything, user.ID).Return(user, nil)
 
-	result, err := s.Login(context.Background(), username, password)
+	result, err := s.Login(context.Background(), username, password, repository.ClientInfo{})
 
 	assert.Error(t, err)
 	assert.Empty(t, result)
@@ -258,19 +377,207 @@ func TestInactiveUserCannotLogIn(t *testing.T) {
 
 func TestRefreshToken(t *testing.T) {
 	mockStore := new(MockAppUserStore)
+	mockRefreshTokenStore := new(MockRefreshTokenStore)
+	mockSessionStore := new(MockUserSessionStore)
+	mockRoleStore := new(MockRoleStore)
 	mockJwtUtil := new(MockJwtUtil)
-	s := service.AppUserService{AppUserStore: mockStore, JwtUtil: mockJwtUtil}
+	s := service.AppUserService{AppUserStore: mockStore, RefreshTokenStore: mockRefreshTokenStore, UserSessionStore: mockSessionStore, RoleStore: mockRoleStore, JwtUtil: mockJwtUtil}
+	clientInfo := repository.ClientInfo{UserAgent: "test-agent", IpAddress: "127.0.0.1"}
 
 	user := repository.AppUser{ID: uuid.New(), Username: "test", IsActive: true}
+	jti := uuid.New()
+	familyId := uuid.New()
+	newJti := uuid.New()
+	newRefreshTokenClaims := map[string]interface{}{"jti": newJti.String(), "iat": int64(1707105923), "exp": int64(1707710723)}
 	mockStore.On("GetAppUserById", mock.Anything, user.ID).Return(user, nil)
 	mockStore.On("UpdateAppUserLastLoginNow", mock.Anything, user.ID).Return(user, nil)
-	mockJwtUtil.On("DecodeToken", jwt_util.Refresh, "validToken").Return(map[string]interface{}{"id": user.ID.String()}, nil)
+	mockJwtUtil.On("DecodeToken", mock.Anything, jwt_util.Refresh, "validToken").Return(map[string]interface{}{"id": user.ID.String(), "jti": jti.String()}, nil)
+	mockJwtUtil.On("CreateRefreshToken", mock.Anything).Return("newRefreshToken", newRefreshTokenClaims, nil)
+	mockRoleStore.On("ListUserPermissions", mock.Anything, user.ID).Return([]string{"users.read"}, nil)
+	mockJwtUtil.On("CreateAccessToken", mock.MatchedBy(func(claims map[string]interface{}) bool {
+		return assert.ObjectsAreEqual([]string{"users.read"}, claims["permissions"])
+	})).Return("newAccessToken", map[string]interface{}{}, nil)
+	mockRefreshTokenStore.On("RevokeRefreshToken", mock.Anything, jti).Return(repository.RefreshToken{Jti: jti, UserID: user.ID, FamilyID: familyId}, nil)
+	mockRefreshTokenStore.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(arg repository.CreateRefreshTokenParams) bool {
+		return arg.Jti == newJti && arg.UserID == user.ID && arg.FamilyID == familyId
+	})).Return(repository.RefreshToken{}, nil)
+	mockSessionStore.On("TouchUserSession", mock.Anything, repository.TouchUserSessionParams{ID: familyId, UserAgent: clientInfo.UserAgent, IpAddress: clientInfo.IpAddress}).Return(repository.UserSession{}, nil)
+
+	refreshToken, _, accessToken, _, _, err := s.RefreshToken(context.Background(), "validToken", clientInfo)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "newRefreshToken", refreshToken)
+	assert.Equal(t, "newAccessToken", accessToken)
+	mockStore.AssertExpectations(t)
+	mockRefreshTokenStore.AssertExpectations(t)
+	mockSessionStore.AssertExpectations(t)
+	mockJwtUtil.AssertExpectations(t)
+}
+
+func TestRefreshTokenReusedTokenRevokesFamily(t *testing.T) {
+	mockStore := new(MockAppUserStore)
+	mockRefreshTokenStore := new(MockRefreshTokenStore)
+	mockSessionStore := new(MockUserSessionStore)
+	mockJwtUtil := new(MockJwtUtil)
+	s := service.AppUserService{AppUserStore: mockStore, RefreshTokenStore: mockRefreshTokenStore, UserSessionStore: mockSessionStore, JwtUtil: mockJwtUtil}
+
+	userId := uuid.New()
+	jti := uuid.New()
+	familyId := uuid.New()
+	mockJwtUtil.On("DecodeToken", mock.Anything, jwt_util.Refresh, "reusedToken").Return(map[string]interface{}{}, &jwt_util.RevokedTokenError{Jti: jti.String()})
+	mockRefreshTokenStore.On("GetRefreshTokenByJti", mock.Anything, jti).Return(repository.RefreshToken{Jti: jti, UserID: userId, FamilyID: familyId}, nil)
+	mockSessionStore.On("RevokeUserSession", mock.Anything, repository.RevokeUserSessionParams{ID: familyId, UserID: userId}).Return(repository.UserSession{}, nil)
+	mockRefreshTokenStore.On("RevokeRefreshTokenFamily", mock.Anything, familyId).Return(nil)
+
+	_, _, _, _, _, err := s.RefreshToken(context.Background(), "reusedToken", repository.ClientInfo{})
+
+	assert.Error(t, err)
+	mockStore.AssertNotCalled(t, "GetAppUserById")
+	mockRefreshTokenStore.AssertExpectations(t)
+	mockSessionStore.AssertExpectations(t)
+	mockJwtUtil.AssertExpectations(t)
+}
+
+func TestRefreshTokenConcurrentRotationRevokesFamily(t *testing.T) {
+	mockStore := new(MockAppUserStore)
+	mockRefreshTokenStore := new(MockRefreshTokenStore)
+	mockSessionStore := new(MockUserSessionStore)
+	mockJwtUtil := new(MockJwtUtil)
+	s := service.AppUserService{AppUserStore: mockStore, RefreshTokenStore: mockRefreshTokenStore, UserSessionStore: mockSessionStore, JwtUtil: mockJwtUtil}
+
+	user := repository.AppUser{ID: uuid.New(), Username: "test", IsActive: true}
+	userId := user.ID
+	jti := uuid.New()
+	familyId := uuid.New()
+	mockStore.On("GetAppUserById", mock.Anything, user.ID).Return(user, nil)
+	mockJwtUtil.On("DecodeToken", mock.Anything, jwt_util.Refresh, "validToken").Return(map[string]interface{}{"id": user.ID.String(), "jti": jti.String()}, nil)
+	mockRefreshTokenStore.On("RevokeRefreshToken", mock.Anything, jti).Return(repository.RefreshToken{}, sql.ErrNoRows)
+	mockRefreshTokenStore.On("GetRefreshTokenByJti", mock.Anything, jti).Return(repository.RefreshToken{Jti: jti, UserID: userId, FamilyID: familyId}, nil)
+	mockSessionStore.On("RevokeUserSession", mock.Anything, repository.RevokeUserSessionParams{ID: familyId, UserID: userId}).Return(repository.UserSession{}, nil)
+	mockRefreshTokenStore.On("RevokeRefreshTokenFamily", mock.Anything, familyId).Return(nil)
+
+	_, _, _, _, _, err := s.RefreshToken(context.Background(), "validToken", repository.ClientInfo{})
+
+	assert.Error(t, err)
+	mockStore.AssertExpectations(t)
+	mockRefreshTokenStore.AssertExpectations(t)
+	mockSessionStore.AssertExpectations(t)
+	mockJwtUtil.AssertNotCalled(t, "CreateRefreshToken", mock.Anything)
+}
+
+func TestRefreshToken_WithinTransaction(t *testing.T) {
+	mockStore := new(MockAppUserStore)
+	mockJwtUtil := new(MockJwtUtil)
+	clientInfo := repository.ClientInfo{UserAgent: "test-agent", IpAddress: "127.0.0.1"}
+
+	user := repository.AppUser{ID: uuid.New(), Username: "test", IsActive: true}
+	jti := uuid.New()
+	familyId := uuid.New()
+	newJti := uuid.New()
+	newRefreshTokenClaims := map[string]interface{}{"jti": newJti.String(), "iat": int64(1707105923), "exp": int64(1707710723)}
+	mockStore.On("GetAppUserById", mock.Anything, user.ID).Return(user, nil)
+	mockStore.On("UpdateAppUserLastLoginNow", mock.Anything, user.ID).Return(user, nil)
+	mockJwtUtil.On("DecodeToken", mock.Anything, jwt_util.Refresh, "validToken").Return(map[string]interface{}{"id": user.ID.String(), "jti": jti.String()}, nil)
+	mockJwtUtil.On("CreateRefreshToken", mock.Anything).Return("newRefreshToken", newRefreshTokenClaims, nil)
 	mockJwtUtil.On("CreateAccessToken", mock.Anything).Return("newAccessToken", map[string]interface{}{}, nil)
 
-	_, _, _, err := s.RefreshToken(context.Background(), "validToken")
+	txRefreshTokenStore := new(MockRefreshTokenStore)
+	txRefreshTokenStore.On("RevokeRefreshToken", mock.Anything, jti).Return(repository.RefreshToken{Jti: jti, UserID: user.ID, FamilyID: familyId}, nil)
+	txRefreshTokenStore.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(arg repository.CreateRefreshTokenParams) bool {
+		return arg.Jti == newJti && arg.FamilyID == familyId
+	})).Return(repository.RefreshToken{}, nil)
+	txSessionStore := new(MockUserSessionStore)
+	txSessionStore.On("TouchUserSession", mock.Anything, repository.TouchUserSessionParams{ID: familyId, UserAgent: clientInfo.UserAgent, IpAddress: clientInfo.IpAddress}).Return(repository.UserSession{}, nil)
+	txRoleStore := new(MockRoleStore)
+	txRoleStore.On("ListUserPermissions", mock.Anything, user.ID).Return([]string{}, nil)
+	transactor := &mockTransactor{store: &mockTxStore{
+		mockAppUserServiceStore: mockAppUserServiceStore{
+			MockRefreshTokenStore: txRefreshTokenStore,
+			MockUserSessionStore:  txSessionStore,
+			MockRoleStore:         txRoleStore,
+		},
+	}}
+	mockRefreshTokenStore := new(MockRefreshTokenStore)
+	s := service.AppUserService{AppUserStore: mockStore, RefreshTokenStore: mockRefreshTokenStore, JwtUtil: mockJwtUtil, Transactor: transactor}
+
+	refreshToken, _, _, _, _, err := s.RefreshToken(context.Background(), "validToken", clientInfo)
 
 	assert.NoError(t, err)
-	mockStore.AssertExpectations(t)
+	assert.Equal(t, "newRefreshToken", refreshToken)
+	assert.False(t, transactor.rolledBack)
+	txRefreshTokenStore.AssertExpectations(t)
+	txSessionStore.AssertExpectations(t)
+	mockRefreshTokenStore.AssertNotCalled(t, "RevokeRefreshToken", mock.Anything, mock.Anything)
+	mockRefreshTokenStore.AssertNotCalled(t, "CreateRefreshToken", mock.Anything, mock.Anything)
+}
+
+func TestRefreshToken_RollsBackWhenIssuingFails(t *testing.T) {
+	mockStore := new(MockAppUserStore)
+	mockJwtUtil := new(MockJwtUtil)
+
+	user := repository.AppUser{ID: uuid.New(), Username: "test", IsActive: true}
+	jti := uuid.New()
+	familyId := uuid.New()
+	newRefreshTokenClaims := map[string]interface{}{"jti": uuid.New().String(), "iat": int64(1707105923), "exp": int64(1707710723)}
+	mockStore.On("GetAppUserById", mock.Anything, user.ID).Return(user, nil)
+	mockJwtUtil.On("DecodeToken", mock.Anything, jwt_util.Refresh, "validToken").Return(map[string]interface{}{"id": user.ID.String(), "jti": jti.String()}, nil)
+	mockJwtUtil.On("CreateRefreshToken", mock.Anything).Return("newRefreshToken", newRefreshTokenClaims, nil)
+
+	txRefreshTokenStore := new(MockRefreshTokenStore)
+	txRefreshTokenStore.On("RevokeRefreshToken", mock.Anything, jti).Return(repository.RefreshToken{Jti: jti, UserID: user.ID, FamilyID: familyId}, nil)
+	txRefreshTokenStore.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(repository.RefreshToken{}, errors.New("insert error"))
+	txSessionStore := new(MockUserSessionStore)
+	txRoleStore := new(MockRoleStore)
+	txRoleStore.On("ListUserPermissions", mock.Anything, user.ID).Return([]string{}, nil)
+	transactor := &mockTransactor{store: &mockTxStore{
+		mockAppUserServiceStore: mockAppUserServiceStore{
+			MockRefreshTokenStore: txRefreshTokenStore,
+			MockUserSessionStore:  txSessionStore,
+			MockRoleStore:         txRoleStore,
+		},
+	}}
+	s := service.AppUserService{AppUserStore: mockStore, JwtUtil: mockJwtUtil, Transactor: transactor}
+
+	_, _, _, _, _, err := s.RefreshToken(context.Background(), "validToken", repository.ClientInfo{})
+
+	assert.Error(t, err)
+	assert.True(t, transactor.rolledBack)
+	txSessionStore.AssertNotCalled(t, "TouchUserSession", mock.Anything, mock.Anything)
+	mockJwtUtil.AssertNotCalled(t, "CreateAccessToken", mock.Anything)
+	mockStore.AssertNotCalled(t, "UpdateAppUserLastLoginNow", mock.Anything, mock.Anything)
+}
+
+func TestGetAppUserTokensPersistsRefreshToken(t *testing.T) {
+	mockRefreshTokenStore := new(MockRefreshTokenStore)
+	mockSessionStore := new(MockUserSessionStore)
+	mockRoleStore := new(MockRoleStore)
+	mockJwtUtil := new(MockJwtUtil)
+	s := service.AppUserService{RefreshTokenStore: mockRefreshTokenStore, UserSessionStore: mockSessionStore, RoleStore: mockRoleStore, JwtUtil: mockJwtUtil}
+
+	user := repository.AppUser{ID: uuid.New(), Username: "test", IsActive: true}
+	clientInfo := repository.ClientInfo{UserAgent: "test-agent", IpAddress: "127.0.0.1"}
+	sessionId := uuid.New()
+	jti := uuid.New()
+	refreshTokenClaims := map[string]interface{}{"jti": jti.String(), "iat": int64(1707105923), "exp": int64(1707710723)}
+	mockJwtUtil.On("CreateRefreshToken", mock.Anything).Return("refreshToken", refreshTokenClaims, nil)
+	mockRoleStore.On("ListUserPermissions", mock.Anything, user.ID).Return([]string(nil), nil)
+	mockJwtUtil.On("CreateAccessToken", mock.MatchedBy(func(claims map[string]interface{}) bool {
+		return assert.ObjectsAreEqual([]string{}, claims["permissions"])
+	})).Return("accessToken", map[string]interface{}{}, nil)
+	mockSessionStore.On("CreateUserSession", mock.Anything, mock.MatchedBy(func(arg repository.CreateUserSessionParams) bool {
+		return arg.UserID == user.ID && arg.UserAgent == clientInfo.UserAgent && arg.IpAddress == clientInfo.IpAddress
+	})).Return(repository.UserSession{ID: sessionId, UserID: user.ID}, nil)
+	mockRefreshTokenStore.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(arg repository.CreateRefreshTokenParams) bool {
+		return arg.Jti == jti && arg.UserID == user.ID && arg.FamilyID == sessionId
+	})).Return(repository.RefreshToken{}, nil)
+
+	refreshToken, _, accessToken, _, err := s.GetAppUserTokens(context.Background(), user, clientInfo)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "refreshToken", refreshToken)
+	assert.Equal(t, "accessToken", accessToken)
+	mockSessionStore.AssertExpectations(t)
+	mockRefreshTokenStore.AssertExpectations(t)
 	mockJwtUtil.AssertExpectations(t)
 }
 
@@ -279,8 +586,8 @@ func TestRefreshTokenInvalidToken(t *testing.T) {
 	mockJwtUtil := new(MockJwtUtil)
 	s := service.AppUserService{AppUserStore: mockStore, JwtUtil: mockJwtUtil}
 
-	mockJwtUtil.On("DecodeToken", jwt_util.Refresh, "invalidToken").Return(map[string]interface{}{}, errors.New("invalid token"))
-	_, _, _, err := s.RefreshToken(context.Background(), "invalidToken")
+	mockJwtUtil.On("DecodeToken", mock.Anything, jwt_util.Refresh, "invalidToken").Return(map[string]interface{}{}, errors.New("invalid token"))
+	_, _, _, _, _, err := s.RefreshToken(context.Background(), "invalidToken", repository.ClientInfo{})
 
 	assert.Error(t, err)
 	mockStore.AssertExpectations(t)
@@ -293,10 +600,10 @@ func TestRefreshTokenUserNotFound(t *testing.T) {
 	s := service.AppUserService{AppUserStore: mockStore, JwtUtil: mockJwtUtil}
 
 	userId := uuid.New()
-	mockJwtUtil.On("DecodeToken", jwt_util.Refresh, "validToken").Return(map[string]interface{}{"id": userId.String()}, nil)
+	mockJwtUtil.On("DecodeToken", mock.Anything, jwt_util.Refresh, "validToken").Return(map[string]interface{}{"id": userId.String(), "jti": uuid.New().String()}, nil)
 	mockStore.On("GetAppUserById", mock.Anything, userId).Return(repository.AppUser{}, errors.New("user not found"))
 
-	_, _, _, err := s.RefreshToken(context.Background(), "validToken")
+	_, _, _, _, _, err := s.RefreshToken(context.Background(), "validToken", repository.ClientInfo{})
 
 	assert.Error(t, err)
 	mockStore.AssertExpectations(t)
@@ -309,49 +616,105 @@ func TestRefreshTokenInactiveUser(t *testing.T) {
 	s := service.AppUserService{AppUserStore: mockStore, JwtUtil: mockJwtUtil}
 
 	user := repository.AppUser{ID: uuid.New(), Username: "test", IsActive: false}
-	mockJwtUtil.On("DecodeToken", jwt_util.Refresh, "validToken").Return(map[string]interface{}{"id": user.ID.String()}, nil)
+	mockJwtUtil.On("DecodeToken", mock.Anything, jwt_util.Refresh, "validToken").Return(map[string]interface{}{"id": user.ID.String(), "jti": uuid.New().String()}, nil)
 	mockStore.On("GetAppUserById", mock.Anything, user.ID).Return(user, nil)
 
-	_, _, _, err := s.RefreshToken(context.Background(), "validToken")
+	_, _, _, _, _, err := s.RefreshToken(context.Background(), "validToken", repository.ClientInfo{})
 
 	assert.Error(t, err)
 	mockStore.AssertExpectations(t)
 	mockJwtUtil.AssertExpectations(t)
 }
 
+func TestLogoutRevokesRefreshToken(t *testing.T) {
+	mockRefreshTokenStore := new(MockRefreshTokenStore)
+	mockSessionStore := new(MockUserSessionStore)
+	mockJwtUtil := new(MockJwtUtil)
+	s := service.AppUserService{RefreshTokenStore: mockRefreshTokenStore, UserSessionStore: mockSessionStore, JwtUtil: mockJwtUtil}
+
+	userId := uuid.New()
+	jti := uuid.New()
+	familyId := uuid.New()
+	mockJwtUtil.On("DecodeToken", mock.Anything, jwt_util.Refresh, "validToken").Return(map[string]interface{}{"jti": jti.String()}, nil)
+	mockRefreshTokenStore.On("RevokeRefreshToken", mock.Anything, jti).Return(repository.RefreshToken{Jti: jti, UserID: userId, FamilyID: familyId}, nil)
+	mockSessionStore.On("RevokeUserSession", mock.Anything, repository.RevokeUserSessionParams{ID: familyId, UserID: userId}).Return(repository.UserSession{}, nil)
+	mockRefreshTokenStore.On("RevokeRefreshTokenFamily", mock.Anything, familyId).Return(nil)
+
+	err := s.Logout(context.Background(), "validToken")
+
+	assert.NoError(t, err)
+	mockRefreshTokenStore.AssertExpectations(t)
+	mockSessionStore.AssertExpectations(t)
+	mockJwtUtil.AssertExpectations(t)
+}
+
+func TestLogoutInvalidTokenIsIgnored(t *testing.T) {
+	mockRefreshTokenStore := new(MockRefreshTokenStore)
+	mockJwtUtil := new(MockJwtUtil)
+	s := service.AppUserService{RefreshTokenStore: mockRefreshTokenStore, JwtUtil: mockJwtUtil}
+
+	mockJwtUtil.On("DecodeToken", mock.Anything, jwt_util.Refresh, "invalidToken").Return(map[string]interface{}{}, errors.New("invalid token"))
+
+	err := s.Logout(context.Background(), "invalidToken")
+
+	assert.NoError(t, err)
+	mockRefreshTokenStore.AssertNotCalled(t, "RevokeRefreshToken", mock.Anything, mock.Anything)
+}
+
+func TestRevokeAllRefreshTokens(t *testing.T) {
+	mockRefreshTokenStore := new(MockRefreshTokenStore)
+	mockSessionStore := new(MockUserSessionStore)
+	s := service.AppUserService{RefreshTokenStore: mockRefreshTokenStore, UserSessionStore: mockSessionStore}
+
+	userId := uuid.New()
+	mockRefreshTokenStore.On("RevokeAllUserRefreshTokens", mock.Anything, userId).Return(nil)
+	mockSessionStore.On("RevokeAllUserSessions", mock.Anything, userId).Return(nil)
+
+	err := s.RevokeAllRefreshTokens(context.Background(), userId)
+
+	assert.NoError(t, err)
+	mockRefreshTokenStore.AssertExpectations(t)
+	mockSessionStore.AssertExpectations(t)
+}
+
 func TestVerifyEmailVerificationToken(t *testing.T) {
 	ctx := context.Background()
 	userId := uuid.New()
 	userEmailAddress := "test@example.com"
-	emailVerifier := email_util.NewEmailTokenVerifier()
-	token, _ := emailVerifier.CreateToken(userEmailAddress)
 
 	mockStore := new(MockAppUserStore)
 	mockStore.On("SetUserEmailVerified", ctx, userId).Return(repository.AppUser{}, nil)
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("ConsumeVerificationToken", ctx, repository.ConsumeVerificationTokenParams{
+		TokenHash: email_util.HashVerificationToken("token"),
+		Purpose:   service.EmailVerificationPurpose,
+	}).Return(repository.VerificationToken{UserID: userId, Email: userEmailAddress}, nil)
 
-	s := service.NewAppUserService(mockStore)
+	s := service.NewAppUserService(&mockAppUserServiceStore{MockAppUserStore: mockStore, MockVerificationTokenStore: mockTokenStore}, nil)
 
-	_, err := s.VerifyEmailVerificationToken(ctx, userId, userEmailAddress, token)
+	verified, err := s.VerifyEmailVerificationToken(ctx, userId, userEmailAddress, "token", repository.ClientInfo{})
 
 	assert.NoError(t, err)
+	assert.True(t, verified)
 	mockStore.AssertExpectations(t)
-	mockStore.AssertCalled(t, "SetUserEmailVerified", ctx, userId)
+	mockTokenStore.AssertExpectations(t)
 }
 
 func TestVerifyEmailVerificationToken_InvalidToken(t *testing.T) {
 	ctx := context.Background()
 	userId := uuid.New()
 	userEmailAddress := "test@example.com"
-	token := "bad_token"
 
 	mockStore := new(MockAppUserStore)
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("ConsumeVerificationToken", ctx, mock.Anything).Return(repository.VerificationToken{}, sql.ErrNoRows)
 
-	s := service.NewAppUserService(mockStore)
+	s := service.NewAppUserService(&mockAppUserServiceStore{MockAppUserStore: mockStore, MockVerificationTokenStore: mockTokenStore}, nil)
 
-	_, err := s.VerifyEmailVerificationToken(ctx, userId, userEmailAddress, token)
+	_, err := s.VerifyEmailVerificationToken(ctx, userId, userEmailAddress, "bad_token", repository.ClientInfo{})
 
-	assert.Error(t, err)
-	mockStore.AssertExpectations(t)
+	var invalidTokenError *repository.InvalidVerificationTokenError
+	assert.ErrorAs(t, err, &invalidTokenError)
 	mockStore.AssertNotCalled(t, "SetUserEmailVerified", ctx, userId)
 }
 
@@ -359,73 +722,385 @@ func TestVerifyEmailVerificationToken_EmailMismatch(t *testing.T) {
 	ctx := context.Background()
 	userId := uuid.New()
 	userEmailAddress := "test@example.com"
-	emailVerifier := email_util.NewEmailTokenVerifier()
-	token, _ := emailVerifier.CreateToken("wrong@email.com")
 
 	mockStore := new(MockAppUserStore)
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("ConsumeVerificationToken", ctx, mock.Anything).Return(repository.VerificationToken{UserID: userId, Email: "wrong@email.com"}, nil)
 
-	s := service.NewAppUserService(mockStore)
+	s := service.NewAppUserService(&mockAppUserServiceStore{MockAppUserStore: mockStore, MockVerificationTokenStore: mockTokenStore}, nil)
 
-	_, err := s.VerifyEmailVerificationToken(ctx, userId, userEmailAddress, token)
+	_, err := s.VerifyEmailVerificationToken(ctx, userId, userEmailAddress, "token", repository.ClientInfo{})
+
+	assert.Error(t, err)
+	mockStore.AssertNotCalled(t, "SetUserEmailVerified", ctx, userId)
+}
+
+func TestVerifyEmailVerificationToken_OtherUser(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+	userEmailAddress := "test@example.com"
+
+	mockStore := new(MockAppUserStore)
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("ConsumeVerificationToken", ctx, mock.Anything).Return(repository.VerificationToken{UserID: uuid.New(), Email: userEmailAddress}, nil)
+
+	s := service.NewAppUserService(&mockAppUserServiceStore{MockAppUserStore: mockStore, MockVerificationTokenStore: mockTokenStore}, nil)
+
+	_, err := s.VerifyEmailVerificationToken(ctx, userId, userEmailAddress, "token", repository.ClientInfo{})
 
 	assert.Error(t, err)
-	mockStore.AssertExpectations(t)
 	mockStore.AssertNotCalled(t, "SetUserEmailVerified", ctx, userId)
 }
 
 func TestSendEmailVerification_Success(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
 	emailAddress := "test@example.com"
-	mockVerifier := new(MockEmailVerifier)
-	mockVerifier.On("CreateToken", emailAddress).Return("token", nil)
 
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("InvalidateUserVerificationTokens", ctx, repository.InvalidateUserVerificationTokensParams{
+		UserID:  userId,
+		Purpose: service.EmailVerificationPurpose,
+	}).Return(nil)
+	var tokenParams repository.CreateVerificationTokenParams
+	mockTokenStore.On("CreateVerificationToken", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		tokenParams = args.Get(1).(repository.CreateVerificationTokenParams)
+	}).Return(repository.VerificationToken{}, nil)
+
+	var sentLink string
 	mockSender := new(MockEmailSender)
-	mockSender.On("SendSingleEmail", emailAddress, "Email Verification", url.QueryEscape("token")).Return(nil)
+	mockSender.On("SendTemplatedEmail", emailAddress, email_util.VerificationEmailTemplate, mock.Anything).Run(func(args mock.Arguments) {
+		sentLink = args.Get(2).(email_util.TemplateData).Link
+	}).Return(nil)
 
-	s := service.NewAppUserService(nil)
-
-	s.EmailVerifier = mockVerifier
+	s := service.NewAppUserService(&mockAppUserServiceStore{MockVerificationTokenStore: mockTokenStore}, nil)
 	s.EmailSender = mockSender
 
-	err := s.SendUserEmailVerification(context.Background(), emailAddress)
+	err := s.SendUserEmailVerification(ctx, userId, emailAddress)
 
 	assert.NoError(t, err)
-	mockVerifier.AssertExpectations(t)
+	assert.Equal(t, userId, tokenParams.UserID)
+	assert.Equal(t, emailAddress, tokenParams.Email)
+	assert.Equal(t, service.EmailVerificationPurpose, tokenParams.Purpose)
+	assert.True(t, tokenParams.ExpiresAt.After(time.Now()))
+	link, err := url.Parse(sentLink)
+	assert.NoError(t, err)
+	assert.Equal(t, "/verify-email", link.Path)
+	assert.Equal(t, email_util.HashVerificationToken(link.Query().Get("token")), tokenParams.TokenHash, "Expected only the token hash to be persisted")
+	mockTokenStore.AssertExpectations(t)
 	mockSender.AssertExpectations(t)
-
 }
 
 func TestSendEmailVerification_TokenCreationError(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
 	emailAddress := "test@example.com"
-	mockVerifier := new(MockEmailVerifier)
-	mockVerifier.On("CreateToken", emailAddress).Return("", errors.New("token creation error"))
 
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("InvalidateUserVerificationTokens", ctx, mock.Anything).Return(nil)
+	mockTokenStore.On("CreateVerificationToken", ctx, mock.Anything).Return(repository.VerificationToken{}, errors.New("token creation error"))
 	mockSender := new(MockEmailSender)
 
-	s := service.NewAppUserService(nil)
-	s.EmailVerifier = mockVerifier
+	s := service.NewAppUserService(&mockAppUserServiceStore{MockVerificationTokenStore: mockTokenStore}, nil)
 	s.EmailSender = mockSender
 
-	err := s.SendUserEmailVerification(context.Background(), emailAddress)
+	err := s.SendUserEmailVerification(ctx, userId, emailAddress)
 
 	assert.Error(t, err)
-	mockVerifier.AssertExpectations(t)
+	mockSender.AssertNotCalled(t, "SendTemplatedEmail", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestSendEmailVerification_EmailSendingError(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
 	emailAddress := "test@example.com"
-	mockVerifier := new(MockEmailVerifier)
-	mockVerifier.On("CreateToken", emailAddress).Return("token", nil)
 
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("InvalidateUserVerificationTokens", ctx, mock.Anything).Return(nil)
+	mockTokenStore.On("CreateVerificationToken", ctx, mock.Anything).Return(repository.VerificationToken{}, nil)
 	mockSender := new(MockEmailSender)
-	mockSender.On("SendSingleEmail", emailAddress, "Email Verification", mock.Anything).Return(errors.New("email sending error"))
+	mockSender.On("SendTemplatedEmail", emailAddress, email_util.VerificationEmailTemplate, mock.Anything).Return(errors.New("email sending error"))
 
-	s := service.NewAppUserService(nil)
-	s.EmailVerifier = mockVerifier
+	s := service.NewAppUserService(&mockAppUserServiceStore{MockVerificationTokenStore: mockTokenStore}, nil)
 	s.EmailSender = mockSender
 
-	err := s.SendUserEmailVerification(context.Background(), emailAddress)
+	err := s.SendUserEmailVerification(ctx, userId, emailAddress)
 
 	assert.Error(t, err)
-	mockVerifier.AssertExpectations(t)
 	mockSender.AssertExpectations(t)
 }
+
+func TestRequestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	appUser := repository.AppUser{ID: uuid.New(), Email: "test@example.com", IsActive: true}
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserByEmailAddr", ctx, appUser.Email).Return(appUser, nil)
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("InvalidateUserVerificationTokens", ctx, repository.InvalidateUserVerificationTokensParams{
+		UserID:  appUser.ID,
+		Purpose: service.PasswordResetPurpose,
+	}).Return(nil)
+	mockTokenStore.On("CreateVerificationToken", ctx, mock.MatchedBy(func(arg repository.CreateVerificationTokenParams) bool {
+		return arg.UserID == appUser.ID && arg.Purpose == service.PasswordResetPurpose
+	})).Return(repository.VerificationToken{}, nil)
+	mockSender := new(MockEmailSender)
+	mockSender.On("SendTemplatedEmail", appUser.Email, email_util.PasswordResetEmailTemplate, mock.MatchedBy(func(data email_util.TemplateData) bool {
+		return data.Link != "" && data.ExpiresIn > 0
+	})).Return(nil)
+
+	s := service.AppUserService{AppUserStore: mockStore, VerificationTokenStore: mockTokenStore, EmailSender: mockSender}
+
+	err := s.RequestPasswordReset(ctx, appUser.Email)
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockTokenStore.AssertExpectations(t)
+	mockSender.AssertExpectations(t)
+}
+
+func TestRequestPasswordReset_UnknownEmail(t *testing.T) {
+	ctx := context.Background()
+	emailAddress := "unknown@example.com"
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserByEmailAddr", ctx, emailAddress).Return(repository.AppUser{}, sql.ErrNoRows)
+	mockSender := new(MockEmailSender)
+
+	s := service.AppUserService{AppUserStore: mockStore, EmailSender: mockSender}
+
+	err := s.RequestPasswordReset(ctx, emailAddress)
+
+	assert.NoError(t, err)
+	mockSender.AssertNotCalled(t, "SendTemplatedEmail", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestConfirmPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	appUser := repository.AppUser{ID: uuid.New(), Email: "test@example.com", Password: "hashedPassword"}
+	newPassword := "correct horse battery staple"
+
+	service.HashPasswordFunc = func(password string) ([]byte, error) {
+		return []byte(password), nil
+	}
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, appUser.ID).Return(appUser, nil)
+	mockStore.On("UpdateAppUserPassword", ctx, repository.UpdateAppUserPasswordParams{ID: appUser.ID, Password: newPassword}).Return(appUser, nil)
+	mockRefreshTokenStore := new(MockRefreshTokenStore)
+	mockRefreshTokenStore.On("RevokeAllUserRefreshTokens", ctx, appUser.ID).Return(nil)
+	mockSessionStore := new(MockUserSessionStore)
+	mockSessionStore.On("RevokeAllUserSessions", ctx, appUser.ID).Return(nil)
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("ConsumeVerificationToken", ctx, repository.ConsumeVerificationTokenParams{
+		TokenHash: email_util.HashVerificationToken("token"),
+		Purpose:   service.PasswordResetPurpose,
+	}).Return(repository.VerificationToken{UserID: appUser.ID}, nil)
+
+	mockSender := new(MockEmailSender)
+	mockSender.On("SendTemplatedEmail", appUser.Email, email_util.SecurityAlertEmailTemplate, mock.Anything).Return(nil)
+
+	s := service.NewAppUserService(&mockAppUserServiceStore{MockAppUserStore: mockStore, MockRefreshTokenStore: mockRefreshTokenStore, MockUserSessionStore: mockSessionStore, MockVerificationTokenStore: mockTokenStore}, nil)
+	s.EmailSender = mockSender
+
+	err := s.ConfirmPasswordReset(ctx, "token", newPassword, repository.ClientInfo{})
+
+	assert.NoError(t, err)
+	mockSender.AssertExpectations(t)
+	mockStore.AssertExpectations(t)
+	mockRefreshTokenStore.AssertExpectations(t)
+	mockSessionStore.AssertExpectations(t)
+	mockTokenStore.AssertExpectations(t)
+}
+
+func TestConfirmPasswordReset_TokenAlreadyUsed(t *testing.T) {
+	ctx := context.Background()
+
+	mockStore := new(MockAppUserStore)
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("ConsumeVerificationToken", ctx, mock.Anything).Return(repository.VerificationToken{}, sql.ErrNoRows)
+
+	s := service.NewAppUserService(&mockAppUserServiceStore{MockAppUserStore: mockStore, MockVerificationTokenStore: mockTokenStore}, nil)
+
+	err := s.ConfirmPasswordReset(ctx, "token", "correct horse battery staple", repository.ClientInfo{})
+
+	var invalidTokenError *repository.InvalidVerificationTokenError
+	assert.ErrorAs(t, err, &invalidTokenError)
+	mockStore.AssertNotCalled(t, "UpdateAppUserPassword", mock.Anything, mock.Anything)
+}
+
+func TestConfirmPasswordReset_WeakPassword(t *testing.T) {
+	ctx := context.Background()
+
+	mockStore := new(MockAppUserStore)
+	mockTokenStore := new(MockVerificationTokenStore)
+
+	s := service.NewAppUserService(&mockAppUserServiceStore{MockAppUserStore: mockStore, MockVerificationTokenStore: mockTokenStore}, nil)
+
+	err := s.ConfirmPasswordReset(ctx, "token", "weak", repository.ClientInfo{})
+
+	var weakPasswordError *password_util.WeakPasswordError
+	assert.ErrorAs(t, err, &weakPasswordError)
+	mockTokenStore.AssertNotCalled(t, "ConsumeVerificationToken", mock.Anything, mock.Anything)
+	mockStore.AssertNotCalled(t, "UpdateAppUserPassword", mock.Anything, mock.Anything)
+}
+
+func TestRequestEmailChange(t *testing.T) {
+	ctx := context.Background()
+	password := "P4ssword!123"
+	passwordHash, err := password_util.HashPassword(password)
+	assert.NoError(t, err)
+	appUser := repository.AppUser{ID: uuid.New(), Email: "old@example.com", Password: string(passwordHash)}
+	newEmail := "new@example.com"
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, appUser.ID).Return(appUser, nil)
+	mockStore.On("GetAppUserByEmailAddr", ctx, newEmail).Return(repository.AppUser{}, sql.ErrNoRows)
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("InvalidateUserVerificationTokens", ctx, repository.InvalidateUserVerificationTokensParams{
+		UserID:  appUser.ID,
+		Purpose: service.EmailChangePurpose,
+	}).Return(nil)
+	mockTokenStore.On("CreateVerificationToken", ctx, mock.MatchedBy(func(arg repository.CreateVerificationTokenParams) bool {
+		return arg.UserID == appUser.ID && arg.Purpose == service.EmailChangePurpose && arg.Email == newEmail
+	})).Return(repository.VerificationToken{}, nil)
+	mockSender := new(MockEmailSender)
+	mockSender.On("SendTemplatedEmail", newEmail, email_util.EmailChangeEmailTemplate, mock.MatchedBy(func(data email_util.TemplateData) bool {
+		return data.Link != "" && data.ExpiresIn > 0
+	})).Return(nil)
+
+	s := service.AppUserService{AppUserStore: mockStore, VerificationTokenStore: mockTokenStore, EmailSender: mockSender}
+
+	err = s.RequestEmailChange(ctx, appUser.ID, password, newEmail)
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockTokenStore.AssertExpectations(t)
+	mockSender.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "UpdateAppUserEmail", mock.Anything, mock.Anything)
+}
+
+func TestRequestEmailChange_IncorrectPassword(t *testing.T) {
+	ctx := context.Background()
+	passwordHash, err := password_util.HashPassword("P4ssword!123")
+	assert.NoError(t, err)
+	appUser := repository.AppUser{ID: uuid.New(), Email: "old@example.com", Password: string(passwordHash)}
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, appUser.ID).Return(appUser, nil)
+	mockSender := new(MockEmailSender)
+
+	s := service.AppUserService{AppUserStore: mockStore, EmailSender: mockSender}
+
+	err = s.RequestEmailChange(ctx, appUser.ID, "wrong", "new@example.com")
+
+	var incorrectCredentialError *repository.IncorrectUserCredentialError
+	assert.ErrorAs(t, err, &incorrectCredentialError)
+	mockSender.AssertNotCalled(t, "SendTemplatedEmail", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRequestEmailChange_SameEmailDifferentCase(t *testing.T) {
+	ctx := context.Background()
+	password := "P4ssword!123"
+	passwordHash, err := password_util.HashPassword(password)
+	assert.NoError(t, err)
+	appUser := repository.AppUser{ID: uuid.New(), Email: "user@example.com", Password: string(passwordHash)}
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, appUser.ID).Return(appUser, nil)
+
+	s := service.AppUserService{AppUserStore: mockStore}
+
+	err = s.RequestEmailChange(ctx, appUser.ID, password, "User@Example.com")
+
+	var sameEmailError *repository.SameEmailError
+	assert.ErrorAs(t, err, &sameEmailError)
+}
+
+func TestRequestEmailChange_EmailInUse(t *testing.T) {
+	ctx := context.Background()
+	password := "P4ssword!123"
+	passwordHash, err := password_util.HashPassword(password)
+	assert.NoError(t, err)
+	appUser := repository.AppUser{ID: uuid.New(), Email: "old@example.com", Password: string(passwordHash)}
+	newEmail := "taken@example.com"
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, appUser.ID).Return(appUser, nil)
+	mockStore.On("GetAppUserByEmailAddr", ctx, newEmail).Return(repository.AppUser{ID: uuid.New(), Email: "Taken@example.com"}, nil)
+	mockTokenStore := new(MockVerificationTokenStore)
+
+	s := service.AppUserService{AppUserStore: mockStore, VerificationTokenStore: mockTokenStore}
+
+	err = s.RequestEmailChange(ctx, appUser.ID, password, newEmail)
+
+	var duplicateKeyError *repository.DuplicateKeyError
+	assert.ErrorAs(t, err, &duplicateKeyError)
+	mockTokenStore.AssertNotCalled(t, "CreateVerificationToken", mock.Anything, mock.Anything)
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	ctx := context.Background()
+	appUser := repository.AppUser{ID: uuid.New(), Email: "old@example.com", EmailVerified: true}
+	newEmail := "new@example.com"
+	updatedUser := appUser
+	updatedUser.Email = newEmail
+
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("ConsumeVerificationToken", ctx, repository.ConsumeVerificationTokenParams{
+		TokenHash: email_util.HashVerificationToken("token"),
+		Purpose:   service.EmailChangePurpose,
+	}).Return(repository.VerificationToken{UserID: appUser.ID, Email: newEmail}, nil)
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, appUser.ID).Return(appUser, nil)
+	mockStore.On("UpdateAppUserEmail", ctx, repository.UpdateAppUserEmailParams{ID: appUser.ID, Email: newEmail}).Return(updatedUser, nil)
+	mockStore.On("SetUserEmailVerified", ctx, appUser.ID).Return(updatedUser, nil)
+	mockSender := new(MockEmailSender)
+	mockSender.On("SendTemplatedEmail", appUser.Email, email_util.SecurityAlertEmailTemplate, mock.MatchedBy(func(data email_util.TemplateData) bool {
+		return strings.Contains(data.Event, newEmail)
+	})).Return(nil)
+
+	s := service.AppUserService{AppUserStore: mockStore, VerificationTokenStore: mockTokenStore, EmailSender: mockSender}
+
+	result, err := s.ConfirmEmailChange(ctx, appUser.ID, "token")
+
+	assert.NoError(t, err)
+	assert.Equal(t, newEmail, result.Email)
+	mockStore.AssertExpectations(t)
+	mockTokenStore.AssertExpectations(t)
+	mockSender.AssertExpectations(t)
+}
+
+func TestConfirmEmailChange_OtherUsersToken(t *testing.T) {
+	ctx := context.Background()
+
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("ConsumeVerificationToken", ctx, mock.Anything).Return(repository.VerificationToken{UserID: uuid.New(), Email: "new@example.com"}, nil)
+	mockStore := new(MockAppUserStore)
+
+	s := service.AppUserService{AppUserStore: mockStore, VerificationTokenStore: mockTokenStore}
+
+	_, err := s.ConfirmEmailChange(ctx, uuid.New(), "token")
+
+	var invalidTokenError *repository.InvalidVerificationTokenError
+	assert.ErrorAs(t, err, &invalidTokenError)
+	mockStore.AssertNotCalled(t, "UpdateAppUserEmail", mock.Anything, mock.Anything)
+}
+
+func TestConfirmEmailChange_EmailTakenSinceRequest(t *testing.T) {
+	ctx := context.Background()
+	appUser := repository.AppUser{ID: uuid.New(), Email: "old@example.com"}
+
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("ConsumeVerificationToken", ctx, mock.Anything).Return(repository.VerificationToken{UserID: appUser.ID, Email: "new@example.com"}, nil)
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, appUser.ID).Return(appUser, nil)
+	mockStore.On("UpdateAppUserEmail", ctx, mock.Anything).Return(repository.AppUser{}, &pq.Error{Code: "23505"})
+
+	s := service.AppUserService{AppUserStore: mockStore, VerificationTokenStore: mockTokenStore}
+
+	_, err := s.ConfirmEmailChange(ctx, appUser.ID, "token")
+
+	var duplicateKeyError *repository.DuplicateKeyError
+	assert.ErrorAs(t, err, &duplicateKeyError)
+	mockStore.AssertNotCalled(t, "SetUserEmailVerified", mock.Anything, mock.Anything)
+}
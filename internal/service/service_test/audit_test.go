@@ -0,0 +1,194 @@
+package service_test
+
+import (
+	"context"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/service"
+	"eau-de-go/pkg/pagination"
+	"eau-de-go/pkg/password_util"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+type MockAuditEventStore struct {
+	mock.Mock
+}
+
+func (m *MockAuditEventStore) CreateAuditEvent(ctx context.Context, arg repository.CreateAuditEventParams) (repository.AuditEvent, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.AuditEvent), args.Error(1)
+}
+
+func (m *MockAuditEventStore) ListAuditEventPage(ctx context.Context, arg repository.ListAuditEventPageParams) ([]repository.AuditEvent, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]repository.AuditEvent), args.Error(1)
+}
+
+type MockAuditLogger struct {
+	mock.Mock
+}
+
+func (m *MockAuditLogger) LogAuditEvent(ctx context.Context, entry service.AuditEntry) {
+	m.Called(ctx, entry)
+}
+
+func matchAuditEvent(actorId uuid.UUID, targetId uuid.UUID, action string) interface{} {
+	return mock.MatchedBy(func(arg repository.CreateAuditEventParams) bool {
+		return arg.ActorID == uuid.NullUUID{UUID: actorId, Valid: true} &&
+			arg.TargetID.UUID == targetId &&
+			arg.Action == action &&
+			arg.IpAddress == "127.0.0.1" &&
+			json.Valid(arg.Metadata)
+	})
+}
+
+func matchAuditEntry(actorId uuid.UUID, targetId uuid.UUID, action string) interface{} {
+	return mock.MatchedBy(func(entry service.AuditEntry) bool {
+		return entry.ActorId == actorId && entry.TargetId == targetId && entry.Action == action
+	})
+}
+
+func TestStoreAuditLoggerRecordsUnknownActor(t *testing.T) {
+	ctx := context.Background()
+	targetId := uuid.New()
+	mockAuditStore := new(MockAuditEventStore)
+	mockAuditStore.On("CreateAuditEvent", ctx, mock.MatchedBy(func(arg repository.CreateAuditEventParams) bool {
+		return !arg.ActorID.Valid &&
+			arg.TargetID == uuid.NullUUID{UUID: targetId, Valid: true} &&
+			arg.UserAgent == "test-agent" &&
+			string(arg.Metadata) == "{}"
+	})).Return(repository.AuditEvent{}, nil)
+
+	service.NewStoreAuditLogger(mockAuditStore).LogAuditEvent(ctx, service.AuditEntry{
+		TargetId:   targetId,
+		Action:     service.UserLoginFailedAction,
+		ClientInfo: adminClientInfo,
+	})
+
+	mockAuditStore.AssertExpectations(t)
+}
+
+func TestStoreAuditLoggerTruncatesOversizedUserAgent(t *testing.T) {
+	ctx := context.Background()
+	userAgent := strings.Repeat("é", 600) + "\xff"
+	mockAuditStore := new(MockAuditEventStore)
+	mockAuditStore.On("CreateAuditEvent", ctx, mock.MatchedBy(func(arg repository.CreateAuditEventParams) bool {
+		return utf8.ValidString(arg.UserAgent) &&
+			arg.UserAgent == strings.Repeat("é", 512) &&
+			arg.IpAddress == strings.Repeat("1", 45)
+	})).Return(repository.AuditEvent{}, nil)
+
+	service.NewStoreAuditLogger(mockAuditStore).LogAuditEvent(ctx, service.AuditEntry{
+		Action:     service.UserLoginFailedAction,
+		ClientInfo: repository.ClientInfo{UserAgent: userAgent, IpAddress: strings.Repeat("1", 60)},
+	})
+
+	mockAuditStore.AssertExpectations(t)
+}
+
+func TestStoreAuditLoggerReplacesInvalidUtf8(t *testing.T) {
+	ctx := context.Background()
+	mockAuditStore := new(MockAuditEventStore)
+	mockAuditStore.On("CreateAuditEvent", ctx, mock.MatchedBy(func(arg repository.CreateAuditEventParams) bool {
+		return arg.UserAgent == "agent\uFFFD"
+	})).Return(repository.AuditEvent{}, nil)
+
+	service.NewStoreAuditLogger(mockAuditStore).LogAuditEvent(ctx, service.AuditEntry{
+		Action:     service.UserLoginFailedAction,
+		ClientInfo: repository.ClientInfo{UserAgent: "agent\xff\xfe", IpAddress: "127.0.0.1"},
+	})
+
+	mockAuditStore.AssertExpectations(t)
+}
+
+func TestLoginIsAudited(t *testing.T) {
+	password := "P4ssword!123"
+	passwordHash, _ := password_util.HashPassword(password)
+	user := repository.AppUser{ID: uuid.New(), Username: "user", Password: string(passwordHash), IsActive: true}
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserByUsername", mock.Anything, "user").Return(user, nil)
+	mockStore.On("UpdateAppUserLastLoginNow", mock.Anything, user.ID).Return(user, nil)
+	mockAuditLogger := new(MockAuditLogger)
+	mockAuditLogger.On("LogAuditEvent", mock.Anything, matchAuditEntry(user.ID, user.ID, service.UserLoginAction)).Return()
+	mockAuditLogger.On("LogAuditEvent", mock.Anything, mock.MatchedBy(func(entry service.AuditEntry) bool {
+		return entry.ActorId == uuid.Nil &&
+			entry.TargetId == user.ID &&
+			entry.Action == service.UserLoginFailedAction &&
+			entry.Metadata["reason"] == "incorrect_password" &&
+			entry.ClientInfo == adminClientInfo
+	})).Return()
+
+	s := service.AppUserService{AppUserStore: mockStore, LoginThrottleStore: newMockLoginThrottleStore(), AuditLogger: mockAuditLogger}
+
+	_, err := s.Login(context.Background(), "user", "wrong password", adminClientInfo)
+	assert.IsType(t, &repository.IncorrectUserCredentialError{}, err)
+
+	_, err = s.Login(context.Background(), "user", password, adminClientInfo)
+	assert.NoError(t, err)
+
+	mockAuditLogger.AssertExpectations(t)
+}
+
+func TestListAuditEvents(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	userId := uuid.New()
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := since.Add(time.Hour)
+	events := []repository.AuditEvent{
+		{ID: uuid.New(), CreatedAt: createdAt.Add(2 * time.Minute)},
+		{ID: uuid.New(), CreatedAt: createdAt.Add(time.Minute)},
+		{ID: uuid.New(), CreatedAt: createdAt},
+	}
+
+	mockAuditStore := new(MockAuditEventStore)
+	mockAuditStore.On("ListAuditEventPage", ctx, mock.MatchedBy(func(arg repository.ListAuditEventPageParams) bool {
+		return arg.UserID == uuid.NullUUID{UUID: userId, Valid: true} &&
+			!arg.Action.Valid &&
+			arg.Since.Time.Equal(since) &&
+			!arg.Until.Valid &&
+			!arg.AfterID.Valid &&
+			arg.PageSize == 3
+	})).Return(events, nil)
+	mockAuditLogger := new(MockAuditLogger)
+	mockAuditLogger.On("LogAuditEvent", ctx, matchAuditEntry(actorId, userId, service.AdminAuditEventsListedAction)).Return()
+
+	s := service.NewAuditService(mockAuditStore, mockAuditLogger)
+
+	page, err := s.ListAuditEvents(ctx, actorId, adminClientInfo, repository.AuditEventFilter{UserId: &userId, Since: &since}, pagination.PageRequest{Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, events[:2], page.Items)
+	cursor, err := s.CursorCodec.Decode(page.NextCursor, "-created_at")
+	assert.NoError(t, err)
+	assert.Equal(t, events[1].ID, cursor.ID)
+	mockAuditStore.AssertExpectations(t)
+	mockAuditLogger.AssertExpectations(t)
+
+	mockAuditStore.On("ListAuditEventPage", ctx, mock.MatchedBy(func(arg repository.ListAuditEventPageParams) bool {
+		return arg.AfterID.UUID == events[1].ID && arg.AfterCreatedAt.Time.Equal(events[1].CreatedAt)
+	})).Return(events[2:], nil)
+
+	page, err = s.ListAuditEvents(ctx, actorId, adminClientInfo, repository.AuditEventFilter{UserId: &userId}, pagination.PageRequest{Limit: 2, Cursor: page.NextCursor})
+
+	assert.NoError(t, err)
+	assert.Equal(t, events[2:], page.Items)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestListAuditEventsInvalidCursor(t *testing.T) {
+	mockAuditStore := new(MockAuditEventStore)
+	s := service.NewAuditService(mockAuditStore, new(MockAuditLogger))
+
+	_, err := s.ListAuditEvents(context.Background(), uuid.New(), adminClientInfo, repository.AuditEventFilter{}, pagination.PageRequest{Cursor: "tampered"})
+
+	assert.IsType(t, &pagination.InvalidCursorError{}, err)
+	mockAuditStore.AssertNotCalled(t, "ListAuditEventPage", mock.Anything, mock.Anything)
+}
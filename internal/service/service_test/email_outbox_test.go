@@ -0,0 +1,386 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/service"
+	"eau-de-go/pkg/email_util"
+	"eau-de-go/pkg/keys"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+type MockEmailOutboxStore struct {
+	mock.Mock
+}
+
+func (m *MockEmailOutboxStore) EnqueueEmail(ctx context.Context, message json.RawMessage) (repository.EmailOutbox, error) {
+	args := m.Called(ctx, message)
+	return args.Get(0).(repository.EmailOutbox), args.Error(1)
+}
+
+func (m *MockEmailOutboxStore) GetEmailOutboxById(ctx context.Context, id uuid.UUID) (repository.EmailOutbox, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(repository.EmailOutbox), args.Error(1)
+}
+
+func (m *MockEmailOutboxStore) ClaimDueEmails(ctx context.Context, arg repository.ClaimDueEmailsParams) ([]repository.EmailOutbox, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]repository.EmailOutbox), args.Error(1)
+}
+
+func (m *MockEmailOutboxStore) MarkEmailSent(ctx context.Context, arg repository.MarkEmailSentParams) (repository.EmailOutbox, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.EmailOutbox), args.Error(1)
+}
+
+func (m *MockEmailOutboxStore) MarkEmailFailed(ctx context.Context, arg repository.MarkEmailFailedParams) (repository.EmailOutbox, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.EmailOutbox), args.Error(1)
+}
+
+func (m *MockEmailOutboxStore) DeleteEmailOutboxBefore(ctx context.Context, createdAt time.Time) (int64, error) {
+	args := m.Called(ctx, createdAt)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func newTestEmailOutboxWorker(store *MockEmailOutboxStore, sender *MockEmailSender) *service.EmailOutboxWorker {
+	return &service.EmailOutboxWorker{
+		ID:           uuid.New(),
+		Store:        store,
+		Sender:       sender,
+		MaxAttempts:  3,
+		BatchSize:    10,
+		BaseDelay:    time.Minute,
+		MaxDelay:     10 * time.Minute,
+		LockDuration: time.Minute,
+		Retention:    24 * time.Hour,
+	}
+}
+
+func newOutboxEmail(t *testing.T, message email_util.Message, attempts int32) repository.EmailOutbox {
+	messageJson, err := json.Marshal(message)
+	assert.NoError(t, err)
+	return repository.EmailOutbox{
+		ID:          uuid.New(),
+		Message:     messageJson,
+		Status:      service.EmailPendingStatus,
+		Attempts:    attempts,
+		LockedUntil: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+	}
+}
+
+func TestEmailOutboxSendTemplatedEmail(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	outbox := service.NewEmailOutbox(mockStore)
+
+	var enqueued email_util.Message
+	mockStore.On("EnqueueEmail", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		assert.NoError(t, json.Unmarshal(args.Get(1).(json.RawMessage), &enqueued))
+	}).Return(repository.EmailOutbox{}, nil)
+
+	err := outbox.SendTemplatedEmail("test@example.com", email_util.WelcomeEmailTemplate, email_util.TemplateData{Name: "Test"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test@example.com"}, enqueued.To)
+	assert.NotEmpty(t, enqueued.Subject)
+	assert.NotEmpty(t, enqueued.TextBody)
+	assert.NotEmpty(t, enqueued.HtmlBody)
+	mockStore.AssertExpectations(t)
+}
+
+func TestEmailOutboxEnqueueWithoutRecipients(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	outbox := service.NewEmailOutbox(mockStore)
+
+	_, err := outbox.Enqueue(context.Background(), email_util.Message{Subject: "Subject"})
+
+	assert.Error(t, err)
+	mockStore.AssertNotCalled(t, "EnqueueEmail", mock.Anything, mock.Anything)
+}
+
+func TestEmailOutboxGetDeliveryStatus(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	outbox := service.NewEmailOutbox(mockStore)
+	id := uuid.New()
+	sentAt := time.Now()
+	mockStore.On("GetEmailOutboxById", mock.Anything, id).Return(repository.EmailOutbox{
+		ID:       id,
+		Status:   service.EmailSentStatus,
+		Attempts: 2,
+		SentAt:   sql.NullTime{Time: sentAt, Valid: true},
+	}, nil)
+
+	status, err := outbox.GetDeliveryStatus(context.Background(), id)
+
+	assert.NoError(t, err)
+	assert.Equal(t, service.EmailSentStatus, status.Status)
+	assert.Equal(t, int32(2), status.Attempts)
+	assert.Equal(t, sentAt, status.SentAt.Time)
+}
+
+func TestEmailOutboxGetDeliveryStatusNotFound(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	outbox := service.NewEmailOutbox(mockStore)
+	id := uuid.New()
+	mockStore.On("GetEmailOutboxById", mock.Anything, id).Return(repository.EmailOutbox{}, sql.ErrNoRows)
+
+	_, err := outbox.GetDeliveryStatus(context.Background(), id)
+
+	assert.IsType(t, &repository.NotFoundError{}, err)
+}
+
+func TestEmailOutboxWorkerDelivers(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	mockSender := new(MockEmailSender)
+	worker := newTestEmailOutboxWorker(mockStore, mockSender)
+	message := email_util.Message{To: []string{"test@example.com"}, Subject: "Subject", TextBody: "Body"}
+	email := newOutboxEmail(t, message, 0)
+
+	mockStore.On("ClaimDueEmails", mock.Anything, mock.MatchedBy(func(arg repository.ClaimDueEmailsParams) bool {
+		return arg.BatchSize == 10 && arg.LockedUntil.Valid && arg.LockedUntil.Time.After(time.Now()) &&
+			arg.LockedBy == uuid.NullUUID{UUID: worker.ID, Valid: true}
+	})).Return([]repository.EmailOutbox{email}, nil)
+	mockSender.On("Send", message).Return(nil)
+	mockStore.On("MarkEmailSent", mock.Anything, repository.MarkEmailSentParams{
+		ID:       email.ID,
+		LockedBy: uuid.NullUUID{UUID: worker.ID, Valid: true},
+	}).Return(repository.EmailOutbox{}, nil)
+
+	claimed, err := worker.ProcessBatch(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	mockStore.AssertExpectations(t)
+	mockSender.AssertExpectations(t)
+}
+
+func TestEmailOutboxWorkerRetriesWithBackoff(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	mockSender := new(MockEmailSender)
+	worker := newTestEmailOutboxWorker(mockStore, mockSender)
+	message := email_util.Message{To: []string{"test@example.com"}, Subject: "Subject", TextBody: "Body"}
+	email := newOutboxEmail(t, message, 1)
+
+	mockStore.On("ClaimDueEmails", mock.Anything, mock.Anything).Return([]repository.EmailOutbox{email}, nil)
+	mockSender.On("Send", message).Return(errors.New("connection refused"))
+	mockStore.On("MarkEmailFailed", mock.Anything, mock.MatchedBy(func(arg repository.MarkEmailFailedParams) bool {
+		delay := time.Until(arg.NextAttemptAt)
+		return arg.ID == email.ID &&
+			arg.LockedBy == uuid.NullUUID{UUID: worker.ID, Valid: true} &&
+			arg.Status == service.EmailPendingStatus &&
+			arg.LastError == "connection refused" &&
+			delay > time.Minute && delay <= 2*time.Minute
+	})).Return(repository.EmailOutbox{}, nil)
+
+	_, err := worker.ProcessBatch(context.Background())
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "MarkEmailSent", mock.Anything, mock.Anything)
+}
+
+func TestEmailOutboxWorkerDeadLetters(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	mockSender := new(MockEmailSender)
+	worker := newTestEmailOutboxWorker(mockStore, mockSender)
+	message := email_util.Message{To: []string{"test@example.com"}, Subject: "Subject", TextBody: "Body"}
+	email := newOutboxEmail(t, message, 2)
+
+	mockStore.On("ClaimDueEmails", mock.Anything, mock.Anything).Return([]repository.EmailOutbox{email}, nil)
+	mockSender.On("Send", message).Return(errors.New("mailbox unavailable"))
+	mockStore.On("MarkEmailFailed", mock.Anything, mock.MatchedBy(func(arg repository.MarkEmailFailedParams) bool {
+		return arg.ID == email.ID && arg.Status == service.EmailDeadStatus
+	})).Return(repository.EmailOutbox{}, nil)
+
+	_, err := worker.ProcessBatch(context.Background())
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+}
+
+func TestEmailOutboxWorkerDeadLettersInvalidMessage(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	mockSender := new(MockEmailSender)
+	worker := newTestEmailOutboxWorker(mockStore, mockSender)
+	worker.MaxAttempts = 1
+	email := repository.EmailOutbox{ID: uuid.New(), Message: json.RawMessage(`"not a message"`)}
+
+	mockStore.On("ClaimDueEmails", mock.Anything, mock.Anything).Return([]repository.EmailOutbox{email}, nil)
+	mockStore.On("MarkEmailFailed", mock.Anything, mock.MatchedBy(func(arg repository.MarkEmailFailedParams) bool {
+		return arg.ID == email.ID && arg.Status == service.EmailDeadStatus
+	})).Return(repository.EmailOutbox{}, nil)
+
+	_, err := worker.ProcessBatch(context.Background())
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockSender.AssertNotCalled(t, "Send", mock.Anything)
+}
+
+func TestEmailOutboxWorkerLostLock(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	mockSender := new(MockEmailSender)
+	worker := newTestEmailOutboxWorker(mockStore, mockSender)
+	message := email_util.Message{To: []string{"test@example.com"}, Subject: "Subject", TextBody: "Body"}
+	email := newOutboxEmail(t, message, 0)
+
+	mockStore.On("ClaimDueEmails", mock.Anything, mock.Anything).Return([]repository.EmailOutbox{email}, nil)
+	mockSender.On("Send", message).Return(nil)
+	mockStore.On("MarkEmailSent", mock.Anything, mock.Anything).Return(repository.EmailOutbox{}, sql.ErrNoRows)
+
+	_, err := worker.ProcessBatch(context.Background())
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "MarkEmailFailed", mock.Anything, mock.Anything)
+}
+
+func TestEmailOutboxWorkerSkipsExpiredLock(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	mockSender := new(MockEmailSender)
+	worker := newTestEmailOutboxWorker(mockStore, mockSender)
+	message := email_util.Message{To: []string{"test@example.com"}, Subject: "Subject", TextBody: "Body"}
+	email := newOutboxEmail(t, message, 0)
+	email.LockedUntil = sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true}
+
+	mockStore.On("ClaimDueEmails", mock.Anything, mock.Anything).Return([]repository.EmailOutbox{email}, nil)
+
+	_, err := worker.ProcessBatch(context.Background())
+
+	assert.NoError(t, err)
+	mockSender.AssertNotCalled(t, "Send", mock.Anything)
+	mockStore.AssertNotCalled(t, "MarkEmailSent", mock.Anything, mock.Anything)
+	mockStore.AssertNotCalled(t, "MarkEmailFailed", mock.Anything, mock.Anything)
+}
+
+func TestEmailOutboxWorkerRetryDelay(t *testing.T) {
+	worker := newTestEmailOutboxWorker(nil, nil)
+
+	assert.Equal(t, time.Minute, worker.RetryDelay(1))
+	assert.Equal(t, 2*time.Minute, worker.RetryDelay(2))
+	assert.Equal(t, 8*time.Minute, worker.RetryDelay(4))
+	assert.Equal(t, 10*time.Minute, worker.RetryDelay(5))
+	assert.Equal(t, 10*time.Minute, worker.RetryDelay(100))
+}
+
+func TestEmailOutboxWorkerPurgeEmails(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	worker := newTestEmailOutboxWorker(mockStore, nil)
+
+	mockStore.On("DeleteEmailOutboxBefore", mock.Anything, mock.MatchedBy(func(createdAt time.Time) bool {
+		age := time.Since(createdAt)
+		return age >= 24*time.Hour && age < 25*time.Hour
+	})).Return(int64(3), nil)
+
+	purged, err := worker.PurgeEmails(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	mockStore.AssertExpectations(t)
+}
+
+func TestEmailOutboxWorkerPurgeEmailsWithoutRetention(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	worker := newTestEmailOutboxWorker(mockStore, nil)
+	worker.Retention = 0
+
+	purged, err := worker.PurgeEmails(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+	mockStore.AssertNotCalled(t, "DeleteEmailOutboxBefore", mock.Anything, mock.Anything)
+}
+
+func newTestAesKeyRing(t *testing.T) *keys.AesKeyRing {
+	key, err := keys.GenerateAesKey()
+	assert.NoError(t, err)
+	ring, err := keys.NewAesKeyRing("test", map[string][]byte{"test": key})
+	assert.NoError(t, err)
+	return ring
+}
+
+func TestEmailOutboxEncryptsMessages(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	mockSender := new(MockEmailSender)
+	ring := newTestAesKeyRing(t)
+	outbox := service.NewEmailOutbox(mockStore)
+	outbox.Cipher = ring
+	worker := newTestEmailOutboxWorker(mockStore, mockSender)
+	worker.Cipher = ring
+	message := email_util.Message{To: []string{"test@example.com"}, Subject: "Subject", TextBody: "https://example.com/reset-password?token=secret"}
+
+	var stored json.RawMessage
+	mockStore.On("EnqueueEmail", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(json.RawMessage)
+	}).Return(repository.EmailOutbox{}, nil)
+
+	_, err := outbox.Enqueue(context.Background(), message)
+
+	assert.NoError(t, err)
+	assert.True(t, json.Valid(stored))
+	assert.NotContains(t, string(stored), "secret", "Expected the message to be stored encrypted")
+
+	email := repository.EmailOutbox{ID: uuid.New(), Message: stored, LockedUntil: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}}
+	mockStore.On("ClaimDueEmails", mock.Anything, mock.Anything).Return([]repository.EmailOutbox{email}, nil)
+	mockSender.On("Send", message).Return(nil)
+	mockStore.On("MarkEmailSent", mock.Anything, mock.Anything).Return(repository.EmailOutbox{}, nil)
+
+	_, err = worker.ProcessBatch(context.Background())
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockSender.AssertExpectations(t)
+}
+
+func TestEmailOutboxWorkerDecodesPlainMessagesWithCipher(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	mockSender := new(MockEmailSender)
+	worker := newTestEmailOutboxWorker(mockStore, mockSender)
+	worker.Cipher = newTestAesKeyRing(t)
+	message := email_util.Message{To: []string{"test@example.com"}, Subject: "Subject", TextBody: "Body"}
+	email := newOutboxEmail(t, message, 0)
+
+	mockStore.On("ClaimDueEmails", mock.Anything, mock.Anything).Return([]repository.EmailOutbox{email}, nil)
+	mockSender.On("Send", message).Return(nil)
+	mockStore.On("MarkEmailSent", mock.Anything, mock.Anything).Return(repository.EmailOutbox{}, nil)
+
+	_, err := worker.ProcessBatch(context.Background())
+
+	assert.NoError(t, err)
+	mockSender.AssertExpectations(t)
+}
+
+func TestEmailOutboxWorkerWithoutCipherFailsEncryptedMessages(t *testing.T) {
+	mockStore := new(MockEmailOutboxStore)
+	mockSender := new(MockEmailSender)
+	outbox := service.NewEmailOutbox(mockStore)
+	outbox.Cipher = newTestAesKeyRing(t)
+	worker := newTestEmailOutboxWorker(mockStore, mockSender)
+	message := email_util.Message{To: []string{"test@example.com"}, Subject: "Subject", TextBody: "Body"}
+
+	var stored json.RawMessage
+	mockStore.On("EnqueueEmail", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(json.RawMessage)
+	}).Return(repository.EmailOutbox{}, nil)
+	_, err := outbox.Enqueue(context.Background(), message)
+	assert.NoError(t, err)
+
+	email := repository.EmailOutbox{ID: uuid.New(), Message: stored}
+	mockStore.On("ClaimDueEmails", mock.Anything, mock.Anything).Return([]repository.EmailOutbox{email}, nil)
+	mockStore.On("MarkEmailFailed", mock.Anything, mock.MatchedBy(func(arg repository.MarkEmailFailedParams) bool {
+		return arg.ID == email.ID && arg.Status == service.EmailPendingStatus
+	})).Return(repository.EmailOutbox{}, nil)
+
+	_, err = worker.ProcessBatch(context.Background())
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockSender.AssertNotCalled(t, "Send", mock.Anything)
+}
@@ -0,0 +1,420 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/service"
+	"eau-de-go/pkg/email_util"
+	"eau-de-go/pkg/password_util"
+	"eau-de-go/settings"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"sync"
+	"testing"
+	"time"
+)
+
+type MockLoginThrottleStore struct {
+	mock.Mock
+}
+
+func (m *MockLoginThrottleStore) GetAccountLoginFailure(ctx context.Context, userID uuid.UUID) (repository.AccountLoginFailure, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(repository.AccountLoginFailure), args.Error(1)
+}
+
+func (m *MockLoginThrottleStore) BeginAccountLoginAttempt(ctx context.Context, arg repository.BeginAccountLoginAttemptParams) (repository.AccountLoginFailure, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.AccountLoginFailure), args.Error(1)
+}
+
+func (m *MockLoginThrottleStore) LockAccount(ctx context.Context, arg repository.LockAccountParams) (repository.AccountLoginFailure, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.AccountLoginFailure), args.Error(1)
+}
+
+func (m *MockLoginThrottleStore) DeleteAccountLoginFailure(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleStore) GetIpLoginFailure(ctx context.Context, ipAddress string) (repository.IpLoginFailure, error) {
+	args := m.Called(ctx, ipAddress)
+	return args.Get(0).(repository.IpLoginFailure), args.Error(1)
+}
+
+func (m *MockLoginThrottleStore) BeginIpLoginAttempt(ctx context.Context, arg repository.BeginIpLoginAttemptParams) (repository.IpLoginFailure, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.IpLoginFailure), args.Error(1)
+}
+
+func (m *MockLoginThrottleStore) ReleaseIpLoginAttempt(ctx context.Context, ipAddress string) error {
+	args := m.Called(ctx, ipAddress)
+	return args.Error(0)
+}
+
+// newMockLoginThrottleStore returns a store without any failed login attempts, counting new ones from scratch.
+func newMockLoginThrottleStore() *MockLoginThrottleStore {
+	mockStore := new(MockLoginThrottleStore)
+	mockStore.On("BeginIpLoginAttempt", mock.Anything, mock.Anything).Return(repository.IpLoginFailure{FailedAttempts: 1}, nil).Maybe()
+	mockStore.On("ReleaseIpLoginAttempt", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockStore.On("BeginAccountLoginAttempt", mock.Anything, mock.Anything).Return(repository.AccountLoginFailure{FailedAttempts: 1}, nil).Maybe()
+	mockStore.On("DeleteAccountLoginFailure", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return mockStore
+}
+
+// concurrentLoginThrottleStore counts login attempts to a single account atomically, like the upsert of the database.
+type concurrentLoginThrottleStore struct {
+	mu             sync.Mutex
+	failedAttempts int32
+	lockedUntil    sql.NullTime
+	locks          int
+}
+
+func (s *concurrentLoginThrottleStore) GetAccountLoginFailure(ctx context.Context, userID uuid.UUID) (repository.AccountLoginFailure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return repository.AccountLoginFailure{UserID: userID, FailedAttempts: s.failedAttempts, LockedUntil: s.lockedUntil}, nil
+}
+
+func (s *concurrentLoginThrottleStore) BeginAccountLoginAttempt(ctx context.Context, arg repository.BeginAccountLoginAttemptParams) (repository.AccountLoginFailure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lockedUntil.Valid && arg.AttemptedAt.Before(s.lockedUntil.Time) {
+		return repository.AccountLoginFailure{}, sql.ErrNoRows
+	}
+	s.failedAttempts++
+	return repository.AccountLoginFailure{UserID: arg.UserID, FailedAttempts: s.failedAttempts, LastFailedAt: arg.AttemptedAt}, nil
+}
+
+func (s *concurrentLoginThrottleStore) LockAccount(ctx context.Context, arg repository.LockAccountParams) (repository.AccountLoginFailure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lockedUntil = arg.LockedUntil
+	s.locks++
+	return repository.AccountLoginFailure{UserID: arg.UserID, FailedAttempts: s.failedAttempts, LockedUntil: s.lockedUntil}, nil
+}
+
+func (s *concurrentLoginThrottleStore) DeleteAccountLoginFailure(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failedAttempts = 0
+	s.lockedUntil = sql.NullTime{}
+	return nil
+}
+
+func (s *concurrentLoginThrottleStore) GetIpLoginFailure(ctx context.Context, ipAddress string) (repository.IpLoginFailure, error) {
+	return repository.IpLoginFailure{}, sql.ErrNoRows
+}
+
+func (s *concurrentLoginThrottleStore) BeginIpLoginAttempt(ctx context.Context, arg repository.BeginIpLoginAttemptParams) (repository.IpLoginFailure, error) {
+	return repository.IpLoginFailure{IpAddress: arg.IpAddress, FailedAttempts: 1, WindowStartedAt: arg.AttemptedAt}, nil
+}
+
+func (s *concurrentLoginThrottleStore) ReleaseIpLoginAttempt(ctx context.Context, ipAddress string) error {
+	return nil
+}
+
+const throttleTestPassword = "P4ssword!123"
+
+func newThrottleTestUser(t *testing.T) repository.AppUser {
+	passwordHash, err := password_util.HashPassword(throttleTestPassword)
+	assert.NoError(t, err)
+	return repository.AppUser{ID: uuid.New(), Username: "user", Email: "user@example.com", Password: string(passwordHash), IsActive: true}
+}
+
+func TestLoginLocksAccountAfterMaxFailedAttempts(t *testing.T) {
+	ctx := context.Background()
+	user := newThrottleTestUser(t)
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserByUsername", ctx, user.Username).Return(user, nil)
+	mockThrottleStore := new(MockLoginThrottleStore)
+	mockThrottleStore.On("BeginIpLoginAttempt", ctx, mock.MatchedBy(func(arg repository.BeginIpLoginAttemptParams) bool {
+		return arg.IpAddress == "127.0.0.1" &&
+			arg.AttemptedAt.Sub(arg.WindowStart) == settings.LoginIpWindow &&
+			int(arg.MaxAttempts) == settings.LoginIpMaxFailedAttempts
+	})).Return(repository.IpLoginFailure{FailedAttempts: 1}, nil)
+	mockThrottleStore.On("BeginAccountLoginAttempt", ctx, mock.MatchedBy(func(arg repository.BeginAccountLoginAttemptParams) bool {
+		return arg.UserID == user.ID &&
+			arg.AttemptedAt.Sub(arg.WindowStart) == settings.LoginFailureWindow &&
+			arg.DelayBaseSeconds == settings.LoginDelayBase.Seconds() &&
+			arg.DelayMaxSeconds == settings.LoginDelayMax.Seconds()
+	})).Return(repository.AccountLoginFailure{UserID: user.ID, FailedAttempts: int32(settings.LoginMaxFailedAttempts)}, nil)
+	mockThrottleStore.On("LockAccount", ctx, mock.MatchedBy(func(arg repository.LockAccountParams) bool {
+		return arg.UserID == user.ID && arg.LockedUntil.Valid && arg.LockedUntil.Time.After(time.Now())
+	})).Return(repository.AccountLoginFailure{}, nil)
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("InvalidateUserVerificationTokens", ctx, repository.InvalidateUserVerificationTokensParams{
+		UserID:  user.ID,
+		Purpose: service.AccountUnlockPurpose,
+	}).Return(nil)
+	mockTokenStore.On("CreateVerificationToken", ctx, mock.MatchedBy(func(arg repository.CreateVerificationTokenParams) bool {
+		return arg.UserID == user.ID && arg.Purpose == service.AccountUnlockPurpose
+	})).Return(repository.VerificationToken{}, nil)
+	mockSender := new(MockEmailSender)
+	mockSender.On("SendTemplatedEmail", user.Email, email_util.AccountLockedEmailTemplate, mock.MatchedBy(func(data email_util.TemplateData) bool {
+		return data.Link != "" && data.ExpiresIn == settings.LoginLockoutDuration
+	})).Return(nil)
+	mockAuditLogger := new(MockAuditLogger)
+	mockAuditLogger.On("LogAuditEvent", ctx, matchAuditEntry(uuid.Nil, user.ID, service.UserLoginFailedAction)).Return()
+	mockAuditLogger.On("LogAuditEvent", ctx, matchAuditEntry(uuid.Nil, user.ID, service.UserAccountLockedAction)).Return()
+
+	s := service.AppUserService{
+		AppUserStore:           mockStore,
+		LoginThrottleStore:     mockThrottleStore,
+		VerificationTokenStore: mockTokenStore,
+		EmailSender:            mockSender,
+		AuditLogger:            mockAuditLogger,
+	}
+
+	_, err := s.Login(ctx, user.Username, "wrong password", adminClientInfo)
+
+	var lockedAccountError *repository.LockedAccountError
+	assert.ErrorAs(t, err, &lockedAccountError)
+	assert.WithinDuration(t, time.Now().Add(settings.LoginLockoutDuration), lockedAccountError.LockedUntil, time.Minute)
+	mockThrottleStore.AssertExpectations(t)
+	mockTokenStore.AssertExpectations(t)
+	mockSender.AssertExpectations(t)
+	mockAuditLogger.AssertExpectations(t)
+}
+
+func TestLoginIncorrectPasswordBelowMaxFailedAttempts(t *testing.T) {
+	ctx := context.Background()
+	user := newThrottleTestUser(t)
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserByUsername", ctx, user.Username).Return(user, nil)
+	mockThrottleStore := newMockLoginThrottleStore()
+
+	s := service.AppUserService{AppUserStore: mockStore, LoginThrottleStore: mockThrottleStore}
+
+	_, err := s.Login(ctx, user.Username, "wrong password", repository.ClientInfo{})
+
+	assert.IsType(t, &repository.IncorrectUserCredentialError{}, err)
+	mockThrottleStore.AssertCalled(t, "BeginAccountLoginAttempt", ctx, mock.Anything)
+	mockThrottleStore.AssertNotCalled(t, "LockAccount", mock.Anything, mock.Anything)
+	mockThrottleStore.AssertNotCalled(t, "DeleteAccountLoginFailure", mock.Anything, mock.Anything)
+	mockThrottleStore.AssertNotCalled(t, "BeginIpLoginAttempt", mock.Anything, mock.Anything)
+}
+
+func TestLoginReleasesIpAttemptOnSuccess(t *testing.T) {
+	ctx := context.Background()
+	user := newThrottleTestUser(t)
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserByUsername", ctx, user.Username).Return(user, nil)
+	mockStore.On("UpdateAppUserLastLoginNow", ctx, user.ID).Return(user, nil)
+	mockThrottleStore := new(MockLoginThrottleStore)
+	mockThrottleStore.On("BeginIpLoginAttempt", ctx, mock.Anything).Return(repository.IpLoginFailure{FailedAttempts: 3}, nil)
+	mockThrottleStore.On("BeginAccountLoginAttempt", ctx, mock.Anything).Return(repository.AccountLoginFailure{FailedAttempts: 2}, nil)
+	mockThrottleStore.On("ReleaseIpLoginAttempt", ctx, "127.0.0.1").Return(nil)
+	mockThrottleStore.On("DeleteAccountLoginFailure", ctx, user.ID).Return(nil)
+
+	s := service.AppUserService{AppUserStore: mockStore, LoginThrottleStore: mockThrottleStore}
+
+	_, err := s.Login(ctx, user.Username, throttleTestPassword, adminClientInfo)
+
+	assert.NoError(t, err)
+	mockThrottleStore.AssertExpectations(t)
+}
+
+func TestLoginRejectsLockedAccountWithoutCheckingPassword(t *testing.T) {
+	ctx := context.Background()
+	user := newThrottleTestUser(t)
+	lockedUntil := time.Now().Add(10 * time.Minute)
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserByUsername", ctx, user.Username).Return(user, nil)
+	mockThrottleStore := new(MockLoginThrottleStore)
+	mockThrottleStore.On("BeginAccountLoginAttempt", ctx, mock.Anything).Return(repository.AccountLoginFailure{}, sql.ErrNoRows)
+	mockThrottleStore.On("GetAccountLoginFailure", ctx, user.ID).Return(repository.AccountLoginFailure{
+		UserID:         user.ID,
+		FailedAttempts: 5,
+		LastFailedAt:   time.Now().Add(-5 * time.Minute),
+		LockedUntil:    sql.NullTime{Time: lockedUntil, Valid: true},
+	}, nil)
+
+	s := service.AppUserService{AppUserStore: mockStore, LoginThrottleStore: mockThrottleStore}
+
+	_, err := s.Login(ctx, user.Username, throttleTestPassword, repository.ClientInfo{})
+
+	assert.Equal(t, &repository.LockedAccountError{LockedUntil: lockedUntil}, err)
+	mockStore.AssertNotCalled(t, "UpdateAppUserLastLoginNow", mock.Anything, mock.Anything)
+	mockThrottleStore.AssertNotCalled(t, "DeleteAccountLoginFailure", mock.Anything, mock.Anything)
+}
+
+func TestLoginAfterLockoutExpired(t *testing.T) {
+	ctx := context.Background()
+	user := newThrottleTestUser(t)
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserByUsername", ctx, user.Username).Return(user, nil)
+	mockStore.On("UpdateAppUserLastLoginNow", ctx, user.ID).Return(user, nil)
+	mockThrottleStore := new(MockLoginThrottleStore)
+	mockThrottleStore.On("BeginAccountLoginAttempt", ctx, mock.Anything).Return(repository.AccountLoginFailure{
+		UserID:         user.ID,
+		FailedAttempts: 1,
+		LastFailedAt:   time.Now(),
+	}, nil)
+	mockThrottleStore.On("DeleteAccountLoginFailure", ctx, user.ID).Return(nil)
+
+	s := service.AppUserService{AppUserStore: mockStore, LoginThrottleStore: mockThrottleStore}
+
+	result, err := s.Login(ctx, user.Username, throttleTestPassword, repository.ClientInfo{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, result.ID)
+	mockThrottleStore.AssertExpectations(t)
+}
+
+func TestLoginProgressiveDelay(t *testing.T) {
+	ctx := context.Background()
+	user := newThrottleTestUser(t)
+	lastFailedAt := time.Now()
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserByUsername", ctx, user.Username).Return(user, nil)
+	mockThrottleStore := new(MockLoginThrottleStore)
+	mockThrottleStore.On("BeginAccountLoginAttempt", ctx, mock.Anything).Return(repository.AccountLoginFailure{}, sql.ErrNoRows)
+	mockThrottleStore.On("GetAccountLoginFailure", ctx, user.ID).Return(repository.AccountLoginFailure{
+		UserID:         user.ID,
+		FailedAttempts: 3,
+		LastFailedAt:   lastFailedAt,
+	}, nil)
+
+	s := service.AppUserService{AppUserStore: mockStore, LoginThrottleStore: mockThrottleStore}
+
+	_, err := s.Login(ctx, user.Username, throttleTestPassword, repository.ClientInfo{})
+
+	assert.Equal(t, &repository.LoginThrottledError{RetryAfter: lastFailedAt.Add(min(4*settings.LoginDelayBase, settings.LoginDelayMax))}, err)
+	mockStore.AssertNotCalled(t, "UpdateAppUserLastLoginNow", mock.Anything, mock.Anything)
+}
+
+func TestLoginThrottlesIpAddress(t *testing.T) {
+	ctx := context.Background()
+	windowStartedAt := time.Now().Add(-time.Minute)
+
+	mockStore := new(MockAppUserStore)
+	mockThrottleStore := new(MockLoginThrottleStore)
+	mockThrottleStore.On("BeginIpLoginAttempt", ctx, mock.Anything).Return(repository.IpLoginFailure{}, sql.ErrNoRows)
+	mockThrottleStore.On("GetIpLoginFailure", ctx, "127.0.0.1").Return(repository.IpLoginFailure{
+		IpAddress:       "127.0.0.1",
+		FailedAttempts:  int32(settings.LoginIpMaxFailedAttempts),
+		WindowStartedAt: windowStartedAt,
+	}, nil)
+
+	s := service.AppUserService{AppUserStore: mockStore, LoginThrottleStore: mockThrottleStore}
+
+	_, err := s.Login(ctx, "user", throttleTestPassword, adminClientInfo)
+
+	assert.Equal(t, &repository.LoginThrottledError{RetryAfter: windowStartedAt.Add(settings.LoginIpWindow)}, err)
+	mockStore.AssertNotCalled(t, "GetAppUserByUsername", mock.Anything, mock.Anything)
+}
+
+func TestLoginRejectsAttemptsBeyondMaxWithoutCheckingPassword(t *testing.T) {
+	ctx := context.Background()
+	user := newThrottleTestUser(t)
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserByUsername", ctx, user.Username).Return(user, nil)
+	mockThrottleStore := new(MockLoginThrottleStore)
+	mockThrottleStore.On("BeginAccountLoginAttempt", ctx, mock.Anything).Return(repository.AccountLoginFailure{
+		UserID:         user.ID,
+		FailedAttempts: int32(settings.LoginMaxFailedAttempts + 1),
+		LastFailedAt:   time.Now(),
+	}, nil)
+
+	s := service.AppUserService{AppUserStore: mockStore, LoginThrottleStore: mockThrottleStore}
+
+	_, err := s.Login(ctx, user.Username, throttleTestPassword, repository.ClientInfo{})
+
+	assert.IsType(t, &repository.LoginThrottledError{}, err)
+	mockStore.AssertNotCalled(t, "UpdateAppUserLastLoginNow", mock.Anything, mock.Anything)
+	mockThrottleStore.AssertNotCalled(t, "DeleteAccountLoginFailure", mock.Anything, mock.Anything)
+}
+
+func TestConcurrentLoginsCheckAtMostMaxPasswords(t *testing.T) {
+	ctx := context.Background()
+	user := newThrottleTestUser(t)
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserByUsername", ctx, user.Username).Return(user, nil)
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("InvalidateUserVerificationTokens", ctx, mock.Anything).Return(nil)
+	mockTokenStore.On("CreateVerificationToken", ctx, mock.Anything).Return(repository.VerificationToken{}, nil)
+	mockSender := new(MockEmailSender)
+	mockSender.On("SendTemplatedEmail", user.Email, email_util.AccountLockedEmailTemplate, mock.Anything).Return(nil)
+	throttleStore := &concurrentLoginThrottleStore{}
+
+	s := service.AppUserService{
+		AppUserStore:           mockStore,
+		LoginThrottleStore:     throttleStore,
+		VerificationTokenStore: mockTokenStore,
+		EmailSender:            mockSender,
+	}
+
+	attempts := 4 * settings.LoginMaxFailedAttempts
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Login(ctx, user.Username, "wrong password", repository.ClientInfo{})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	incorrectCredentials := 0
+	for err := range errs {
+		if _, ok := err.(*repository.IncorrectUserCredentialError); ok {
+			incorrectCredentials++
+		}
+	}
+	assert.Equal(t, settings.LoginMaxFailedAttempts-1, incorrectCredentials, "Expected only the attempts up to the lockout to check the password")
+	assert.Equal(t, 1, throttleStore.locks)
+	mockSender.AssertNumberOfCalls(t, "SendTemplatedEmail", 1)
+}
+
+func TestUnlockAccount(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("ConsumeVerificationToken", ctx, repository.ConsumeVerificationTokenParams{
+		TokenHash: email_util.HashVerificationToken("token"),
+		Purpose:   service.AccountUnlockPurpose,
+	}).Return(repository.VerificationToken{UserID: userId}, nil)
+	mockThrottleStore := new(MockLoginThrottleStore)
+	mockThrottleStore.On("DeleteAccountLoginFailure", ctx, userId).Return(nil)
+	mockAuditLogger := new(MockAuditLogger)
+	mockAuditLogger.On("LogAuditEvent", ctx, matchAuditEntry(userId, userId, service.UserAccountUnlockedAction)).Return()
+
+	s := service.AppUserService{VerificationTokenStore: mockTokenStore, LoginThrottleStore: mockThrottleStore, AuditLogger: mockAuditLogger}
+
+	err := s.UnlockAccount(ctx, "token", adminClientInfo)
+
+	assert.NoError(t, err)
+	mockThrottleStore.AssertExpectations(t)
+	mockAuditLogger.AssertExpectations(t)
+}
+
+func TestUnlockAccountInvalidToken(t *testing.T) {
+	ctx := context.Background()
+
+	mockTokenStore := new(MockVerificationTokenStore)
+	mockTokenStore.On("ConsumeVerificationToken", ctx, mock.Anything).Return(repository.VerificationToken{}, sql.ErrNoRows)
+	mockThrottleStore := new(MockLoginThrottleStore)
+
+	s := service.AppUserService{VerificationTokenStore: mockTokenStore, LoginThrottleStore: mockThrottleStore}
+
+	err := s.UnlockAccount(ctx, "token", repository.ClientInfo{})
+
+	assert.IsType(t, &repository.InvalidVerificationTokenError{}, err)
+	mockThrottleStore.AssertNotCalled(t, "DeleteAccountLoginFailure", mock.Anything, mock.Anything)
+}
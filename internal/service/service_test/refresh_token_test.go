@@ -0,0 +1,116 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/service"
+	"eau-de-go/pkg/jwt_util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+type MockRefreshTokenStore struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenStore) CreateRefreshToken(ctx context.Context, arg repository.CreateRefreshTokenParams) (repository.RefreshToken, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenStore) GetRefreshTokenByJti(ctx context.Context, jti uuid.UUID) (repository.RefreshToken, error) {
+	args := m.Called(ctx, jti)
+	return args.Get(0).(repository.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenStore) RevokeRefreshToken(ctx context.Context, jti uuid.UUID) (repository.RefreshToken, error) {
+	args := m.Called(ctx, jti)
+	return args.Get(0).(repository.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenStore) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenStore) RevokeAllUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenStore) DeleteStaleRefreshTokens(ctx context.Context, expiresAt time.Time) (int64, error) {
+	args := m.Called(ctx, expiresAt)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestRefreshTokenRevocationChecker_ActiveToken(t *testing.T) {
+	mockStore := new(MockRefreshTokenStore)
+	checker := service.NewRefreshTokenRevocationChecker(mockStore)
+
+	jti := uuid.New()
+	mockStore.On("GetRefreshTokenByJti", mock.Anything, jti).Return(repository.RefreshToken{Jti: jti}, nil)
+
+	revoked, err := checker.IsRevoked(context.Background(), jwt_util.Refresh, jti.String())
+
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	mockStore.AssertExpectations(t)
+}
+
+func TestRefreshTokenRevocationChecker_RevokedToken(t *testing.T) {
+	mockStore := new(MockRefreshTokenStore)
+	checker := service.NewRefreshTokenRevocationChecker(mockStore)
+
+	jti := uuid.New()
+	revokedAt := sql.NullTime{Time: time.Now(), Valid: true}
+	mockStore.On("GetRefreshTokenByJti", mock.Anything, jti).Return(repository.RefreshToken{Jti: jti, RevokedAt: revokedAt}, nil)
+
+	revoked, err := checker.IsRevoked(context.Background(), jwt_util.Refresh, jti.String())
+
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	mockStore.AssertExpectations(t)
+}
+
+func TestRefreshTokenRevocationChecker_UnknownToken(t *testing.T) {
+	mockStore := new(MockRefreshTokenStore)
+	checker := service.NewRefreshTokenRevocationChecker(mockStore)
+
+	jti := uuid.New()
+	mockStore.On("GetRefreshTokenByJti", mock.Anything, jti).Return(repository.RefreshToken{}, sql.ErrNoRows)
+
+	revoked, err := checker.IsRevoked(context.Background(), jwt_util.Refresh, jti.String())
+
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	mockStore.AssertExpectations(t)
+}
+
+func TestRefreshTokenRevocationChecker_AccessTokenNotChecked(t *testing.T) {
+	mockStore := new(MockRefreshTokenStore)
+	checker := service.NewRefreshTokenRevocationChecker(mockStore)
+
+	revoked, err := checker.IsRevoked(context.Background(), jwt_util.Access, uuid.New().String())
+
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	mockStore.AssertNotCalled(t, "GetRefreshTokenByJti")
+}
+
+func TestPurgeRefreshTokens(t *testing.T) {
+	mockStore := new(MockRefreshTokenStore)
+	leeway := 30 * time.Second
+	mockStore.On("DeleteStaleRefreshTokens", mock.Anything, mock.MatchedBy(func(expiresAt time.Time) bool {
+		return time.Since(expiresAt) >= leeway && time.Since(expiresAt) < leeway+time.Minute
+	})).Return(int64(3), nil)
+
+	purged, err := service.PurgeRefreshTokens(context.Background(), mockStore, leeway)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	mockStore.AssertExpectations(t)
+}
@@ -0,0 +1,315 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/service"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+type MockRoleStore struct {
+	mock.Mock
+}
+
+func (m *MockRoleStore) CreateRole(ctx context.Context, arg repository.CreateRoleParams) (repository.Role, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.Role), args.Error(1)
+}
+
+func (m *MockRoleStore) GetRoleByName(ctx context.Context, name string) (repository.Role, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(repository.Role), args.Error(1)
+}
+
+func (m *MockRoleStore) ListRoles(ctx context.Context) ([]repository.Role, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]repository.Role), args.Error(1)
+}
+
+func (m *MockRoleStore) DeleteRole(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRoleStore) ListPermissions(ctx context.Context) ([]repository.Permission, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]repository.Permission), args.Error(1)
+}
+
+func (m *MockRoleStore) AddRolePermission(ctx context.Context, arg repository.AddRolePermissionParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockRoleStore) ListRolePermissions(ctx context.Context, roleID uuid.UUID) ([]string, error) {
+	args := m.Called(ctx, roleID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRoleStore) AssignUserRole(ctx context.Context, arg repository.AssignUserRoleParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockRoleStore) RevokeUserRole(ctx context.Context, arg repository.RevokeUserRoleParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockRoleStore) ListUserRoles(ctx context.Context, userID uuid.UUID) ([]repository.Role, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]repository.Role), args.Error(1)
+}
+
+func (m *MockRoleStore) ListUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func TestCreateRole(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	role := repository.Role{ID: uuid.New(), Name: "support", Description: "Support staff"}
+	mockStore := new(MockRoleStore)
+	mockStore.On("ListPermissions", ctx).Return([]repository.Permission{{Codename: service.UsersReadPermission}}, nil)
+	mockStore.On("CreateRole", ctx, repository.CreateRoleParams{Name: "support", Description: "Support staff"}).Return(role, nil)
+	mockStore.On("AddRolePermission", ctx, repository.AddRolePermissionParams{RoleID: role.ID, Permission: service.UsersReadPermission}).Return(nil)
+	mockAuditLogger := new(MockAuditLogger)
+	mockAuditLogger.On("LogAuditEvent", ctx, matchAuditEntry(actorId, role.ID, service.AdminRoleCreatedAction)).Return()
+
+	s := service.NewRoleService(mockStore, mockAuditLogger)
+
+	result, err := s.CreateRole(ctx, actorId, adminClientInfo, "support", "Support staff", []string{service.UsersReadPermission})
+
+	assert.NoError(t, err)
+	assert.Equal(t, role, result)
+	mockStore.AssertExpectations(t)
+	mockAuditLogger.AssertExpectations(t)
+}
+
+func TestCreateRole_Duplicate(t *testing.T) {
+	ctx := context.Background()
+	mockStore := new(MockRoleStore)
+	mockStore.On("CreateRole", ctx, mock.Anything).Return(repository.Role{}, &pq.Error{Code: "23505"})
+	mockAuditLogger := new(MockAuditLogger)
+
+	s := service.NewRoleService(mockStore, mockAuditLogger)
+
+	_, err := s.CreateRole(ctx, uuid.New(), adminClientInfo, "admin", "", nil)
+
+	var duplicateKeyError *repository.DuplicateKeyError
+	assert.ErrorAs(t, err, &duplicateKeyError)
+	mockAuditLogger.AssertNotCalled(t, "LogAuditEvent", mock.Anything, mock.Anything)
+}
+
+func TestCreateRole_UnknownPermission(t *testing.T) {
+	ctx := context.Background()
+	mockStore := new(MockRoleStore)
+	mockStore.On("ListPermissions", ctx).Return([]repository.Permission{{Codename: service.UsersReadPermission}}, nil)
+
+	s := service.NewRoleService(mockStore, new(MockAuditLogger))
+
+	_, err := s.CreateRole(ctx, uuid.New(), adminClientInfo, "support", "", []string{service.UsersReadPermission, "users.fly"})
+
+	var notFoundError *repository.NotFoundError
+	assert.ErrorAs(t, err, &notFoundError)
+	mockStore.AssertNotCalled(t, "CreateRole", mock.Anything, mock.Anything)
+	mockStore.AssertNotCalled(t, "AddRolePermission", mock.Anything, mock.Anything)
+}
+
+func TestCreateRole_RollsBackWhenAddingPermissionFails(t *testing.T) {
+	ctx := context.Background()
+	role := repository.Role{ID: uuid.New(), Name: "support"}
+	mockStore := new(MockRoleStore)
+	mockStore.On("ListPermissions", ctx).Return([]repository.Permission{{Codename: service.UsersReadPermission}, {Codename: service.UsersWritePermission}}, nil)
+	txStore := new(MockRoleStore)
+	txStore.On("CreateRole", ctx, mock.Anything).Return(role, nil)
+	txStore.On("AddRolePermission", ctx, repository.AddRolePermissionParams{RoleID: role.ID, Permission: service.UsersReadPermission}).Return(nil)
+	// The permission was deleted after the permissions were checked.
+	txStore.On("AddRolePermission", ctx, repository.AddRolePermissionParams{RoleID: role.ID, Permission: service.UsersWritePermission}).Return(&pq.Error{Code: "23503"})
+	txAuditStore := new(MockAuditEventStore)
+	transactor := &mockTransactor{store: &mockTxStore{
+		mockAppUserServiceStore: mockAppUserServiceStore{MockRoleStore: txStore},
+		MockAuditEventStore:     txAuditStore,
+	}}
+
+	s := service.NewRoleService(mockStore, service.NewStoreAuditLogger(new(MockAuditEventStore)))
+	s.Transactor = transactor
+
+	_, err := s.CreateRole(ctx, uuid.New(), adminClientInfo, "support", "", []string{service.UsersReadPermission, service.UsersWritePermission})
+
+	var notFoundError *repository.NotFoundError
+	assert.ErrorAs(t, err, &notFoundError)
+	assert.True(t, transactor.rolledBack, "Expected the role to be rolled back along with its permissions")
+	txStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "CreateRole", mock.Anything, mock.Anything)
+	txAuditStore.AssertNotCalled(t, "CreateAuditEvent", mock.Anything, mock.Anything)
+}
+
+func TestCreateRole_RecordsAuditEventWithinTransaction(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	role := repository.Role{ID: uuid.New(), Name: "support"}
+	txStore := new(MockRoleStore)
+	txStore.On("CreateRole", ctx, mock.Anything).Return(role, nil)
+	txAuditStore := new(MockAuditEventStore)
+	txAuditStore.On("CreateAuditEvent", ctx, matchAuditEvent(actorId, role.ID, service.AdminRoleCreatedAction)).Return(repository.AuditEvent{}, nil)
+	transactor := &mockTransactor{store: &mockTxStore{
+		mockAppUserServiceStore: mockAppUserServiceStore{MockRoleStore: txStore},
+		MockAuditEventStore:     txAuditStore,
+	}}
+	auditStore := new(MockAuditEventStore)
+
+	s := service.NewRoleService(new(MockRoleStore), service.NewStoreAuditLogger(auditStore))
+	s.Transactor = transactor
+
+	_, err := s.CreateRole(ctx, actorId, adminClientInfo, "support", "", nil)
+
+	assert.NoError(t, err)
+	assert.False(t, transactor.rolledBack)
+	txAuditStore.AssertExpectations(t)
+	auditStore.AssertNotCalled(t, "CreateAuditEvent", mock.Anything, mock.Anything)
+}
+
+func TestDeleteRole(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	role := repository.Role{ID: uuid.New(), Name: "support"}
+	mockStore := new(MockRoleStore)
+	mockStore.On("GetRoleByName", ctx, "support").Return(role, nil)
+	mockStore.On("DeleteRole", ctx, role.ID).Return(nil)
+	mockAuditLogger := new(MockAuditLogger)
+	mockAuditLogger.On("LogAuditEvent", ctx, matchAuditEntry(actorId, role.ID, service.AdminRoleDeletedAction)).Return()
+
+	s := service.NewRoleService(mockStore, mockAuditLogger)
+
+	err := s.DeleteRole(ctx, actorId, adminClientInfo, "support")
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockAuditLogger.AssertExpectations(t)
+}
+
+func TestDeleteRole_UnknownRole(t *testing.T) {
+	ctx := context.Background()
+	mockStore := new(MockRoleStore)
+	mockStore.On("GetRoleByName", ctx, "unknown").Return(repository.Role{}, sql.ErrNoRows)
+	mockAuditLogger := new(MockAuditLogger)
+
+	s := service.NewRoleService(mockStore, mockAuditLogger)
+
+	err := s.DeleteRole(ctx, uuid.New(), adminClientInfo, "unknown")
+
+	var notFoundError *repository.NotFoundError
+	assert.ErrorAs(t, err, &notFoundError)
+	mockStore.AssertNotCalled(t, "DeleteRole", mock.Anything, mock.Anything)
+	mockAuditLogger.AssertNotCalled(t, "LogAuditEvent", mock.Anything, mock.Anything)
+}
+
+func TestAssignUserRole(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	userId := uuid.New()
+	role := repository.Role{ID: uuid.New(), Name: "admin"}
+	mockStore := new(MockRoleStore)
+	mockStore.On("GetRoleByName", ctx, "admin").Return(role, nil)
+	mockStore.On("AssignUserRole", ctx, repository.AssignUserRoleParams{UserID: userId, RoleID: role.ID}).Return(nil)
+	mockAuditLogger := new(MockAuditLogger)
+	mockAuditLogger.On("LogAuditEvent", ctx, matchAuditEntry(actorId, userId, service.AdminUserRoleAssignedAction)).Return()
+
+	s := service.NewRoleService(mockStore, mockAuditLogger)
+
+	err := s.AssignUserRole(ctx, actorId, adminClientInfo, userId, "admin")
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockAuditLogger.AssertExpectations(t)
+}
+
+func TestAssignUserRole_UnknownRole(t *testing.T) {
+	ctx := context.Background()
+	mockStore := new(MockRoleStore)
+	mockStore.On("GetRoleByName", ctx, "unknown").Return(repository.Role{}, sql.ErrNoRows)
+	mockAuditLogger := new(MockAuditLogger)
+
+	s := service.NewRoleService(mockStore, mockAuditLogger)
+
+	err := s.AssignUserRole(ctx, uuid.New(), adminClientInfo, uuid.New(), "unknown")
+
+	var notFoundError *repository.NotFoundError
+	assert.ErrorAs(t, err, &notFoundError)
+	mockStore.AssertNotCalled(t, "AssignUserRole", mock.Anything, mock.Anything)
+	mockAuditLogger.AssertNotCalled(t, "LogAuditEvent", mock.Anything, mock.Anything)
+}
+
+func TestAssignUserRole_UnknownUser(t *testing.T) {
+	ctx := context.Background()
+	role := repository.Role{ID: uuid.New(), Name: "admin"}
+	mockStore := new(MockRoleStore)
+	mockStore.On("GetRoleByName", ctx, "admin").Return(role, nil)
+	mockStore.On("AssignUserRole", ctx, mock.Anything).Return(&pq.Error{Code: "23503"})
+	mockAuditLogger := new(MockAuditLogger)
+
+	s := service.NewRoleService(mockStore, mockAuditLogger)
+
+	err := s.AssignUserRole(ctx, uuid.New(), adminClientInfo, uuid.New(), "admin")
+
+	var notFoundError *repository.NotFoundError
+	assert.ErrorAs(t, err, &notFoundError)
+	mockAuditLogger.AssertNotCalled(t, "LogAuditEvent", mock.Anything, mock.Anything)
+}
+
+func TestAssignUserRole_RecordsAuditEventWithinTransaction(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	userId := uuid.New()
+	role := repository.Role{ID: uuid.New(), Name: "admin"}
+	txStore := new(MockRoleStore)
+	txStore.On("GetRoleByName", ctx, "admin").Return(role, nil)
+	txStore.On("AssignUserRole", ctx, repository.AssignUserRoleParams{UserID: userId, RoleID: role.ID}).Return(nil)
+	txAuditStore := new(MockAuditEventStore)
+	txAuditStore.On("CreateAuditEvent", ctx, matchAuditEvent(actorId, userId, service.AdminUserRoleAssignedAction)).Return(repository.AuditEvent{}, nil)
+	transactor := &mockTransactor{store: &mockTxStore{
+		mockAppUserServiceStore: mockAppUserServiceStore{MockRoleStore: txStore},
+		MockAuditEventStore:     txAuditStore,
+	}}
+	mockStore := new(MockRoleStore)
+	auditStore := new(MockAuditEventStore)
+
+	s := service.NewRoleService(mockStore, service.NewStoreAuditLogger(auditStore))
+	s.Transactor = transactor
+
+	err := s.AssignUserRole(ctx, actorId, adminClientInfo, userId, "admin")
+
+	assert.NoError(t, err)
+	assert.False(t, transactor.rolledBack)
+	txStore.AssertExpectations(t)
+	txAuditStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "AssignUserRole", mock.Anything, mock.Anything)
+	auditStore.AssertNotCalled(t, "CreateAuditEvent", mock.Anything, mock.Anything)
+}
+
+func TestRevokeUserRole(t *testing.T) {
+	ctx := context.Background()
+	actorId := uuid.New()
+	userId := uuid.New()
+	role := repository.Role{ID: uuid.New(), Name: "admin"}
+	mockStore := new(MockRoleStore)
+	mockStore.On("GetRoleByName", ctx, "admin").Return(role, nil)
+	mockStore.On("RevokeUserRole", ctx, repository.RevokeUserRoleParams{UserID: userId, RoleID: role.ID}).Return(nil)
+	mockAuditLogger := new(MockAuditLogger)
+	mockAuditLogger.On("LogAuditEvent", ctx, matchAuditEntry(actorId, userId, service.AdminUserRoleRevokedAction)).Return()
+
+	s := service.NewRoleService(mockStore, mockAuditLogger)
+
+	err := s.RevokeUserRole(ctx, actorId, adminClientInfo, userId, "admin")
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockAuditLogger.AssertExpectations(t)
+}
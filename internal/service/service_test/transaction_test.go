@@ -0,0 +1,174 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/service"
+	"eau-de-go/pkg/email_util"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+// mockTxStore composes the mock stores into the store of a transaction.
+type mockTxStore struct {
+	mockAppUserServiceStore
+	*MockEmailOutboxStore
+	*MockAuditEventStore
+}
+
+// mockTransactor runs functions with the transaction store, recording whether the transaction was rolled back.
+type mockTransactor struct {
+	store      service.TxStore
+	rolledBack bool
+}
+
+func (m *mockTransactor) ExecTx(ctx context.Context, fn func(store service.TxStore) error) error {
+	err := fn(m.store)
+	m.rolledBack = err != nil
+	return err
+}
+
+func TestSendEmailVerification_EnqueuesWithinTransaction(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+	emailAddress := "test@example.com"
+
+	txTokenStore := new(MockVerificationTokenStore)
+	txTokenStore.On("InvalidateUserVerificationTokens", ctx, mock.Anything).Return(nil)
+	txTokenStore.On("CreateVerificationToken", ctx, mock.Anything).Return(repository.VerificationToken{}, nil)
+	txOutboxStore := new(MockEmailOutboxStore)
+	var enqueued email_util.Message
+	txOutboxStore.On("EnqueueEmail", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		assert.NoError(t, json.Unmarshal(args.Get(1).(json.RawMessage), &enqueued))
+	}).Return(repository.EmailOutbox{}, nil)
+	transactor := &mockTransactor{store: &mockTxStore{
+		mockAppUserServiceStore: mockAppUserServiceStore{MockVerificationTokenStore: txTokenStore},
+		MockEmailOutboxStore:    txOutboxStore,
+	}}
+
+	outboxStore := new(MockEmailOutboxStore)
+	s := service.NewAppUserService(&mockAppUserServiceStore{}, nil)
+	s.EmailSender = service.NewEmailOutbox(outboxStore)
+	s.Transactor = transactor
+
+	err := s.SendUserEmailVerification(ctx, userId, emailAddress)
+
+	assert.NoError(t, err)
+	assert.False(t, transactor.rolledBack)
+	assert.Equal(t, []string{emailAddress}, enqueued.To)
+	txTokenStore.AssertExpectations(t)
+	txOutboxStore.AssertExpectations(t)
+	outboxStore.AssertNotCalled(t, "EnqueueEmail", mock.Anything, mock.Anything)
+}
+
+func TestSendEmailVerification_RollsBackWhenEnqueueingFails(t *testing.T) {
+	ctx := context.Background()
+
+	txTokenStore := new(MockVerificationTokenStore)
+	txTokenStore.On("InvalidateUserVerificationTokens", ctx, mock.Anything).Return(nil)
+	txTokenStore.On("CreateVerificationToken", ctx, mock.Anything).Return(repository.VerificationToken{}, nil)
+	txOutboxStore := new(MockEmailOutboxStore)
+	txOutboxStore.On("EnqueueEmail", mock.Anything, mock.Anything).Return(repository.EmailOutbox{}, errors.New("enqueue error"))
+	transactor := &mockTransactor{store: &mockTxStore{
+		mockAppUserServiceStore: mockAppUserServiceStore{MockVerificationTokenStore: txTokenStore},
+		MockEmailOutboxStore:    txOutboxStore,
+	}}
+
+	s := service.NewAppUserService(&mockAppUserServiceStore{}, nil)
+	s.EmailSender = service.NewEmailOutbox(new(MockEmailOutboxStore))
+	s.Transactor = transactor
+
+	err := s.SendUserEmailVerification(ctx, uuid.New(), "test@example.com")
+
+	assert.Error(t, err)
+	assert.True(t, transactor.rolledBack, "Expected the verification token to be rolled back along with the email")
+}
+
+func TestCreateAppUser_EnqueuesWelcomeEmailAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	service.HashPasswordFunc = func(password string) ([]byte, error) {
+		return []byte(password), nil
+	}
+	userParams := repository.CreateAppUserParams{Username: "testuser", Password: "testPassword", Email: "testuser@example.com"}
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserByUsername", ctx, userParams.Username).Return(repository.AppUser{}, sql.ErrNoRows)
+	mockHistoryStore := new(MockUsernameHistoryStore)
+	mockHistoryStore.On("IsUsernameReserved", ctx, mock.Anything).Return(false, nil)
+	txStore := new(MockAppUserStore)
+	txStore.On("CreateAppUser", ctx, userParams).Return(repository.AppUser{Email: userParams.Email}, nil)
+	txOutboxStore := new(MockEmailOutboxStore)
+	transactor := &mockTransactor{store: &mockTxStore{
+		mockAppUserServiceStore: mockAppUserServiceStore{MockAppUserStore: txStore},
+		MockEmailOutboxStore:    txOutboxStore,
+	}}
+	outboxStore := new(MockEmailOutboxStore)
+	// Enqueueing the welcome email fails, which must not fail the sign-up.
+	outboxStore.On("EnqueueEmail", mock.Anything, mock.Anything).Return(repository.EmailOutbox{}, errors.New("enqueue error"))
+
+	s := service.NewAppUserService(&mockAppUserServiceStore{MockAppUserStore: mockStore, MockUsernameHistoryStore: mockHistoryStore}, nil)
+	s.EmailSender = service.NewEmailOutbox(outboxStore)
+	s.Transactor = transactor
+
+	_, err := s.CreateAppUser(ctx, userParams)
+
+	assert.NoError(t, err)
+	assert.False(t, transactor.rolledBack)
+	txStore.AssertExpectations(t)
+	outboxStore.AssertExpectations(t)
+	txOutboxStore.AssertNotCalled(t, "EnqueueEmail", mock.Anything, mock.Anything)
+}
+
+func TestSendEmailVerification_SendsAfterCommitWithoutOutbox(t *testing.T) {
+	ctx := context.Background()
+	emailAddress := "test@example.com"
+
+	txTokenStore := new(MockVerificationTokenStore)
+	txTokenStore.On("InvalidateUserVerificationTokens", ctx, mock.Anything).Return(nil)
+	txTokenStore.On("CreateVerificationToken", ctx, mock.Anything).Return(repository.VerificationToken{}, nil)
+	transactor := &mockTransactor{store: &mockTxStore{
+		mockAppUserServiceStore: mockAppUserServiceStore{MockVerificationTokenStore: txTokenStore},
+	}}
+	committed := false
+	mockSender := new(MockEmailSender)
+	mockSender.On("SendTemplatedEmail", emailAddress, email_util.VerificationEmailTemplate, mock.Anything).Run(func(args mock.Arguments) {
+		committed = !transactor.rolledBack && txTokenStore.AssertNumberOfCalls(t, "CreateVerificationToken", 1)
+	}).Return(nil)
+
+	s := service.NewAppUserService(&mockAppUserServiceStore{}, nil)
+	s.EmailSender = mockSender
+	s.Transactor = transactor
+
+	err := s.SendUserEmailVerification(ctx, uuid.New(), emailAddress)
+
+	assert.NoError(t, err)
+	assert.True(t, committed, "Expected the email to be sent once the transaction committed")
+	mockSender.AssertExpectations(t)
+}
+
+func TestSendEmailVerification_NotSentWhenRolledBackWithoutOutbox(t *testing.T) {
+	ctx := context.Background()
+
+	txTokenStore := new(MockVerificationTokenStore)
+	txTokenStore.On("InvalidateUserVerificationTokens", ctx, mock.Anything).Return(nil)
+	txTokenStore.On("CreateVerificationToken", ctx, mock.Anything).Return(repository.VerificationToken{}, errors.New("insert error"))
+	transactor := &mockTransactor{store: &mockTxStore{
+		mockAppUserServiceStore: mockAppUserServiceStore{MockVerificationTokenStore: txTokenStore},
+	}}
+	mockSender := new(MockEmailSender)
+
+	s := service.NewAppUserService(&mockAppUserServiceStore{}, nil)
+	s.EmailSender = mockSender
+	s.Transactor = transactor
+
+	err := s.SendUserEmailVerification(ctx, uuid.New(), "test@example.com")
+
+	assert.Error(t, err)
+	assert.True(t, transactor.rolledBack)
+	mockSender.AssertNotCalled(t, "SendTemplatedEmail", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,91 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/service"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+type MockUserSessionStore struct {
+	mock.Mock
+}
+
+func (m *MockUserSessionStore) CreateUserSession(ctx context.Context, arg repository.CreateUserSessionParams) (repository.UserSession, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.UserSession), args.Error(1)
+}
+
+func (m *MockUserSessionStore) TouchUserSession(ctx context.Context, arg repository.TouchUserSessionParams) (repository.UserSession, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.UserSession), args.Error(1)
+}
+
+func (m *MockUserSessionStore) ListActiveUserSessions(ctx context.Context, userID uuid.UUID) ([]repository.UserSession, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]repository.UserSession), args.Error(1)
+}
+
+func (m *MockUserSessionStore) RevokeUserSession(ctx context.Context, arg repository.RevokeUserSessionParams) (repository.UserSession, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.UserSession), args.Error(1)
+}
+
+func (m *MockUserSessionStore) RevokeAllUserSessions(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func TestListActiveSessions(t *testing.T) {
+	mockSessionStore := new(MockUserSessionStore)
+	s := service.AppUserService{UserSessionStore: mockSessionStore}
+
+	userId := uuid.New()
+	sessions := []repository.UserSession{{ID: uuid.New(), UserID: userId}, {ID: uuid.New(), UserID: userId}}
+	mockSessionStore.On("ListActiveUserSessions", mock.Anything, userId).Return(sessions, nil)
+
+	result, err := s.ListActiveSessions(context.Background(), userId)
+
+	assert.NoError(t, err)
+	assert.Equal(t, sessions, result)
+	mockSessionStore.AssertExpectations(t)
+}
+
+func TestRevokeSession(t *testing.T) {
+	mockRefreshTokenStore := new(MockRefreshTokenStore)
+	mockSessionStore := new(MockUserSessionStore)
+	s := service.AppUserService{RefreshTokenStore: mockRefreshTokenStore, UserSessionStore: mockSessionStore}
+
+	userId := uuid.New()
+	sessionId := uuid.New()
+	mockSessionStore.On("RevokeUserSession", mock.Anything, repository.RevokeUserSessionParams{ID: sessionId, UserID: userId}).Return(repository.UserSession{ID: sessionId}, nil)
+	mockRefreshTokenStore.On("RevokeRefreshTokenFamily", mock.Anything, sessionId).Return(nil)
+
+	err := s.RevokeSession(context.Background(), userId, sessionId)
+
+	assert.NoError(t, err)
+	mockSessionStore.AssertExpectations(t)
+	mockRefreshTokenStore.AssertExpectations(t)
+}
+
+func TestRevokeSessionNotFound(t *testing.T) {
+	mockRefreshTokenStore := new(MockRefreshTokenStore)
+	mockSessionStore := new(MockUserSessionStore)
+	s := service.AppUserService{RefreshTokenStore: mockRefreshTokenStore, UserSessionStore: mockSessionStore}
+
+	userId := uuid.New()
+	sessionId := uuid.New()
+	mockSessionStore.On("RevokeUserSession", mock.Anything, repository.RevokeUserSessionParams{ID: sessionId, UserID: userId}).Return(repository.UserSession{}, sql.ErrNoRows)
+
+	err := s.RevokeSession(context.Background(), userId, sessionId)
+
+	var notFoundError *repository.NotFoundError
+	assert.True(t, errors.As(err, &notFoundError))
+	mockSessionStore.AssertExpectations(t)
+	mockRefreshTokenStore.AssertNotCalled(t, "RevokeRefreshTokenFamily", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,178 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/service"
+	"eau-de-go/pkg/username_util"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+type MockUsernameHistoryStore struct {
+	mock.Mock
+}
+
+func (m *MockUsernameHistoryStore) CreateUsernameHistory(ctx context.Context, arg repository.CreateUsernameHistoryParams) (repository.UsernameHistory, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.UsernameHistory), args.Error(1)
+}
+
+func (m *MockUsernameHistoryStore) GetLatestUsernameChange(ctx context.Context, userID uuid.UUID) (repository.UsernameHistory, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(repository.UsernameHistory), args.Error(1)
+}
+
+func (m *MockUsernameHistoryStore) IsUsernameReserved(ctx context.Context, arg repository.IsUsernameReservedParams) (bool, error) {
+	args := m.Called(ctx, arg)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestChangeUsername(t *testing.T) {
+	ctx := context.Background()
+	appUser := repository.AppUser{ID: uuid.New(), Username: "old_name"}
+	updatedUser := repository.AppUser{ID: appUser.ID, Username: "new_name"}
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, appUser.ID).Return(appUser, nil)
+	mockStore.On("GetAppUserByUsername", ctx, "new_name").Return(repository.AppUser{}, sql.ErrNoRows)
+	mockStore.On("UpdateAppUserUsername", ctx, repository.UpdateAppUserUsernameParams{ID: appUser.ID, Username: "new_name"}).Return(updatedUser, nil)
+	mockHistoryStore := new(MockUsernameHistoryStore)
+	mockHistoryStore.On("GetLatestUsernameChange", ctx, appUser.ID).Return(repository.UsernameHistory{}, sql.ErrNoRows)
+	mockHistoryStore.On("IsUsernameReserved", ctx, mock.MatchedBy(func(arg repository.IsUsernameReservedParams) bool {
+		return arg.Username == "new_name" && arg.UserID == appUser.ID && arg.ReservedSince.Before(time.Now())
+	})).Return(false, nil)
+	mockHistoryStore.On("CreateUsernameHistory", ctx, repository.CreateUsernameHistoryParams{UserID: appUser.ID, Username: "old_name"}).Return(repository.UsernameHistory{}, nil)
+
+	s := service.AppUserService{AppUserStore: mockStore, UsernameHistoryStore: mockHistoryStore}
+
+	result, err := s.ChangeUsername(ctx, appUser.ID, "new_name")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "new_name", result.Username)
+	mockStore.AssertExpectations(t)
+	mockHistoryStore.AssertExpectations(t)
+}
+
+func TestChangeUsername_CaseOnly(t *testing.T) {
+	ctx := context.Background()
+	appUser := repository.AppUser{ID: uuid.New(), Username: "john"}
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, appUser.ID).Return(appUser, nil)
+	mockStore.On("UpdateAppUserUsername", ctx, repository.UpdateAppUserUsernameParams{ID: appUser.ID, Username: "John"}).Return(repository.AppUser{ID: appUser.ID, Username: "John"}, nil)
+	mockHistoryStore := new(MockUsernameHistoryStore)
+	mockHistoryStore.On("GetLatestUsernameChange", ctx, appUser.ID).Return(repository.UsernameHistory{}, sql.ErrNoRows)
+	mockHistoryStore.On("CreateUsernameHistory", ctx, mock.Anything).Return(repository.UsernameHistory{}, nil)
+
+	s := service.AppUserService{AppUserStore: mockStore, UsernameHistoryStore: mockHistoryStore}
+
+	_, err := s.ChangeUsername(ctx, appUser.ID, "John")
+
+	assert.NoError(t, err)
+	mockStore.AssertNotCalled(t, "GetAppUserByUsername", mock.Anything, mock.Anything)
+	mockHistoryStore.AssertNotCalled(t, "IsUsernameReserved", mock.Anything, mock.Anything)
+}
+
+func TestChangeUsername_InvalidUsername(t *testing.T) {
+	mockStore := new(MockAppUserStore)
+	s := service.AppUserService{AppUserStore: mockStore}
+
+	_, err := s.ChangeUsername(context.Background(), uuid.New(), "admin")
+
+	var invalidUsernameError *username_util.InvalidUsernameError
+	assert.ErrorAs(t, err, &invalidUsernameError)
+	mockStore.AssertNotCalled(t, "GetAppUserById", mock.Anything, mock.Anything)
+}
+
+func TestChangeUsername_Cooldown(t *testing.T) {
+	ctx := context.Background()
+	appUser := repository.AppUser{ID: uuid.New(), Username: "old_name"}
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, appUser.ID).Return(appUser, nil)
+	mockHistoryStore := new(MockUsernameHistoryStore)
+	mockHistoryStore.On("GetLatestUsernameChange", ctx, appUser.ID).Return(repository.UsernameHistory{ChangedAt: time.Now().Add(-time.Hour)}, nil)
+
+	s := service.AppUserService{AppUserStore: mockStore, UsernameHistoryStore: mockHistoryStore}
+
+	_, err := s.ChangeUsername(ctx, appUser.ID, "new_name")
+
+	var cooldownError *repository.UsernameChangeCooldownError
+	assert.ErrorAs(t, err, &cooldownError)
+	assert.True(t, cooldownError.RetryAfter.After(time.Now()))
+	mockStore.AssertNotCalled(t, "UpdateAppUserUsername", mock.Anything, mock.Anything)
+}
+
+func TestChangeUsername_ReservedByAnotherUser(t *testing.T) {
+	ctx := context.Background()
+	appUser := repository.AppUser{ID: uuid.New(), Username: "old_name"}
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, appUser.ID).Return(appUser, nil)
+	mockStore.On("GetAppUserByUsername", ctx, "given_up").Return(repository.AppUser{}, sql.ErrNoRows)
+	mockHistoryStore := new(MockUsernameHistoryStore)
+	mockHistoryStore.On("GetLatestUsernameChange", ctx, appUser.ID).Return(repository.UsernameHistory{}, sql.ErrNoRows)
+	mockHistoryStore.On("IsUsernameReserved", ctx, mock.Anything).Return(true, nil)
+
+	s := service.AppUserService{AppUserStore: mockStore, UsernameHistoryStore: mockHistoryStore}
+
+	_, err := s.ChangeUsername(ctx, appUser.ID, "given_up")
+
+	var duplicateKeyError *repository.DuplicateKeyError
+	assert.ErrorAs(t, err, &duplicateKeyError)
+	mockStore.AssertNotCalled(t, "UpdateAppUserUsername", mock.Anything, mock.Anything)
+}
+
+func TestChangeUsername_Taken(t *testing.T) {
+	ctx := context.Background()
+	appUser := repository.AppUser{ID: uuid.New(), Username: "old_name"}
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, appUser.ID).Return(appUser, nil)
+	mockStore.On("GetAppUserByUsername", ctx, "taken").Return(repository.AppUser{ID: uuid.New(), Username: "Taken"}, nil)
+	mockHistoryStore := new(MockUsernameHistoryStore)
+	mockHistoryStore.On("GetLatestUsernameChange", ctx, appUser.ID).Return(repository.UsernameHistory{}, sql.ErrNoRows)
+
+	s := service.AppUserService{AppUserStore: mockStore, UsernameHistoryStore: mockHistoryStore}
+
+	_, err := s.ChangeUsername(ctx, appUser.ID, "taken")
+
+	var duplicateKeyError *repository.DuplicateKeyError
+	assert.ErrorAs(t, err, &duplicateKeyError)
+}
+
+func TestChangeUsername_RollsBackWhenHistoryFails(t *testing.T) {
+	ctx := context.Background()
+	appUser := repository.AppUser{ID: uuid.New(), Username: "old_name"}
+
+	mockStore := new(MockAppUserStore)
+	mockStore.On("GetAppUserById", ctx, appUser.ID).Return(appUser, nil)
+	mockStore.On("GetAppUserByUsername", ctx, "new_name").Return(repository.AppUser{}, sql.ErrNoRows)
+	mockHistoryStore := new(MockUsernameHistoryStore)
+	mockHistoryStore.On("GetLatestUsernameChange", ctx, appUser.ID).Return(repository.UsernameHistory{}, sql.ErrNoRows)
+	mockHistoryStore.On("IsUsernameReserved", ctx, mock.Anything).Return(false, nil)
+
+	txStore := new(MockAppUserStore)
+	txStore.On("UpdateAppUserUsername", ctx, repository.UpdateAppUserUsernameParams{ID: appUser.ID, Username: "new_name"}).Return(repository.AppUser{ID: appUser.ID, Username: "new_name"}, nil)
+	txHistoryStore := new(MockUsernameHistoryStore)
+	txHistoryStore.On("CreateUsernameHistory", ctx, mock.Anything).Return(repository.UsernameHistory{}, errors.New("history error"))
+	transactor := &mockTransactor{store: &mockTxStore{
+		mockAppUserServiceStore: mockAppUserServiceStore{MockAppUserStore: txStore, MockUsernameHistoryStore: txHistoryStore},
+	}}
+
+	s := service.AppUserService{AppUserStore: mockStore, UsernameHistoryStore: mockHistoryStore, Transactor: transactor}
+
+	_, err := s.ChangeUsername(ctx, appUser.ID, "new_name")
+
+	assert.Error(t, err)
+	assert.True(t, transactor.rolledBack, "Expected the username change to be rolled back along with the history")
+	txStore.AssertExpectations(t)
+	txHistoryStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "UpdateAppUserUsername", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,26 @@
+package service_test
+
+import (
+	"context"
+	"eau-de-go/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockVerificationTokenStore struct {
+	mock.Mock
+}
+
+func (m *MockVerificationTokenStore) CreateVerificationToken(ctx context.Context, arg repository.CreateVerificationTokenParams) (repository.VerificationToken, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.VerificationToken), args.Error(1)
+}
+
+func (m *MockVerificationTokenStore) ConsumeVerificationToken(ctx context.Context, arg repository.ConsumeVerificationTokenParams) (repository.VerificationToken, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.VerificationToken), args.Error(1)
+}
+
+func (m *MockVerificationTokenStore) InvalidateUserVerificationTokens(ctx context.Context, arg repository.InvalidateUserVerificationTokensParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	log "github.com/sirupsen/logrus"
+)
+
+// TxStore is the persistence available within a transaction, implemented by repository.Queries.
+type TxStore interface {
+	AppUserServiceStore
+	EmailOutboxStore
+	AuditEventStore
+}
+
+// Transactor runs a function within a transaction, committing the transaction if the function succeeds
+// and rolling it back otherwise.
+type Transactor interface {
+	ExecTx(ctx context.Context, fn func(store TxStore) error) error
+}
+
+// DBTransactor runs functions within a transaction of the database, with queries bound to the transaction.
+type DBTransactor struct {
+	DB      *sql.DB
+	Queries *repository.Queries
+}
+
+func NewDBTransactor(db *sql.DB, queries *repository.Queries) *DBTransactor {
+	return &DBTransactor{DB: db, Queries: queries}
+}
+
+func (transactor *DBTransactor) ExecTx(ctx context.Context, fn func(store TxStore) error) error {
+	tx, err := transactor.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	err = fn(transactor.Queries.WithTx(tx))
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Errorf("Error rolling back transaction: %v", rollbackErr)
+		}
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// withTx runs fn with a copy of the service whose stores are bound to a single transaction.
+// Emails sent through an EmailOutbox by fn are enqueued within the transaction as well,
+// so that they are only delivered if the changes they are about are committed,
+// while emails sent through any other sender are held back and sent once the transaction commits.
+// Notifications, see notifyUser, are sent once the transaction commits with either sender.
+// Audit events are not logged within the transaction, as they are logged once the action has been performed.
+// Services without a Transactor run fn with the service itself.
+func (service *AppUserService) withTx(ctx context.Context, fn func(txService *AppUserService) error) error {
+	return service.withTxStore(ctx, func(txService *AppUserService, _ TxStore) error {
+		return fn(txService)
+	})
+}
+
+// withTxStore is withTx, also passing fn the store bound to the transaction, which is nil for services without a Transactor.
+func (service *AppUserService) withTxStore(ctx context.Context, fn func(txService *AppUserService, store TxStore) error) error {
+	if service.Transactor == nil {
+		return fn(service, nil)
+	}
+	var emails, notifications deferredEmailSender
+	err := service.Transactor.ExecTx(ctx, func(store TxStore) error {
+		txService := *service
+		txService.Transactor = nil
+		txService.AppUserStore = store
+		txService.RefreshTokenStore = store
+		txService.UserSessionStore = store
+		txService.VerificationTokenStore = store
+		txService.UsernameHistoryStore = store
+		txService.RoleStore = store
+		txService.LoginThrottleStore = store
+		txService.notifications = &notifications
+		if outbox, ok := service.EmailSender.(*EmailOutbox); ok {
+			txOutbox := *outbox
+			txOutbox.Store = store
+			txService.EmailSender = &txOutbox
+		} else {
+			txService.EmailSender = &emails
+		}
+		return fn(&txService, store)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := notifications.flush(service.EmailSender); err != nil {
+		log.Errorf("Error sending notification email: %v", err)
+	}
+	err = emails.flush(service.EmailSender)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type UserSessionStore interface {
+	CreateUserSession(ctx context.Context, arg repository.CreateUserSessionParams) (repository.UserSession, error)
+	TouchUserSession(ctx context.Context, arg repository.TouchUserSessionParams) (repository.UserSession, error)
+	ListActiveUserSessions(ctx context.Context, userID uuid.UUID) ([]repository.UserSession, error)
+	RevokeUserSession(ctx context.Context, arg repository.RevokeUserSessionParams) (repository.UserSession, error)
+	RevokeAllUserSessions(ctx context.Context, userID uuid.UUID) error
+}
+
+// ListActiveSessions returns the sessions of the user that still hold a valid refresh token, most recently refreshed first.
+func (service *AppUserService) ListActiveSessions(ctx context.Context, userId uuid.UUID) ([]repository.UserSession, error) {
+	sessions, err := service.UserSessionStore.ListActiveUserSessions(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes a single session of the user, along with every refresh token issued for it.
+func (service *AppUserService) RevokeSession(ctx context.Context, userId uuid.UUID, sessionId uuid.UUID) error {
+	revoked, err := service.revokeSession(ctx, userId, sessionId)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if !revoked {
+		return &repository.NotFoundError{Key: "Session not found."}
+	}
+	return nil
+}
+
+// revokeSession revokes the session and its refresh token family.
+// Returns false if the session does not belong to the user or was already revoked.
+func (service *AppUserService) revokeSession(ctx context.Context, userId uuid.UUID, sessionId uuid.UUID) (bool, error) {
+	_, err := service.UserSessionStore.RevokeUserSession(ctx, repository.RevokeUserSessionParams{
+		ID:     sessionId,
+		UserID: userId,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	err = service.RefreshTokenStore.RevokeRefreshTokenFamily(ctx, sessionId)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/pkg/username_util"
+	"eau-de-go/settings"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+type UsernameHistoryStore interface {
+	CreateUsernameHistory(ctx context.Context, arg repository.CreateUsernameHistoryParams) (repository.UsernameHistory, error)
+	GetLatestUsernameChange(ctx context.Context, userID uuid.UUID) (repository.UsernameHistory, error)
+	IsUsernameReserved(ctx context.Context, arg repository.IsUsernameReservedParams) (bool, error)
+}
+
+// ChangeUsername sets the username of the user, at most once per USERNAME_CHANGE_COOLDOWN_DAYS.
+// The previous username is recorded in the username history, which reserves it for the user for USERNAME_RESERVATION_DAYS,
+// so that it cannot be claimed by someone else to impersonate the user right after the change.
+func (service *AppUserService) ChangeUsername(ctx context.Context, userId uuid.UUID, newUsername string) (repository.AppUser, error) {
+	err := username_util.ValidateUsername(newUsername)
+	if err != nil {
+		return repository.AppUser{}, err
+	}
+
+	dao, err := service.AppUserStore.GetAppUserById(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		return repository.AppUser{}, err
+	}
+	if dao.Username == newUsername {
+		return repository.AppUser{}, &repository.SameUsernameError{}
+	}
+
+	latestChange, err := service.UsernameHistoryStore.GetLatestUsernameChange(ctx, userId)
+	if err == nil {
+		if retryAfter := latestChange.ChangedAt.Add(settings.UsernameChangeCooldown); time.Now().Before(retryAfter) {
+			return repository.AppUser{}, &repository.UsernameChangeCooldownError{RetryAfter: retryAfter}
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		log.Error(err)
+		return repository.AppUser{}, err
+	}
+
+	// Usernames are unique regardless of case, changing only the case of the username keeps it available to the user.
+	if !strings.EqualFold(dao.Username, newUsername) {
+		err = service.checkUsernameAvailable(ctx, userId, newUsername)
+		if err != nil {
+			return repository.AppUser{}, err
+		}
+	}
+
+	// The username is changed along with recording the previous one, so that a username is never given up without being reserved.
+	var updated repository.AppUser
+	err = service.withTx(ctx, func(txService *AppUserService) error {
+		updated, err = txService.AppUserStore.UpdateAppUserUsername(ctx, repository.UpdateAppUserUsernameParams{
+			ID:       userId,
+			Username: newUsername,
+		})
+		if err != nil {
+			var dbErr *pq.Error
+			if errors.As(err, &dbErr) && dbErr.Code.Name() == "unique_violation" {
+				return &repository.DuplicateKeyError{Key: "Username is not available."}
+			}
+			log.Error(err)
+			return err
+		}
+
+		_, err = txService.UsernameHistoryStore.CreateUsernameHistory(ctx, repository.CreateUsernameHistoryParams{
+			UserID:   userId,
+			Username: dao.Username,
+		})
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return repository.AppUser{}, err
+	}
+	return updated, nil
+}
+
+// checkUsernameAvailable returns DuplicateKeyError if the username is used by an account,
+// or was recently given up by a user other than userId. Pass uuid.Nil when the username is not claimed by an existing user.
+func (service *AppUserService) checkUsernameAvailable(ctx context.Context, userId uuid.UUID, username string) error {
+	_, err := service.AppUserStore.GetAppUserByUsername(ctx, username)
+	if err == nil {
+		return &repository.DuplicateKeyError{Key: "Username is not available."}
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Error(err)
+		return err
+	}
+
+	reserved, err := service.UsernameHistoryStore.IsUsernameReserved(ctx, repository.IsUsernameReservedParams{
+		Username:      username,
+		UserID:        userId,
+		ReservedSince: time.Now().Add(-settings.UsernameReservationPeriod),
+	})
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if reserved {
+		return &repository.DuplicateKeyError{Key: "Username is not available."}
+	}
+	return nil
+}
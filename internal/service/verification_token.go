@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	"eau-de-go/pkg/email_util"
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+const (
+	EmailVerificationPurpose = "email_verification"
+	PasswordResetPurpose     = "password_reset"
+	EmailChangePurpose       = "email_change"
+	AccountUnlockPurpose     = "account_unlock"
+)
+
+type VerificationTokenStore interface {
+	CreateVerificationToken(ctx context.Context, arg repository.CreateVerificationTokenParams) (repository.VerificationToken, error)
+	ConsumeVerificationToken(ctx context.Context, arg repository.ConsumeVerificationTokenParams) (repository.VerificationToken, error)
+	InvalidateUserVerificationTokens(ctx context.Context, arg repository.InvalidateUserVerificationTokensParams) error
+}
+
+// createVerificationToken persists a new single-use token of the purpose for the user's email address and returns the token.
+// Outstanding tokens of the same purpose are invalidated, so that only the most recently emailed token can be used.
+func (service *AppUserService) createVerificationToken(ctx context.Context, userId uuid.UUID, emailAddress string, purpose string, tokenLife time.Duration) (string, error) {
+	err := service.VerificationTokenStore.InvalidateUserVerificationTokens(ctx, repository.InvalidateUserVerificationTokensParams{
+		UserID:  userId,
+		Purpose: purpose,
+	})
+	if err != nil {
+		log.Error(err)
+		return "", err
+	}
+
+	token, tokenHash, err := email_util.NewVerificationToken()
+	if err != nil {
+		log.Error(err)
+		return "", err
+	}
+
+	_, err = service.VerificationTokenStore.CreateVerificationToken(ctx, repository.CreateVerificationTokenParams{
+		TokenHash: tokenHash,
+		UserID:    userId,
+		Purpose:   purpose,
+		Email:     emailAddress,
+		ExpiresAt: time.Now().Add(tokenLife),
+	})
+	if err != nil {
+		log.Error(err)
+		return "", err
+	}
+	return token, nil
+}
+
+// consumeVerificationToken marks the token of the purpose as used and returns it.
+// Unknown, expired and already used tokens are rejected with InvalidVerificationTokenError.
+func (service *AppUserService) consumeVerificationToken(ctx context.Context, token string, purpose string) (repository.VerificationToken, error) {
+	if token == "" {
+		return repository.VerificationToken{}, &repository.InvalidVerificationTokenError{}
+	}
+
+	verificationToken, err := service.VerificationTokenStore.ConsumeVerificationToken(ctx, repository.ConsumeVerificationTokenParams{
+		TokenHash: email_util.HashVerificationToken(token),
+		Purpose:   purpose,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.VerificationToken{}, &repository.InvalidVerificationTokenError{}
+		}
+		log.Error(err)
+		return repository.VerificationToken{}, err
+	}
+	return verificationToken, nil
+}
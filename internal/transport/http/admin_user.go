@@ -0,0 +1,227 @@
+package http
+
+import (
+	"context"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/transport/http/request_dto"
+	"eau-de-go/internal/transport/http/response_dto"
+	"eau-de-go/pkg/jwt_util"
+	"eau-de-go/pkg/pagination"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type AdminUserService interface {
+	ListUsers(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, filter repository.AppUserFilter, pageRequest pagination.PageRequest) (pagination.Page[repository.AppUser], error)
+	GetUser(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error)
+	ActivateUser(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error)
+	DeactivateUser(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error)
+	SetUserEmailVerified(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error)
+	ResetUserPassword(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) error
+	SetUserStaffStatus(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID, isStaff bool) (repository.AppUser, error)
+	UnlockUser(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error)
+}
+
+// writeAdminUserServiceError responds with 400 for invalid page requests, 404 for unknown users,
+// 403 for staff members managing their own account, 409 for inactive users and 500 otherwise.
+func writeAdminUserServiceError(w http.ResponseWriter, err error) {
+	var invalidCursorError *pagination.InvalidCursorError
+	var invalidPageRequestError *pagination.InvalidPageRequestError
+	var notFoundError *repository.NotFoundError
+	var selfAdministrationError *repository.SelfAdministrationError
+	var inactiveUserError *repository.InactiveUserError
+	switch {
+	case errors.As(err, &invalidCursorError), errors.As(err, &invalidPageRequestError):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &notFoundError):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &selfAdministrationError):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.As(err, &inactiveUserError):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Unable to process user request", http.StatusInternalServerError)
+	}
+}
+
+func writeAdminAppUser(w http.ResponseWriter, userDao repository.AppUser) {
+	jsonData, err := json.Marshal(response_dto.ConvertAdminDbRow(userDao))
+	if err != nil {
+		log.Errorf("Error marshalling json: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(jsonData)
+	if err != nil {
+		log.Errorf("Error writing response: %v", err)
+		return
+	}
+}
+
+// adminUserRequest returns the staff member making the request and the id of the managed user.
+func adminUserRequest(w http.ResponseWriter, r *http.Request) (*jwt_util.Principal, uuid.UUID, bool) {
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+		return nil, uuid.Nil, false
+	}
+	userId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, uuid.Nil, false
+	}
+	return principal, userId, true
+}
+
+func (h *Handler) AdminListUsers(w http.ResponseWriter, r *http.Request) {
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := request_dto.MakeAppUserFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pageRequest, err := pagination.ParsePageRequest(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.AdminUserService.ListUsers(r.Context(), principal.ID, request_dto.MakeClientInfoFromRequest(r), filter, pageRequest)
+	if err != nil {
+		writeAdminUserServiceError(w, err)
+		return
+	}
+
+	jsonData, err := json.Marshal(pagination.MapPage(page, response_dto.ConvertAdminDbRow))
+	if err != nil {
+		log.Errorf("Error marshalling json: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(jsonData)
+	if err != nil {
+		log.Errorf("Error writing response: %v", err)
+		return
+	}
+}
+
+func (h *Handler) AdminGetUser(w http.ResponseWriter, r *http.Request) {
+	principal, userId, ok := adminUserRequest(w, r)
+	if !ok {
+		return
+	}
+
+	userDao, err := h.AdminUserService.GetUser(r.Context(), principal.ID, request_dto.MakeClientInfoFromRequest(r), userId)
+	if err != nil {
+		writeAdminUserServiceError(w, err)
+		return
+	}
+	writeAdminAppUser(w, userDao)
+}
+
+func (h *Handler) AdminActivateUser(w http.ResponseWriter, r *http.Request) {
+	principal, userId, ok := adminUserRequest(w, r)
+	if !ok {
+		return
+	}
+
+	userDao, err := h.AdminUserService.ActivateUser(r.Context(), principal.ID, request_dto.MakeClientInfoFromRequest(r), userId)
+	if err != nil {
+		writeAdminUserServiceError(w, err)
+		return
+	}
+	writeAdminAppUser(w, userDao)
+}
+
+func (h *Handler) AdminDeactivateUser(w http.ResponseWriter, r *http.Request) {
+	principal, userId, ok := adminUserRequest(w, r)
+	if !ok {
+		return
+	}
+
+	userDao, err := h.AdminUserService.DeactivateUser(r.Context(), principal.ID, request_dto.MakeClientInfoFromRequest(r), userId)
+	if err != nil {
+		writeAdminUserServiceError(w, err)
+		return
+	}
+	writeAdminAppUser(w, userDao)
+}
+
+func (h *Handler) AdminVerifyUserEmail(w http.ResponseWriter, r *http.Request) {
+	principal, userId, ok := adminUserRequest(w, r)
+	if !ok {
+		return
+	}
+
+	userDao, err := h.AdminUserService.SetUserEmailVerified(r.Context(), principal.ID, request_dto.MakeClientInfoFromRequest(r), userId)
+	if err != nil {
+		writeAdminUserServiceError(w, err)
+		return
+	}
+	writeAdminAppUser(w, userDao)
+}
+
+// AdminUnlockUser unlocks an account locked after too many failed login attempts.
+func (h *Handler) AdminUnlockUser(w http.ResponseWriter, r *http.Request) {
+	principal, userId, ok := adminUserRequest(w, r)
+	if !ok {
+		return
+	}
+
+	userDao, err := h.AdminUserService.UnlockUser(r.Context(), principal.ID, request_dto.MakeClientInfoFromRequest(r), userId)
+	if err != nil {
+		writeAdminUserServiceError(w, err)
+		return
+	}
+	writeAdminAppUser(w, userDao)
+}
+
+func (h *Handler) AdminResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	principal, userId, ok := adminUserRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.AdminUserService.ResetUserPassword(r.Context(), principal.ID, request_dto.MakeClientInfoFromRequest(r), userId)
+	if err != nil {
+		writeAdminUserServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) AdminSetUserStaffStatus(w http.ResponseWriter, r *http.Request) {
+	principal, userId, ok := adminUserRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var staffStatusDto request_dto.StaffStatusRequestDto
+	err := json.NewDecoder(r.Body).Decode(&staffStatusDto)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if staffStatusDto.IsStaff == nil {
+		http.Error(w, "is_staff is required", http.StatusBadRequest)
+		return
+	}
+
+	userDao, err := h.AdminUserService.SetUserStaffStatus(r.Context(), principal.ID, request_dto.MakeClientInfoFromRequest(r), userId, *staffStatusDto.IsStaff)
+	if err != nil {
+		writeAdminUserServiceError(w, err)
+		return
+	}
+	writeAdminAppUser(w, userDao)
+}
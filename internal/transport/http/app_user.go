@@ -5,31 +5,82 @@ import (
 	"eau-de-go/internal/repository"
 	"eau-de-go/internal/transport/http/request_dto"
 	"eau-de-go/internal/transport/http/response_dto"
+	"eau-de-go/pkg/email_util"
+	"eau-de-go/pkg/jwt_util"
+	"eau-de-go/pkg/password_util"
+	"eau-de-go/pkg/username_util"
 	"eau-de-go/settings"
 	"encoding/json"
 	"errors"
 	"github.com/google/uuid"
-	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 )
 
 type AppUserService interface {
-	Login(ctx context.Context, username string, password string) (repository.AppUser, error)
-	GetAppUserById(ctx context.Context, ID uuid.UUID) (repository.AppUser, error)
+	Login(ctx context.Context, username string, password string, clientInfo repository.ClientInfo) (repository.AppUser, error)
 	CreateAppUser(ctx context.Context, appUserParams repository.CreateAppUserParams) (repository.AppUser, error)
 	UpdateAppUser(ctx context.Context, appUserParams repository.UpdateAppUserParams) (repository.AppUser, error)
-	UpdateAppUserPassword(ctx context.Context, userId uuid.UUID, oldPassword string, newPassword string) (repository.AppUser, error)
-	GetAppUserTokens(appUser repository.AppUser) (string, map[string]interface{}, string, map[string]interface{}, error)
-	RefreshToken(ctx context.Context, refreshToken string) (string, map[string]interface{}, repository.AppUser, error)
-	SendUserEmailVerification(ctx context.Context, emailAddress string) error
-	VerifyEmailVerificationToken(ctx context.Context, userId uuid.UUID, emailAddress string, token string) (bool, error)
+	UpdateAppUserPassword(ctx context.Context, userId uuid.UUID, oldPassword string, newPassword string, clientInfo repository.ClientInfo) (repository.AppUser, error)
+	GetAppUserTokens(ctx context.Context, appUser repository.AppUser, clientInfo repository.ClientInfo) (string, map[string]interface{}, string, map[string]interface{}, error)
+	RefreshToken(ctx context.Context, refreshToken string, clientInfo repository.ClientInfo) (string, map[string]interface{}, string, map[string]interface{}, repository.AppUser, error)
+	Logout(ctx context.Context, refreshToken string) error
+	RevokeAllRefreshTokens(ctx context.Context, userId uuid.UUID) error
+	ListActiveSessions(ctx context.Context, userId uuid.UUID) ([]repository.UserSession, error)
+	RevokeSession(ctx context.Context, userId uuid.UUID, sessionId uuid.UUID) error
+	SendUserEmailVerification(ctx context.Context, userId uuid.UUID, emailAddress string) error
+	VerifyEmailVerificationToken(ctx context.Context, userId uuid.UUID, emailAddress string, token string, clientInfo repository.ClientInfo) (bool, error)
+	RequestPasswordReset(ctx context.Context, emailAddress string) error
+	RequestEmailChange(ctx context.Context, userId uuid.UUID, password string, newEmailAddress string) error
+	ConfirmEmailChange(ctx context.Context, userId uuid.UUID, token string) (repository.AppUser, error)
+	ChangeUsername(ctx context.Context, userId uuid.UUID, newUsername string) (repository.AppUser, error)
+	ConfirmPasswordReset(ctx context.Context, token string, newPassword string, clientInfo repository.ClientInfo) error
+	UnlockAccount(ctx context.Context, token string, clientInfo repository.ClientInfo) error
 }
 
 var refreshTokenCookieName = "refresh"
-var refreshTokenCookiePath = "/auth/token-refresh"
+
+// The refresh cookie is scoped to /auth so that browsers send it to both the token refresh and the logout endpoints.
+var refreshTokenCookiePath = "/auth"
+
+// Refresh cookies were previously scoped to the token refresh endpoint only,
+// they are expired whenever the refresh cookie is set or cleared so that a stale cookie does not shadow the current one.
+var legacyRefreshTokenCookiePath = "/auth/token-refresh"
+
+func setRefreshTokenCookie(w http.ResponseWriter, refreshToken string, refreshTokenClaims map[string]interface{}) {
+	expireRefreshTokenCookie(w, legacyRefreshTokenCookiePath)
+	cookie := http.Cookie{
+		Name:     refreshTokenCookieName,
+		Value:    refreshToken,
+		HttpOnly: true,
+		Path:     refreshTokenCookiePath,
+		SameSite: http.SameSiteStrictMode,
+		Secure:   settings.RefreshCookieSecure,
+		Expires:  time.Unix(refreshTokenClaims["exp"].(int64), 0),
+	}
+	http.SetCookie(w, &cookie)
+}
+
+func clearRefreshTokenCookie(w http.ResponseWriter) {
+	expireRefreshTokenCookie(w, legacyRefreshTokenCookiePath)
+	expireRefreshTokenCookie(w, refreshTokenCookiePath)
+}
+
+func expireRefreshTokenCookie(w http.ResponseWriter, path string) {
+	cookie := http.Cookie{
+		Name:     refreshTokenCookieName,
+		Value:    "",
+		HttpOnly: true,
+		Path:     path,
+		SameSite: http.SameSiteStrictMode,
+		Secure:   settings.RefreshCookieSecure,
+		MaxAge:   -1,
+	}
+	http.SetCookie(w, &cookie)
+}
 
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 
@@ -45,24 +96,31 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userDao, err := h.AppUserService.Login(r.Context(), loginDto.Username, loginDto.Password)
+	userDao, err := h.AppUserService.Login(r.Context(), loginDto.Username, loginDto.Password, request_dto.MakeClientInfoFromRequest(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		var lockedAccountError *repository.LockedAccountError
+		var loginThrottledError *repository.LoginThrottledError
+		switch {
+		case errors.As(err, &lockedAccountError):
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(lockedAccountError.LockedUntil).Seconds())+1))
+			http.Error(w, err.Error(), http.StatusLocked)
+		case errors.As(err, &loginThrottledError):
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(loginThrottledError.RetryAfter).Seconds())+1))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		default:
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		}
 		return
 	}
 
-	refreshToken, refreshTokenClaims, accessToken, _, err := h.AppUserService.GetAppUserTokens(userDao)
-
-	cookie := http.Cookie{
-		Name:     refreshTokenCookieName,
-		Value:    refreshToken,
-		HttpOnly: true,
-		Path:     refreshTokenCookiePath,
-		SameSite: http.SameSiteStrictMode,
-		Secure:   settings.RefreshCookieSecure,
-		Expires:  time.Unix(refreshTokenClaims["exp"].(int64), 0),
+	refreshToken, refreshTokenClaims, accessToken, _, err := h.AppUserService.GetAppUserTokens(r.Context(), userDao, request_dto.MakeClientInfoFromRequest(r))
+	if err != nil {
+		log.Errorf("Error creating tokens: %v", err)
+		http.Error(w, "Unable to create tokens", http.StatusInternalServerError)
+		return
 	}
-	http.SetCookie(w, &cookie)
+
+	setRefreshTokenCookie(w, refreshToken, refreshTokenClaims)
 
 	userDto := response_dto.ConvertDbRow(userDao)
 	responseData := response_dto.AppUserLoginResponse{
@@ -92,12 +150,14 @@ func (h *Handler) TokenRefresh(w http.ResponseWriter, r *http.Request) {
 	}
 
 	refreshToken := refreshTokenCookie.Value
-	accessToken, _, appUser, err := h.AppUserService.RefreshToken(r.Context(), refreshToken)
+	newRefreshToken, newRefreshTokenClaims, accessToken, _, appUser, err := h.AppUserService.RefreshToken(r.Context(), refreshToken, request_dto.MakeClientInfoFromRequest(r))
 	if err != nil {
 		http.Error(w, "Unable to refresh token", http.StatusUnauthorized)
 		return
 	}
 
+	setRefreshTokenCookie(w, newRefreshToken, newRefreshTokenClaims)
+
 	userDto := response_dto.ConvertDbRow(appUser)
 
 	responseData := response_dto.AppUserLoginResponse{
@@ -119,35 +179,36 @@ func (h *Handler) TokenRefresh(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *Handler) GetAppUserById(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := uuid.Parse(vars["id"])
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	userDao, err := h.AppUserService.GetAppUserById(r.Context(), id)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	refreshTokenCookie, err := r.Cookie(refreshTokenCookieName)
+	if err == nil {
+		err = h.AppUserService.Logout(r.Context(), refreshTokenCookie.Value)
+		if err != nil {
+			log.Errorf("Error revoking refresh token: %v", err)
+			http.Error(w, "Unable to log out", http.StatusInternalServerError)
+			return
+		}
 	}
 
-	userDto := response_dto.ConvertDbRow(userDao)
+	clearRefreshTokenCookie(w)
+	w.WriteHeader(http.StatusNoContent)
+}
 
-	jsonData, err := json.Marshal(userDto)
-	if err != nil {
-		log.Errorf("Error marshalling json: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+func (h *Handler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
 		return
 	}
 
-	_, err = w.Write(jsonData)
+	err := h.AppUserService.RevokeAllRefreshTokens(r.Context(), principal.ID)
 	if err != nil {
-		log.Errorf("Error writing response: %v", err)
+		http.Error(w, "Unable to revoke sessions", http.StatusInternalServerError)
 		return
 	}
+
+	clearRefreshTokenCookie(w)
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *Handler) CreateAppUser(w http.ResponseWriter, r *http.Request) {
@@ -221,31 +282,20 @@ func (h *Handler) UpdateAppUser(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) UpdateAppUserPassword(w http.ResponseWriter, r *http.Request) {
 
-	jwtClaims, ok := r.Context().Value("jwt_claims").(map[string]interface{})
-	if !ok {
-		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
-		return
-	}
-
-	idStr, ok := jwtClaims["id"].(string)
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
 	if !ok {
 		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
-	}
-
-	userId, err := uuid.Parse(idStr)
-	if err != nil {
-		http.Error(w, "Invalid access token", http.StatusUnauthorized)
 		return
 	}
 
 	var updatePasswordDto request_dto.UpdateAppUserPasswordRequestDto
-	err = json.NewDecoder(r.Body).Decode(&updatePasswordDto)
+	err := json.NewDecoder(r.Body).Decode(&updatePasswordDto)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	userDao, err := h.AppUserService.UpdateAppUserPassword(r.Context(), userId, updatePasswordDto.OldPassword, updatePasswordDto.NewPassword)
+	userDao, err := h.AppUserService.UpdateAppUserPassword(r.Context(), principal.ID, updatePasswordDto.OldPassword, updatePasswordDto.NewPassword, request_dto.MakeClientInfoFromRequest(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -267,58 +317,155 @@ func (h *Handler) UpdateAppUserPassword(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-func (h *Handler) SendUserEmailVerification(w http.ResponseWriter, r *http.Request) {
-	jwtClaims, ok := r.Context().Value("jwt_claims").(map[string]interface{})
-	if !ok {
-		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+// RequestPasswordReset always responds with 202 Accepted, whether or not an account with the email address exists.
+func (h *Handler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var passwordResetDto request_dto.PasswordResetRequestDto
+	err := json.NewDecoder(r.Body).Decode(&passwordResetDto)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	emailVerified, ok := jwtClaims["email_verified"].(bool)
-	if !ok {
-		log.Errorf("Error parsing access token: %v", jwtClaims)
-		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+	err = h.AppUserService.RequestPasswordReset(r.Context(), passwordResetDto.Email)
+	if err != nil {
+		log.Errorf("Error requesting password reset: %v", err)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var passwordResetDto request_dto.PasswordResetConfirmRequestDto
+	err := json.NewDecoder(r.Body).Decode(&passwordResetDto)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if emailVerified {
-		http.Error(w, "Email already verified", http.StatusBadRequest)
+
+	err = h.AppUserService.ConfirmPasswordReset(r.Context(), passwordResetDto.Token, passwordResetDto.NewPassword, request_dto.MakeClientInfoFromRequest(r))
+	if err != nil {
+		var invalidTokenError *repository.InvalidVerificationTokenError
+		var weakPasswordError *password_util.WeakPasswordError
+		if errors.As(err, &invalidTokenError) || errors.As(err, &weakPasswordError) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Errorf("Error resetting password: %v", err)
+		http.Error(w, "Unable to reset password", http.StatusInternalServerError)
 		return
 	}
 
-	emailAddress, ok := jwtClaims["email"].(string)
+	clearRefreshTokenCookie(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnlockAccount unlocks an account locked after too many failed login attempts, with the token emailed to the user.
+func (h *Handler) UnlockAccount(w http.ResponseWriter, r *http.Request) {
+	var accountUnlockDto request_dto.AccountUnlockRequestDto
+	err := json.NewDecoder(r.Body).Decode(&accountUnlockDto)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.AppUserService.UnlockAccount(r.Context(), accountUnlockDto.Token, request_dto.MakeClientInfoFromRequest(r))
+	if err != nil {
+		var invalidTokenError *repository.InvalidVerificationTokenError
+		if errors.As(err, &invalidTokenError) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Errorf("Error unlocking account: %v", err)
+		http.Error(w, "Unable to unlock account", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) SendUserEmailVerification(w http.ResponseWriter, r *http.Request) {
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
 	if !ok {
-		log.Errorf("Error parsing access token: %v", jwtClaims)
 		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+		return
+	}
+	if principal.EmailVerified {
+		http.Error(w, "Email already verified", http.StatusBadRequest)
+		return
 	}
 
-	err := h.AppUserService.SendUserEmailVerification(r.Context(), emailAddress)
+	err := h.AppUserService.SendUserEmailVerification(r.Context(), principal.ID, principal.Email)
 	if err != nil {
 		log.Errorf("Error sending email: %v", err)
+		http.Error(w, "Error sending email verification", http.StatusInternalServerError)
 		return
 	}
+	w.WriteHeader(http.StatusAccepted)
 }
 
 func (h *Handler) VerifyEmailToken(w http.ResponseWriter, r *http.Request) {
-	jwtClaims, ok := r.Context().Value("jwt_claims").(map[string]interface{})
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
 	if !ok {
 		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
 		return
 	}
 
-	userIdStr, ok := jwtClaims["id"].(string)
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "Invalid token", http.StatusBadRequest)
+		return
+	}
+
+	verified, err := h.AppUserService.VerifyEmailVerificationToken(r.Context(), principal.ID, principal.Email, token, request_dto.MakeClientInfoFromRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !verified {
+		http.Error(w, "Invalid token", http.StatusBadRequest)
+		return
+	}
+}
+
+// RequestEmailChange emails a confirmation link to the new email address, the current address is kept until it is confirmed.
+func (h *Handler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
 	if !ok {
 		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+		return
 	}
 
-	userId, err := uuid.Parse(userIdStr)
+	var emailChangeDto request_dto.EmailChangeRequestDto
+	err := json.NewDecoder(r.Body).Decode(&emailChangeDto)
 	if err != nil {
-		http.Error(w, "Invalid access token", http.StatusUnauthorized)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	emailAddress, ok := jwtClaims["email"].(string)
+	err = h.AppUserService.RequestEmailChange(r.Context(), principal.ID, emailChangeDto.Password, emailChangeDto.Email)
+	if err != nil {
+		var duplicateKeyError *repository.DuplicateKeyError
+		var invalidEmailError *email_util.InvalidEmailError
+		var incorrectCredentialError *repository.IncorrectUserCredentialError
+		var sameEmailError *repository.SameEmailError
+		switch {
+		case errors.As(err, &duplicateKeyError):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.As(err, &invalidEmailError), errors.As(err, &incorrectCredentialError), errors.As(err, &sameEmailError):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Errorf("Error requesting email change: %v", err)
+			http.Error(w, "Error requesting email change", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
 	if !ok {
 		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+		return
 	}
 
 	token := r.URL.Query().Get("token")
@@ -327,14 +474,85 @@ func (h *Handler) VerifyEmailToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	verified, err := h.AppUserService.VerifyEmailVerificationToken(r.Context(), userId, emailAddress, token)
+	userDao, err := h.AppUserService.ConfirmEmailChange(r.Context(), principal.ID, token)
+	if err != nil {
+		var invalidTokenError *repository.InvalidVerificationTokenError
+		var duplicateKeyError *repository.DuplicateKeyError
+		switch {
+		case errors.As(err, &invalidTokenError):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.As(err, &duplicateKeyError):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			log.Errorf("Error confirming email change: %v", err)
+			http.Error(w, "Error confirming email change", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	userDto := response_dto.ConvertDbRow(userDao)
+
+	jsonData, err := json.Marshal(userDto)
+	if err != nil {
+		log.Errorf("Error marshalling json: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(jsonData)
+	if err != nil {
+		log.Errorf("Error writing response: %v", err)
+		return
+	}
+}
+
+func (h *Handler) ChangeUsername(w http.ResponseWriter, r *http.Request) {
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+		return
+	}
+
+	var usernameChangeDto request_dto.UsernameChangeRequestDto
+	err := json.NewDecoder(r.Body).Decode(&usernameChangeDto)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if !verified {
-		http.Error(w, "Invalid token", http.StatusBadRequest)
+	userDao, err := h.AppUserService.ChangeUsername(r.Context(), principal.ID, usernameChangeDto.Username)
+	if err != nil {
+		var invalidUsernameError *username_util.InvalidUsernameError
+		var sameUsernameError *repository.SameUsernameError
+		var duplicateKeyError *repository.DuplicateKeyError
+		var cooldownError *repository.UsernameChangeCooldownError
+		switch {
+		case errors.As(err, &invalidUsernameError), errors.As(err, &sameUsernameError):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.As(err, &duplicateKeyError):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.As(err, &cooldownError):
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(cooldownError.RetryAfter).Seconds())+1))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		default:
+			log.Errorf("Error changing username: %v", err)
+			http.Error(w, "Error changing username", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	userDto := response_dto.ConvertDbRow(userDao)
+
+	jsonData, err := json.Marshal(userDto)
+	if err != nil {
+		log.Errorf("Error marshalling json: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(jsonData)
+	if err != nil {
+		log.Errorf("Error writing response: %v", err)
 		return
 	}
 }
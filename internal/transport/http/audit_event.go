@@ -0,0 +1,63 @@
+package http
+
+import (
+	"context"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/transport/http/request_dto"
+	"eau-de-go/internal/transport/http/response_dto"
+	"eau-de-go/pkg/jwt_util"
+	"eau-de-go/pkg/pagination"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type AuditService interface {
+	ListAuditEvents(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, filter repository.AuditEventFilter, pageRequest pagination.PageRequest) (pagination.Page[repository.AuditEvent], error)
+}
+
+// AdminListAuditEvents lists the audit trail, most recent events first.
+func (h *Handler) AdminListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := request_dto.MakeAuditEventFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pageRequest, err := pagination.ParsePageRequest(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.AuditService.ListAuditEvents(r.Context(), principal.ID, request_dto.MakeClientInfoFromRequest(r), filter, pageRequest)
+	if err != nil {
+		var invalidCursorError *pagination.InvalidCursorError
+		if errors.As(err, &invalidCursorError) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Unable to list audit events", http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(pagination.MapPage(page, response_dto.ConvertAuditEventDbRow))
+	if err != nil {
+		log.Errorf("Error marshalling json: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(jsonData)
+	if err != nil {
+		log.Errorf("Error writing response: %v", err)
+		return
+	}
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"context"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/transport/http/response_dto"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type EmailOutboxService interface {
+	GetDeliveryStatus(ctx context.Context, id uuid.UUID) (repository.EmailOutbox, error)
+}
+
+// AdminGetEmailDeliveryStatus returns the delivery status of an email enqueued in the outbox.
+func (h *Handler) AdminGetEmailDeliveryStatus(w http.ResponseWriter, r *http.Request) {
+	emailId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	email, err := h.EmailOutboxService.GetDeliveryStatus(r.Context(), emailId)
+	if err != nil {
+		var notFoundError *repository.NotFoundError
+		if errors.As(err, &notFoundError) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Unable to get the email delivery status", http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(response_dto.ConvertEmailOutboxDbRow(email))
+	if err != nil {
+		log.Errorf("Error marshalling json: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(jsonData)
+	if err != nil {
+		log.Errorf("Error writing response: %v", err)
+		return
+	}
+}
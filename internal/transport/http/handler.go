@@ -14,15 +14,25 @@ import (
 )
 
 type Handler struct {
-	Router          *mux.Router
-	ProtectedRouter *mux.Router
-	AppUserService  AppUserService
-	Server          *http.Server
+	Router               *mux.Router
+	ProtectedRouter      *mux.Router
+	AppUserService       AppUserService
+	RoleService          RoleService
+	AdminUserService     AdminUserService
+	AuditService         AuditService
+	EmailOutboxService   EmailOutboxService
+	VerificationKeyStore VerificationKeyStore
+	Server               *http.Server
 }
 
-func NewHandler(appUserService AppUserService) *Handler {
+func NewHandler(appUserService AppUserService, roleService RoleService, adminUserService AdminUserService, auditService AuditService, emailOutboxService EmailOutboxService, verificationKeyStore VerificationKeyStore) *Handler {
 	h := &Handler{
-		AppUserService: appUserService,
+		AppUserService:       appUserService,
+		RoleService:          roleService,
+		AdminUserService:     adminUserService,
+		AuditService:         auditService,
+		EmailOutboxService:   emailOutboxService,
+		VerificationKeyStore: verificationKeyStore,
 	}
 	h.Router = mux.NewRouter()
 	h.ProtectedRouter = h.Router.PathPrefix("/api").Subrouter()
@@ -40,16 +50,56 @@ func NewHandler(appUserService AppUserService) *Handler {
 
 func (h *Handler) mapRoutes() {
 
+	h.Router.HandleFunc("/.well-known/jwks.json", h.GetJwks).Methods("GET")
+
 	h.Router.HandleFunc("/auth/login/", h.Login).Methods("POST")
 	h.Router.HandleFunc("/auth/token-refresh/", h.TokenRefresh).Methods("POST")
 	h.Router.HandleFunc("/auth/sign-up/", h.CreateAppUser).Methods("POST")
+	h.Router.HandleFunc("/auth/logout/", h.Logout).Methods("POST")
+	h.Router.HandleFunc("/auth/password-reset/request/", h.RequestPasswordReset).Methods("POST")
+	h.Router.HandleFunc("/auth/password-reset/confirm/", h.ConfirmPasswordReset).Methods("POST")
+	h.Router.HandleFunc("/auth/unlock-account/", h.UnlockAccount).Methods("POST")
 
-	h.ProtectedRouter.HandleFunc("/user/{id}/", h.GetAppUserById).Methods("GET") // TODO: remove
 	h.ProtectedRouter.HandleFunc("/user/me/password/", h.UpdateAppUserPassword).Methods("POST")
 	h.ProtectedRouter.HandleFunc("/user/me/", h.UpdateAppUser).Methods("PATCH")
+	h.ProtectedRouter.HandleFunc("/user/me/username/", h.ChangeUsername).Methods("PATCH")
+	h.ProtectedRouter.HandleFunc("/user/me/email/", h.RequestEmailChange).Methods("POST")
+	h.ProtectedRouter.HandleFunc("/user/me/email/confirm/", h.ConfirmEmailChange).Methods("POST")
+	h.ProtectedRouter.HandleFunc("/user/me/sessions/", h.ListSessions).Methods("GET")
+	h.ProtectedRouter.HandleFunc("/user/me/sessions/revoke-all/", h.RevokeAllSessions).Methods("POST")
+	h.ProtectedRouter.HandleFunc("/user/me/sessions/{id}/", h.RevokeSession).Methods("DELETE")
 
 	h.ProtectedRouter.HandleFunc("/user/send-email-verification/", h.SendUserEmailVerification).Methods("POST")
 	h.ProtectedRouter.HandleFunc("/user/verify-email-token/", h.VerifyEmailToken).Methods("POST")
+
+	h.ProtectedRouter.Handle("/admin/roles/", requirePermission("roles.manage", h.ListRoles)).Methods("GET")
+	h.ProtectedRouter.Handle("/admin/roles/", requirePermission("roles.manage", h.CreateRole)).Methods("POST")
+	h.ProtectedRouter.Handle("/admin/roles/{name}/", requirePermission("roles.manage", h.DeleteRole)).Methods("DELETE")
+	h.ProtectedRouter.Handle("/admin/roles/{name}/permissions/", requirePermission("roles.manage", h.GetRolePermissions)).Methods("GET")
+	h.ProtectedRouter.Handle("/admin/permissions/", requirePermission("roles.manage", h.ListPermissions)).Methods("GET")
+	h.ProtectedRouter.Handle("/admin/users/", requireStaffPermission("users.read", h.AdminListUsers)).Methods("GET")
+	h.ProtectedRouter.Handle("/admin/users/{id}/", requireStaffPermission("users.read", h.AdminGetUser)).Methods("GET")
+	h.ProtectedRouter.Handle("/admin/users/{id}/activate/", requireStaffPermission("users.write", h.AdminActivateUser)).Methods("POST")
+	h.ProtectedRouter.Handle("/admin/users/{id}/deactivate/", requireStaffPermission("users.write", h.AdminDeactivateUser)).Methods("POST")
+	h.ProtectedRouter.Handle("/admin/users/{id}/verify-email/", requireStaffPermission("users.write", h.AdminVerifyUserEmail)).Methods("POST")
+	h.ProtectedRouter.Handle("/admin/users/{id}/password-reset/", requireStaffPermission("users.write", h.AdminResetUserPassword)).Methods("POST")
+	h.ProtectedRouter.Handle("/admin/users/{id}/staff/", requireStaffPermission("users.write", h.AdminSetUserStaffStatus)).Methods("PUT")
+	h.ProtectedRouter.Handle("/admin/users/{id}/unlock/", requireStaffPermission("users.write", h.AdminUnlockUser)).Methods("POST")
+	h.ProtectedRouter.Handle("/admin/users/{id}/roles/", requirePermission("roles.manage", h.ListUserRoles)).Methods("GET")
+	h.ProtectedRouter.Handle("/admin/users/{id}/roles/", requirePermission("roles.manage", h.AssignUserRole)).Methods("POST")
+	h.ProtectedRouter.Handle("/admin/users/{id}/roles/{role}/", requirePermission("roles.manage", h.RevokeUserRole)).Methods("DELETE")
+	h.ProtectedRouter.Handle("/admin/audit-events/", requireStaffPermission("audit.read", h.AdminListAuditEvents)).Methods("GET")
+	h.ProtectedRouter.Handle("/admin/emails/{id}/", requireStaffPermission("emails.read", h.AdminGetEmailDeliveryStatus)).Methods("GET")
+}
+
+// requirePermission wraps the handler function with the RequirePermission middleware.
+func requirePermission(permission string, handlerFunc http.HandlerFunc) http.Handler {
+	return middleware.RequirePermission(permission)(handlerFunc)
+}
+
+// requireStaffPermission restricts the handler function to staff members granted the permission.
+func requireStaffPermission(permission string, handlerFunc http.HandlerFunc) http.Handler {
+	return middleware.RequireStaff(requirePermission(permission, handlerFunc))
 }
 
 func (h *Handler) Serve() error {
@@ -0,0 +1,235 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"eau-de-go/internal/repository"
+	transportHttp "eau-de-go/internal/transport/http"
+	"eau-de-go/internal/transport/http/response_dto"
+	"eau-de-go/internal/transport/middleware"
+	"eau-de-go/pkg/jwt_util"
+	"eau-de-go/pkg/pagination"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type MockAdminUserService struct {
+	mock.Mock
+}
+
+func (m *MockAdminUserService) ListUsers(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, filter repository.AppUserFilter, pageRequest pagination.PageRequest) (pagination.Page[repository.AppUser], error) {
+	args := m.Called(ctx, actorId, clientInfo, filter, pageRequest)
+	return args.Get(0).(pagination.Page[repository.AppUser]), args.Error(1)
+}
+
+func (m *MockAdminUserService) GetUser(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error) {
+	args := m.Called(ctx, actorId, clientInfo, userId)
+	return args.Get(0).(repository.AppUser), args.Error(1)
+}
+
+func (m *MockAdminUserService) ActivateUser(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error) {
+	args := m.Called(ctx, actorId, clientInfo, userId)
+	return args.Get(0).(repository.AppUser), args.Error(1)
+}
+
+func (m *MockAdminUserService) DeactivateUser(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error) {
+	args := m.Called(ctx, actorId, clientInfo, userId)
+	return args.Get(0).(repository.AppUser), args.Error(1)
+}
+
+func (m *MockAdminUserService) SetUserEmailVerified(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error) {
+	args := m.Called(ctx, actorId, clientInfo, userId)
+	return args.Get(0).(repository.AppUser), args.Error(1)
+}
+
+func (m *MockAdminUserService) ResetUserPassword(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) error {
+	args := m.Called(ctx, actorId, clientInfo, userId)
+	return args.Error(0)
+}
+
+func (m *MockAdminUserService) SetUserStaffStatus(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID, isStaff bool) (repository.AppUser, error) {
+	args := m.Called(ctx, actorId, clientInfo, userId, isStaff)
+	return args.Get(0).(repository.AppUser), args.Error(1)
+}
+
+func (m *MockAdminUserService) UnlockUser(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID) (repository.AppUser, error) {
+	args := m.Called(ctx, actorId, clientInfo, userId)
+	return args.Get(0).(repository.AppUser), args.Error(1)
+}
+
+func newAdminRequest(method string, url string, body []byte, principal *jwt_util.Principal) *http.Request {
+	req, _ := http.NewRequest(method, url, bytes.NewBuffer(body))
+	return req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), principal))
+}
+
+func TestAdminListUsersSuccessful(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), IsStaff: true}
+	mockService := new(MockAdminUserService)
+	handler := transportHttp.Handler{AdminUserService: mockService}
+
+	users := []repository.AppUser{{ID: uuid.New(), Username: "john", EmailVerified: true}}
+	mockService.On("ListUsers", mock.Anything, staff.ID, mock.Anything, mock.MatchedBy(func(filter repository.AppUserFilter) bool {
+		return filter.Search == "jo" && *filter.IsStaff == false && filter.JoinedAfter.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) && filter.IsActive == nil
+	}), pagination.PageRequest{Cursor: "abc", Limit: 10, Sort: "username"}).Return(pagination.Page[repository.AppUser]{Items: users, NextCursor: "def"}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.AdminListUsers(rr, newAdminRequest("GET", "/admin/users/?search=jo&is_staff=false&joined_after=2026-01-01T00:00:00Z&cursor=abc&limit=10&sort=username", nil, staff))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response pagination.Page[response_dto.AdminAppUserDto]
+	_ = json.Unmarshal(rr.Body.Bytes(), &response)
+
+	assert.Len(t, response.Items, 1)
+	assert.Equal(t, "john", response.Items[0].Username)
+	assert.True(t, response.Items[0].EmailVerified)
+	assert.Equal(t, "def", response.NextCursor)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdminListUsersInvalidFilter(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), IsStaff: true}
+	mockService := new(MockAdminUserService)
+	handler := transportHttp.Handler{AdminUserService: mockService}
+
+	rr := httptest.NewRecorder()
+	handler.AdminListUsers(rr, newAdminRequest("GET", "/admin/users/?joined_before=yesterday", nil, staff))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertNotCalled(t, "ListUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminListUsersInvalidCursor(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), IsStaff: true}
+	mockService := new(MockAdminUserService)
+	handler := transportHttp.Handler{AdminUserService: mockService}
+
+	mockService.On("ListUsers", mock.Anything, staff.ID, mock.Anything, mock.Anything, mock.Anything).Return(pagination.Page[repository.AppUser]{}, &pagination.InvalidCursorError{})
+
+	rr := httptest.NewRecorder()
+	handler.AdminListUsers(rr, newAdminRequest("GET", "/admin/users/?cursor=tampered", nil, staff))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestRequireStaffRejectsNonStaff(t *testing.T) {
+	mockService := new(MockAdminUserService)
+	handler := transportHttp.Handler{AdminUserService: mockService}
+
+	req := newAdminRequest("GET", "/admin/users/", nil, &jwt_util.Principal{ID: uuid.New(), Permissions: []string{"users.read"}})
+
+	rr := httptest.NewRecorder()
+	middleware.RequireStaff(http.HandlerFunc(handler.AdminListUsers)).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	mockService.AssertNotCalled(t, "ListUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminGetUserNotFound(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), IsStaff: true}
+	userId := uuid.New()
+	mockService := new(MockAdminUserService)
+	handler := transportHttp.Handler{AdminUserService: mockService}
+
+	mockService.On("GetUser", mock.Anything, staff.ID, mock.Anything, userId).Return(repository.AppUser{}, &repository.NotFoundError{Key: "User not found."})
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/users/{id}/", handler.AdminGetUser)
+	router.ServeHTTP(rr, newAdminRequest("GET", "/admin/users/"+userId.String()+"/", nil, staff))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAdminDeactivateOwnAccount(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), IsStaff: true}
+	mockService := new(MockAdminUserService)
+	handler := transportHttp.Handler{AdminUserService: mockService}
+
+	mockService.On("DeactivateUser", mock.Anything, staff.ID, mock.Anything, staff.ID).Return(repository.AppUser{}, &repository.SelfAdministrationError{Action: "deactivate"})
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/users/{id}/deactivate/", handler.AdminDeactivateUser)
+	router.ServeHTTP(rr, newAdminRequest("POST", "/admin/users/"+staff.ID.String()+"/deactivate/", nil, staff))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestAdminResetUserPasswordSuccessful(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), IsStaff: true}
+	userId := uuid.New()
+	mockService := new(MockAdminUserService)
+	handler := transportHttp.Handler{AdminUserService: mockService}
+
+	mockService.On("ResetUserPassword", mock.Anything, staff.ID, mock.Anything, userId).Return(nil)
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/users/{id}/password-reset/", handler.AdminResetUserPassword)
+	router.ServeHTTP(rr, newAdminRequest("POST", "/admin/users/"+userId.String()+"/password-reset/", nil, staff))
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdminUnlockUserSuccessful(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), IsStaff: true}
+	userId := uuid.New()
+	mockService := new(MockAdminUserService)
+	handler := transportHttp.Handler{AdminUserService: mockService}
+
+	mockService.On("UnlockUser", mock.Anything, staff.ID, mock.Anything, userId).Return(repository.AppUser{ID: userId}, nil)
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/users/{id}/unlock/", handler.AdminUnlockUser)
+	router.ServeHTTP(rr, newAdminRequest("POST", "/admin/users/"+userId.String()+"/unlock/", nil, staff))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdminSetUserStaffStatusSuccessful(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), IsStaff: true}
+	userId := uuid.New()
+	mockService := new(MockAdminUserService)
+	handler := transportHttp.Handler{AdminUserService: mockService}
+
+	mockService.On("SetUserStaffStatus", mock.Anything, staff.ID, mock.Anything, userId, false).Return(repository.AppUser{ID: userId}, nil)
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/users/{id}/staff/", handler.AdminSetUserStaffStatus)
+	router.ServeHTTP(rr, newAdminRequest("PUT", "/admin/users/"+userId.String()+"/staff/", []byte(`{"is_staff": false}`), staff))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response response_dto.AdminAppUserDto
+	_ = json.Unmarshal(rr.Body.Bytes(), &response)
+
+	assert.False(t, response.IsStaff)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdminSetUserStaffStatusMissingField(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), IsStaff: true}
+	userId := uuid.New()
+	mockService := new(MockAdminUserService)
+	handler := transportHttp.Handler{AdminUserService: mockService}
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/users/{id}/staff/", handler.AdminSetUserStaffStatus)
+	router.ServeHTTP(rr, newAdminRequest("PUT", "/admin/users/"+userId.String()+"/staff/", []byte(`{}`), staff))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertNotCalled(t, "SetUserStaffStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
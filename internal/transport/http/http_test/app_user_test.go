@@ -7,16 +7,18 @@ import (
 	transportHttp "eau-de-go/internal/transport/http"
 	"eau-de-go/internal/transport/http/request_dto"
 	"eau-de-go/internal/transport/http/response_dto"
+	"eau-de-go/pkg/jwt_util"
+	"eau-de-go/pkg/username_util"
 	"encoding/json"
 	"errors"
 	"github.com/google/uuid"
-	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 var refreshTokenCookieName = "refresh"
@@ -25,28 +27,23 @@ type MockAppUserService struct {
 	mock.Mock
 }
 
-func (m *MockAppUserService) SendUserEmailVerification(ctx context.Context, emailAddress string) error {
-	args := m.Called(ctx, emailAddress)
+func (m *MockAppUserService) SendUserEmailVerification(ctx context.Context, userId uuid.UUID, emailAddress string) error {
+	args := m.Called(ctx, userId, emailAddress)
 	return args.Error(0)
 }
 
-func (m *MockAppUserService) VerifyEmailVerificationToken(ctx context.Context, userId uuid.UUID, emailAddress string, token string) (bool, error) {
-	args := m.Called(ctx, userId, emailAddress, token)
+func (m *MockAppUserService) VerifyEmailVerificationToken(ctx context.Context, userId uuid.UUID, emailAddress string, token string, clientInfo repository.ClientInfo) (bool, error) {
+	args := m.Called(ctx, userId, emailAddress, token, clientInfo)
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockAppUserService) UpdateAppUserPassword(ctx context.Context, userId uuid.UUID, oldPassword string, newPassword string) (repository.AppUser, error) {
-	args := m.Called(ctx, userId, oldPassword, newPassword)
-	return args.Get(0).(repository.AppUser), args.Error(1)
-}
-
-func (m *MockAppUserService) Login(ctx context.Context, username string, password string) (repository.AppUser, error) {
-	args := m.Called(ctx, username, password)
+func (m *MockAppUserService) UpdateAppUserPassword(ctx context.Context, userId uuid.UUID, oldPassword string, newPassword string, clientInfo repository.ClientInfo) (repository.AppUser, error) {
+	args := m.Called(ctx, userId, oldPassword, newPassword, clientInfo)
 	return args.Get(0).(repository.AppUser), args.Error(1)
 }
 
-func (m *MockAppUserService) GetAppUserById(ctx context.Context, ID uuid.UUID) (repository.AppUser, error) {
-	args := m.Called(ctx, ID)
+func (m *MockAppUserService) Login(ctx context.Context, username string, password string, clientInfo repository.ClientInfo) (repository.AppUser, error) {
+	args := m.Called(ctx, username, password, clientInfo)
 	return args.Get(0).(repository.AppUser), args.Error(1)
 }
 
@@ -60,14 +57,64 @@ func (m *MockAppUserService) UpdateAppUser(ctx context.Context, appUserParams re
 	return args.Get(0).(repository.AppUser), args.Error(1)
 }
 
-func (m *MockAppUserService) GetAppUserTokens(appUser repository.AppUser) (string, map[string]interface{}, string, map[string]interface{}, error) {
-	args := m.Called(appUser)
+func (m *MockAppUserService) GetAppUserTokens(ctx context.Context, appUser repository.AppUser, clientInfo repository.ClientInfo) (string, map[string]interface{}, string, map[string]interface{}, error) {
+	args := m.Called(ctx, appUser, clientInfo)
 	return args.String(0), args.Get(1).(map[string]interface{}), args.String(2), args.Get(3).(map[string]interface{}), args.Error(4)
 }
 
-func (m *MockAppUserService) RefreshToken(ctx context.Context, refreshToken string) (string, map[string]interface{}, repository.AppUser, error) {
+func (m *MockAppUserService) RefreshToken(ctx context.Context, refreshToken string, clientInfo repository.ClientInfo) (string, map[string]interface{}, string, map[string]interface{}, repository.AppUser, error) {
+	args := m.Called(ctx, refreshToken, clientInfo)
+	return args.String(0), args.Get(1).(map[string]interface{}), args.String(2), args.Get(3).(map[string]interface{}), args.Get(4).(repository.AppUser), args.Error(5)
+}
+
+func (m *MockAppUserService) Logout(ctx context.Context, refreshToken string) error {
 	args := m.Called(ctx, refreshToken)
-	return args.String(0), args.Get(1).(map[string]interface{}), args.Get(2).(repository.AppUser), args.Error(3)
+	return args.Error(0)
+}
+
+func (m *MockAppUserService) RevokeAllRefreshTokens(ctx context.Context, userId uuid.UUID) error {
+	args := m.Called(ctx, userId)
+	return args.Error(0)
+}
+
+func (m *MockAppUserService) ListActiveSessions(ctx context.Context, userId uuid.UUID) ([]repository.UserSession, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).([]repository.UserSession), args.Error(1)
+}
+
+func (m *MockAppUserService) RevokeSession(ctx context.Context, userId uuid.UUID, sessionId uuid.UUID) error {
+	args := m.Called(ctx, userId, sessionId)
+	return args.Error(0)
+}
+
+func (m *MockAppUserService) RequestEmailChange(ctx context.Context, userId uuid.UUID, password string, newEmailAddress string) error {
+	args := m.Called(ctx, userId, password, newEmailAddress)
+	return args.Error(0)
+}
+
+func (m *MockAppUserService) ConfirmEmailChange(ctx context.Context, userId uuid.UUID, token string) (repository.AppUser, error) {
+	args := m.Called(ctx, userId, token)
+	return args.Get(0).(repository.AppUser), args.Error(1)
+}
+
+func (m *MockAppUserService) ChangeUsername(ctx context.Context, userId uuid.UUID, newUsername string) (repository.AppUser, error) {
+	args := m.Called(ctx, userId, newUsername)
+	return args.Get(0).(repository.AppUser), args.Error(1)
+}
+
+func (m *MockAppUserService) RequestPasswordReset(ctx context.Context, emailAddress string) error {
+	args := m.Called(ctx, emailAddress)
+	return args.Error(0)
+}
+
+func (m *MockAppUserService) ConfirmPasswordReset(ctx context.Context, token string, newPassword string, clientInfo repository.ClientInfo) error {
+	args := m.Called(ctx, token, newPassword, clientInfo)
+	return args.Error(0)
+}
+
+func (m *MockAppUserService) UnlockAccount(ctx context.Context, token string, clientInfo repository.ClientInfo) error {
+	args := m.Called(ctx, token, clientInfo)
+	return args.Error(0)
 }
 
 func TestLoginSuccessful(t *testing.T) {
@@ -81,8 +128,8 @@ func TestLoginSuccessful(t *testing.T) {
 	expectedUser := repository.AppUser{ID: uuid.New(), Username: "test"}
 
 	var mockExp int64 = 1707105923
-	mockService.On("GetAppUserTokens", expectedUser).Return("refreshToken", map[string]interface{}{"exp": mockExp}, "accessToken", map[string]interface{}{"exp": 123}, nil)
-	mockService.On("Login", mock.Anything, "test", "test").Return(expectedUser, nil)
+	mockService.On("GetAppUserTokens", mock.Anything, expectedUser, mock.Anything).Return("refreshToken", map[string]interface{}{"exp": mockExp}, "accessToken", map[string]interface{}{"exp": 123}, nil)
+	mockService.On("Login", mock.Anything, "test", "test", mock.Anything).Return(expectedUser, nil)
 
 	rr := httptest.NewRecorder()
 	handler.Login(rr, req)
@@ -103,7 +150,7 @@ func TestLoginInvalidCredentials(t *testing.T) {
 	loginDtoBytes, _ := json.Marshal(loginDto)
 	req, _ := http.NewRequest("POST", "/login", bytes.NewBuffer(loginDtoBytes))
 
-	mockService.On("Login", mock.Anything, "test", "wrong").Return(repository.AppUser{}, errors.New("invalid credentials"))
+	mockService.On("Login", mock.Anything, "test", "wrong", mock.Anything).Return(repository.AppUser{}, errors.New("invalid credentials"))
 
 	rr := httptest.NewRecorder()
 	handler.Login(rr, req)
@@ -111,6 +158,36 @@ func TestLoginInvalidCredentials(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
 }
 
+func TestLoginLockedAccount(t *testing.T) {
+	mockService := new(MockAppUserService)
+	handler := transportHttp.Handler{AppUserService: mockService}
+
+	req, _ := http.NewRequest("POST", "/auth/login", strings.NewReader(`{"username": "test", "password": "test"}`))
+
+	mockService.On("Login", mock.Anything, "test", "test", mock.Anything).Return(repository.AppUser{}, &repository.LockedAccountError{LockedUntil: time.Now().Add(10 * time.Minute)})
+
+	rr := httptest.NewRecorder()
+	handler.Login(rr, req)
+
+	assert.Equal(t, http.StatusLocked, rr.Code)
+	assert.Equal(t, "600", rr.Header().Get("Retry-After"))
+}
+
+func TestLoginThrottled(t *testing.T) {
+	mockService := new(MockAppUserService)
+	handler := transportHttp.Handler{AppUserService: mockService}
+
+	req, _ := http.NewRequest("POST", "/auth/login", strings.NewReader(`{"username": "test", "password": "test"}`))
+
+	mockService.On("Login", mock.Anything, "test", "test", mock.Anything).Return(repository.AppUser{}, &repository.LoginThrottledError{RetryAfter: time.Now().Add(4 * time.Second)})
+
+	rr := httptest.NewRecorder()
+	handler.Login(rr, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "4", rr.Header().Get("Retry-After"))
+}
+
 func TestTokenRefreshSuccessful(t *testing.T) {
 	mockService := new(MockAppUserService)
 	handler := transportHttp.Handler{AppUserService: mockService}
@@ -119,13 +196,24 @@ func TestTokenRefreshSuccessful(t *testing.T) {
 	req.AddCookie(&http.Cookie{Name: refreshTokenCookieName, Value: "validRefreshToken"})
 
 	expectedUser := repository.AppUser{ID: uuid.New(), Username: "test"}
-	mockService.On("RefreshToken", mock.Anything, "validRefreshToken").Return("newAccessToken", make(map[string]interface{}), expectedUser, nil)
+	var mockExp int64 = 1707105923
+	mockService.On("RefreshToken", mock.Anything, "validRefreshToken", mock.Anything).Return("newRefreshToken", map[string]interface{}{"exp": mockExp}, "newAccessToken", make(map[string]interface{}), expectedUser, nil)
 
 	rr := httptest.NewRecorder()
 	handler.TokenRefresh(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
 
+	var refreshCookie *http.Cookie
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == refreshTokenCookieName && cookie.Value != "" {
+			refreshCookie = cookie
+		}
+	}
+	assert.NotNil(t, refreshCookie)
+	assert.Equal(t, "newRefreshToken", refreshCookie.Value)
+	assert.Equal(t, "/auth", refreshCookie.Path)
+
 	var response response_dto.AppUserLoginResponse
 	_ = json.Unmarshal(rr.Body.Bytes(), &response)
 
@@ -140,7 +228,7 @@ func TestTokenRefreshInvalidToken(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/auth/token-refresh", nil)
 	req.AddCookie(&http.Cookie{Name: refreshTokenCookieName, Value: "invalidRefreshToken"})
 
-	mockService.On("RefreshToken", mock.Anything, "invalidRefreshToken").Return("", make(map[string]interface{}), repository.AppUser{}, errors.New("invalid token"))
+	mockService.On("RefreshToken", mock.Anything, "invalidRefreshToken", mock.Anything).Return("", make(map[string]interface{}), "", make(map[string]interface{}), repository.AppUser{}, errors.New("invalid token"))
 
 	rr := httptest.NewRecorder()
 	handler.TokenRefresh(rr, req)
@@ -148,78 +236,320 @@ func TestTokenRefreshInvalidToken(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
 }
 
-func TestGetAppUserByIdSuccessful(t *testing.T) {
+func TestSendUserEmailVerification_Success(t *testing.T) {
+	userId := uuid.New()
+	emailAddress := "test@example.com"
 	mockService := new(MockAppUserService)
+	mockService.On("SendUserEmailVerification", mock.Anything, userId, emailAddress).Return(nil)
+
+	req, _ := http.NewRequest("POST", "/api/user/send-email-verification/", strings.NewReader(""))
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{
+		ID:            userId,
+		Email:         emailAddress,
+		EmailVerified: false,
+	}))
+
+	recorder := httptest.NewRecorder()
 	handler := transportHttp.Handler{AppUserService: mockService}
+	handler.SendUserEmailVerification(recorder, req)
 
-	expectedUser := repository.AppUser{ID: uuid.New(), Username: "test"}
-	mockService.On("GetAppUserById", mock.Anything, expectedUser.ID).Return(expectedUser, nil)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestSendUserEmailVerification_SendError(t *testing.T) {
+	userId := uuid.New()
+	emailAddress := "test@example.com"
+	mockService := new(MockAppUserService)
+	mockService.On("SendUserEmailVerification", mock.Anything, userId, emailAddress).Return(errors.New("connection refused"))
+
+	req, _ := http.NewRequest("POST", "/api/user/send-email-verification/", strings.NewReader(""))
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{
+		ID:            userId,
+		Email:         emailAddress,
+		EmailVerified: false,
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler := transportHttp.Handler{AppUserService: mockService}
+	handler.SendUserEmailVerification(recorder, req)
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestSendUserEmailVerification_EmailAlreadyVerified(t *testing.T) {
+	emailAddress := "test@example.com"
+	mockService := new(MockAppUserService)
+
+	req, _ := http.NewRequest("POST", "/api/user/send-email-verification/", strings.NewReader(""))
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{
+		ID:            uuid.New(),
+		Email:         emailAddress,
+		EmailVerified: true,
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler := transportHttp.Handler{AppUserService: mockService}
+	handler.SendUserEmailVerification(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestLogoutSuccessful(t *testing.T) {
+	mockService := new(MockAppUserService)
+	handler := transportHttp.Handler{AppUserService: mockService}
+
+	req, _ := http.NewRequest("POST", "/auth/logout/", nil)
+	req.AddCookie(&http.Cookie{Name: refreshTokenCookieName, Value: "validRefreshToken"})
+
+	mockService.On("Logout", mock.Anything, "validRefreshToken").Return(nil)
 
-	req, _ := http.NewRequest("GET", "/users/"+expectedUser.ID.String(), nil)
 	rr := httptest.NewRecorder()
+	handler.Logout(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	for _, cookie := range rr.Result().Cookies() {
+		assert.Equal(t, refreshTokenCookieName, cookie.Name)
+		assert.Empty(t, cookie.Value)
+		assert.True(t, cookie.MaxAge < 0)
+	}
+	mockService.AssertExpectations(t)
+}
 
-	router := mux.NewRouter()
-	router.HandleFunc("/users/{id}", handler.GetAppUserById)
-	router.ServeHTTP(rr, req)
+func TestLogoutWithoutCookie(t *testing.T) {
+	mockService := new(MockAppUserService)
+	handler := transportHttp.Handler{AppUserService: mockService}
 
-	assert.Equal(t, http.StatusOK, rr.Code)
+	req, _ := http.NewRequest("POST", "/auth/logout/", nil)
 
-	var response response_dto.AppUserDto
-	_ = json.Unmarshal(rr.Body.Bytes(), &response)
+	rr := httptest.NewRecorder()
+	handler.Logout(rr, req)
 
-	assert.Equal(t, "test", response.Username)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockService.AssertNotCalled(t, "Logout", mock.Anything, mock.Anything)
 }
 
-func TestGetAppUserByIdNotFound(t *testing.T) {
+func TestRevokeAllSessionsSuccessful(t *testing.T) {
+	userId := uuid.New()
 	mockService := new(MockAppUserService)
 	handler := transportHttp.Handler{AppUserService: mockService}
 
-	nonExistentUserId := uuid.New()
-	mockService.On("GetAppUserById", mock.Anything, nonExistentUserId).Return(repository.AppUser{}, errors.New("not found"))
+	req, _ := http.NewRequest("POST", "/api/user/me/sessions/revoke-all/", nil)
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: userId}))
+
+	mockService.On("RevokeAllRefreshTokens", mock.Anything, userId).Return(nil)
 
-	req, _ := http.NewRequest("GET", "/users/"+nonExistentUserId.String(), nil)
 	rr := httptest.NewRecorder()
+	handler.RevokeAllSessions(rr, req)
 
-	router := mux.NewRouter()
-	router.HandleFunc("/users/{id}", handler.GetAppUserById)
-	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockService.AssertExpectations(t)
+}
 
-	assert.Equal(t, http.StatusNotFound, rr.Code)
+func TestRevokeAllSessionsNoJwtClaims(t *testing.T) {
+	mockService := new(MockAppUserService)
+	handler := transportHttp.Handler{AppUserService: mockService}
+
+	req, _ := http.NewRequest("POST", "/api/user/me/sessions/revoke-all/", nil)
+
+	rr := httptest.NewRecorder()
+	handler.RevokeAllSessions(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	mockService.AssertNotCalled(t, "RevokeAllRefreshTokens", mock.Anything, mock.Anything)
 }
 
-func TestSendUserEmailVerification_Success(t *testing.T) {
-	emailAddress := "test@example.com"
+func TestUpdateAppUserPasswordMissingPrincipal(t *testing.T) {
 	mockService := new(MockAppUserService)
-	mockService.On("SendUserEmailVerification", mock.Anything, emailAddress).Return(nil)
 
-	req, _ := http.NewRequest("POST", "/api/user/send-email-verification/", strings.NewReader(""))
-	req = req.WithContext(context.WithValue(req.Context(), "jwt_claims", map[string]interface{}{
-		"email_verified": false,
-		"email":          emailAddress,
-	}))
+	req, _ := http.NewRequest("POST", "/api/user/me/password/", strings.NewReader(`{"old_password": "old", "new_password": "new"}`))
+	recorder := httptest.NewRecorder()
+	handler := transportHttp.Handler{AppUserService: mockService}
+	handler.UpdateAppUserPassword(recorder, req)
+
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	mockService.AssertNotCalled(t, "UpdateAppUserPassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRequestPasswordResetAlwaysAccepted(t *testing.T) {
+	mockService := new(MockAppUserService)
+	handler := transportHttp.Handler{AppUserService: mockService}
+
+	mockService.On("RequestPasswordReset", mock.Anything, "unknown@example.com").Return(errors.New("email sending error"))
+
+	req, _ := http.NewRequest("POST", "/auth/password-reset/request/", strings.NewReader(`{"email": "unknown@example.com"}`))
+	rr := httptest.NewRecorder()
+	handler.RequestPasswordReset(rr, req)
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestConfirmPasswordResetSuccessful(t *testing.T) {
+	mockService := new(MockAppUserService)
+	handler := transportHttp.Handler{AppUserService: mockService}
+
+	mockService.On("ConfirmPasswordReset", mock.Anything, "token", "newPassword", mock.Anything).Return(nil)
+
+	req, _ := http.NewRequest("POST", "/auth/password-reset/confirm/", strings.NewReader(`{"token": "token", "new_password": "newPassword"}`))
+	rr := httptest.NewRecorder()
+	handler.ConfirmPasswordReset(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestConfirmPasswordResetInvalidToken(t *testing.T) {
+	mockService := new(MockAppUserService)
+	handler := transportHttp.Handler{AppUserService: mockService}
+
+	mockService.On("ConfirmPasswordReset", mock.Anything, "token", "newPassword", mock.Anything).Return(&repository.InvalidVerificationTokenError{})
+
+	req, _ := http.NewRequest("POST", "/auth/password-reset/confirm/", strings.NewReader(`{"token": "token", "new_password": "newPassword"}`))
+	rr := httptest.NewRecorder()
+	handler.ConfirmPasswordReset(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestUnlockAccountSuccessful(t *testing.T) {
+	mockService := new(MockAppUserService)
+	handler := transportHttp.Handler{AppUserService: mockService}
+
+	mockService.On("UnlockAccount", mock.Anything, "token", mock.Anything).Return(nil)
+
+	req, _ := http.NewRequest("POST", "/auth/unlock-account/", strings.NewReader(`{"token": "token"}`))
+	rr := httptest.NewRecorder()
+	handler.UnlockAccount(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestUnlockAccountInvalidToken(t *testing.T) {
+	mockService := new(MockAppUserService)
+	handler := transportHttp.Handler{AppUserService: mockService}
+
+	mockService.On("UnlockAccount", mock.Anything, "token", mock.Anything).Return(&repository.InvalidVerificationTokenError{})
+
+	req, _ := http.NewRequest("POST", "/auth/unlock-account/", strings.NewReader(`{"token": "token"}`))
+	rr := httptest.NewRecorder()
+	handler.UnlockAccount(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestRequestEmailChange(t *testing.T) {
+	userId := uuid.New()
+	mockService := new(MockAppUserService)
+	mockService.On("RequestEmailChange", mock.Anything, userId, "password", "new@example.com").Return(nil)
+
+	req, _ := http.NewRequest("POST", "/api/user/me/email/", strings.NewReader(`{"email": "new@example.com", "password": "password"}`))
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: userId}))
 
 	recorder := httptest.NewRecorder()
 	handler := transportHttp.Handler{AppUserService: mockService}
-	handler.SendUserEmailVerification(recorder, req)
+	handler.RequestEmailChange(recorder, req)
+
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestRequestEmailChange_EmailInUse(t *testing.T) {
+	userId := uuid.New()
+	mockService := new(MockAppUserService)
+	mockService.On("RequestEmailChange", mock.Anything, userId, "password", "taken@example.com").Return(&repository.DuplicateKeyError{Key: "Email address already in use."})
+
+	req, _ := http.NewRequest("POST", "/api/user/me/email/", strings.NewReader(`{"email": "taken@example.com", "password": "password"}`))
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: userId}))
+
+	recorder := httptest.NewRecorder()
+	handler := transportHttp.Handler{AppUserService: mockService}
+	handler.RequestEmailChange(recorder, req)
+
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	userId := uuid.New()
+	mockService := new(MockAppUserService)
+	mockService.On("ConfirmEmailChange", mock.Anything, userId, "token").Return(repository.AppUser{ID: userId, Email: "new@example.com", EmailVerified: true}, nil)
+
+	req, _ := http.NewRequest("POST", "/api/user/me/email/confirm/?token=token", nil)
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: userId}))
+
+	recorder := httptest.NewRecorder()
+	handler := transportHttp.Handler{AppUserService: mockService}
+	handler.ConfirmEmailChange(recorder, req)
 
 	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "new@example.com")
 	mockService.AssertExpectations(t)
 }
 
-func TestSendUserEmailVerification_EmailAlreadyVerified(t *testing.T) {
-	emailAddress := "test@example.com"
+func TestConfirmEmailChange_InvalidToken(t *testing.T) {
+	userId := uuid.New()
 	mockService := new(MockAppUserService)
+	mockService.On("ConfirmEmailChange", mock.Anything, userId, "token").Return(repository.AppUser{}, &repository.InvalidVerificationTokenError{})
 
-	req, _ := http.NewRequest("POST", "/api/user/send-email-verification/", strings.NewReader(""))
-	req = req.WithContext(context.WithValue(req.Context(), "jwt_claims", map[string]interface{}{
-		"email_verified": true,
-		"email":          emailAddress,
-	}))
+	req, _ := http.NewRequest("POST", "/api/user/me/email/confirm/?token=token", nil)
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: userId}))
 
 	recorder := httptest.NewRecorder()
 	handler := transportHttp.Handler{AppUserService: mockService}
-	handler.SendUserEmailVerification(recorder, req)
+	handler.ConfirmEmailChange(recorder, req)
 
 	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestChangeUsername(t *testing.T) {
+	userId := uuid.New()
+	mockService := new(MockAppUserService)
+	mockService.On("ChangeUsername", mock.Anything, userId, "new_name").Return(repository.AppUser{ID: userId, Username: "new_name"}, nil)
+
+	req, _ := http.NewRequest("PATCH", "/api/user/me/username/", strings.NewReader(`{"username": "new_name"}`))
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: userId}))
+
+	recorder := httptest.NewRecorder()
+	handler := transportHttp.Handler{AppUserService: mockService}
+	handler.ChangeUsername(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "new_name")
 	mockService.AssertExpectations(t)
 }
+
+func TestChangeUsername_Cooldown(t *testing.T) {
+	userId := uuid.New()
+	mockService := new(MockAppUserService)
+	mockService.On("ChangeUsername", mock.Anything, userId, "new_name").Return(repository.AppUser{}, &repository.UsernameChangeCooldownError{RetryAfter: time.Now().Add(time.Hour)})
+
+	req, _ := http.NewRequest("PATCH", "/api/user/me/username/", strings.NewReader(`{"username": "new_name"}`))
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: userId}))
+
+	recorder := httptest.NewRecorder()
+	handler := transportHttp.Handler{AppUserService: mockService}
+	handler.ChangeUsername(recorder, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "3600", recorder.Header().Get("Retry-After"))
+}
+
+func TestChangeUsername_Invalid(t *testing.T) {
+	userId := uuid.New()
+	mockService := new(MockAppUserService)
+	mockService.On("ChangeUsername", mock.Anything, userId, "no spaces").Return(repository.AppUser{}, &username_util.InvalidUsernameError{Key: "invalid"})
+
+	req, _ := http.NewRequest("PATCH", "/api/user/me/username/", strings.NewReader(`{"username": "no spaces"}`))
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: userId}))
+
+	recorder := httptest.NewRecorder()
+	handler := transportHttp.Handler{AppUserService: mockService}
+	handler.ChangeUsername(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
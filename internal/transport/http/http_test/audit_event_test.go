@@ -0,0 +1,72 @@
+package http_test
+
+import (
+	"context"
+	"eau-de-go/internal/repository"
+	transportHttp "eau-de-go/internal/transport/http"
+	"eau-de-go/internal/transport/http/response_dto"
+	"eau-de-go/pkg/jwt_util"
+	"eau-de-go/pkg/pagination"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) ListAuditEvents(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, filter repository.AuditEventFilter, pageRequest pagination.PageRequest) (pagination.Page[repository.AuditEvent], error) {
+	args := m.Called(ctx, actorId, clientInfo, filter, pageRequest)
+	return args.Get(0).(pagination.Page[repository.AuditEvent]), args.Error(1)
+}
+
+func TestAdminListAuditEventsSuccessful(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), IsStaff: true}
+	userId := uuid.New()
+	mockService := new(MockAuditService)
+	handler := transportHttp.Handler{AuditService: mockService}
+
+	events := []repository.AuditEvent{{
+		ID:        uuid.New(),
+		TargetID:  uuid.NullUUID{UUID: userId, Valid: true},
+		Action:    "user.login_failed",
+		Metadata:  json.RawMessage(`{"reason":"incorrect_password"}`),
+		CreatedAt: time.Now(),
+	}}
+	mockService.On("ListAuditEvents", mock.Anything, staff.ID, mock.Anything, mock.MatchedBy(func(filter repository.AuditEventFilter) bool {
+		return *filter.UserId == userId && filter.Since.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) && filter.Until == nil
+	}), pagination.PageRequest{Limit: 20}).Return(pagination.Page[repository.AuditEvent]{Items: events, NextCursor: "next"}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.AdminListAuditEvents(rr, newAdminRequest("GET", "/admin/audit-events/?user_id="+userId.String()+"&since=2026-01-01T00:00:00Z&limit=20", nil, staff))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response pagination.Page[response_dto.AuditEventDto]
+	_ = json.Unmarshal(rr.Body.Bytes(), &response)
+
+	assert.Len(t, response.Items, 1)
+	assert.Nil(t, response.Items[0].ActorID)
+	assert.Equal(t, userId, *response.Items[0].TargetID)
+	assert.JSONEq(t, `{"reason":"incorrect_password"}`, string(response.Items[0].Metadata))
+	assert.Equal(t, "next", response.NextCursor)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdminListAuditEventsInvalidUserId(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), IsStaff: true}
+	mockService := new(MockAuditService)
+	handler := transportHttp.Handler{AuditService: mockService}
+
+	rr := httptest.NewRecorder()
+	handler.AdminListAuditEvents(rr, newAdminRequest("GET", "/admin/audit-events/?user_id=42", nil, staff))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertNotCalled(t, "ListAuditEvents", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,103 @@
+package http_test
+
+import (
+	"context"
+	"database/sql"
+	"eau-de-go/internal/repository"
+	transportHttp "eau-de-go/internal/transport/http"
+	"eau-de-go/internal/transport/http/response_dto"
+	"eau-de-go/pkg/jwt_util"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type MockEmailOutboxService struct {
+	mock.Mock
+}
+
+func (m *MockEmailOutboxService) GetDeliveryStatus(ctx context.Context, id uuid.UUID) (repository.EmailOutbox, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(repository.EmailOutbox), args.Error(1)
+}
+
+func TestAdminGetEmailDeliveryStatusSuccessful(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), IsStaff: true}
+	emailId := uuid.New()
+	mockService := new(MockEmailOutboxService)
+	handler := transportHttp.Handler{EmailOutboxService: mockService}
+
+	mockService.On("GetDeliveryStatus", mock.Anything, emailId).Return(repository.EmailOutbox{
+		ID:        emailId,
+		Message:   json.RawMessage(`{"to":["test@example.com"],"text_body":"token"}`),
+		Status:    "dead",
+		Attempts:  8,
+		LastError: "mailbox unavailable",
+		CreatedAt: time.Now(),
+	}, nil)
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/emails/{id}/", handler.AdminGetEmailDeliveryStatus)
+	router.ServeHTTP(rr, newAdminRequest("GET", "/admin/emails/"+emailId.String()+"/", nil, staff))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "token", "Expected the message not to be returned")
+
+	var response response_dto.EmailDeliveryStatusDto
+	_ = json.Unmarshal(rr.Body.Bytes(), &response)
+
+	assert.Equal(t, emailId, response.ID)
+	assert.Equal(t, "dead", response.Status)
+	assert.Equal(t, int32(8), response.Attempts)
+	assert.Equal(t, "mailbox unavailable", response.LastError)
+	assert.Nil(t, response.SentAt)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdminGetEmailDeliveryStatusNotFound(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), IsStaff: true}
+	emailId := uuid.New()
+	mockService := new(MockEmailOutboxService)
+	handler := transportHttp.Handler{EmailOutboxService: mockService}
+
+	mockService.On("GetDeliveryStatus", mock.Anything, emailId).Return(repository.EmailOutbox{}, &repository.NotFoundError{Key: "Email not found."})
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/emails/{id}/", handler.AdminGetEmailDeliveryStatus)
+	router.ServeHTTP(rr, newAdminRequest("GET", "/admin/emails/"+emailId.String()+"/", nil, staff))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAdminGetEmailDeliveryStatusSentAt(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), IsStaff: true}
+	emailId := uuid.New()
+	mockService := new(MockEmailOutboxService)
+	handler := transportHttp.Handler{EmailOutboxService: mockService}
+
+	sentAt := time.Now()
+	mockService.On("GetDeliveryStatus", mock.Anything, emailId).Return(repository.EmailOutbox{
+		ID:     emailId,
+		Status: "sent",
+		SentAt: sql.NullTime{Time: sentAt, Valid: true},
+	}, nil)
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/emails/{id}/", handler.AdminGetEmailDeliveryStatus)
+	router.ServeHTTP(rr, newAdminRequest("GET", "/admin/emails/"+emailId.String()+"/", nil, staff))
+
+	var response response_dto.EmailDeliveryStatusDto
+	_ = json.Unmarshal(rr.Body.Bytes(), &response)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, sentAt.String(), *response.SentAt)
+}
@@ -0,0 +1,65 @@
+package http_test
+
+import (
+	transportHttp "eau-de-go/internal/transport/http"
+	"eau-de-go/pkg/keys"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type MockVerificationKeyStore struct {
+	mock.Mock
+}
+
+func (m *MockVerificationKeyStore) GetVerificationKeys() (map[string]keys.VerificationKey, error) {
+	args := m.Called()
+	return args.Get(0).(map[string]keys.VerificationKey), args.Error(1)
+}
+
+func TestGetJwksSuccessful(t *testing.T) {
+	signingKey, err := keys.GenerateSigningKey(keys.PS256)
+	assert.NoError(t, err)
+
+	mockKeyStore := new(MockVerificationKeyStore)
+	mockKeyStore.On("GetVerificationKeys").Return(map[string]keys.VerificationKey{
+		"kid1": {PublicKey: signingKey.Public(), Algorithm: keys.PS256},
+	}, nil)
+	handler := transportHttp.Handler{VerificationKeyStore: mockKeyStore}
+
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	rr := httptest.NewRecorder()
+	handler.GetJwks(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/jwk-set+json", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Cache-Control"), "max-age=")
+
+	var response keys.JwkSet
+	_ = json.Unmarshal(rr.Body.Bytes(), &response)
+
+	assert.Len(t, response.Keys, 1)
+	assert.Equal(t, "kid1", response.Keys[0].Kid)
+	assert.Equal(t, "RSA", response.Keys[0].Kty)
+	assert.Equal(t, "sig", response.Keys[0].Use)
+	assert.Equal(t, "PS256", response.Keys[0].Alg)
+	assert.NotEmpty(t, response.Keys[0].N)
+	assert.Equal(t, "AQAB", response.Keys[0].E)
+	mockKeyStore.AssertExpectations(t)
+}
+
+func TestGetJwksKeyStoreError(t *testing.T) {
+	mockKeyStore := new(MockVerificationKeyStore)
+	mockKeyStore.On("GetVerificationKeys").Return(map[string]keys.VerificationKey{}, errors.New("key store unavailable"))
+	handler := transportHttp.Handler{VerificationKeyStore: mockKeyStore}
+
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	rr := httptest.NewRecorder()
+	handler.GetJwks(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
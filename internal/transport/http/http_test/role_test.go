@@ -0,0 +1,245 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"eau-de-go/internal/repository"
+	transportHttp "eau-de-go/internal/transport/http"
+	"eau-de-go/internal/transport/http/request_dto"
+	"eau-de-go/internal/transport/http/response_dto"
+	"eau-de-go/internal/transport/middleware"
+	"eau-de-go/pkg/jwt_util"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type MockRoleService struct {
+	mock.Mock
+}
+
+func (m *MockRoleService) ListRoles(ctx context.Context) ([]repository.Role, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]repository.Role), args.Error(1)
+}
+
+func (m *MockRoleService) ListPermissions(ctx context.Context) ([]repository.Permission, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]repository.Permission), args.Error(1)
+}
+
+func (m *MockRoleService) CreateRole(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, name string, description string, permissions []string) (repository.Role, error) {
+	args := m.Called(ctx, actorId, clientInfo, name, description, permissions)
+	return args.Get(0).(repository.Role), args.Error(1)
+}
+
+func (m *MockRoleService) DeleteRole(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, roleName string) error {
+	args := m.Called(ctx, actorId, clientInfo, roleName)
+	return args.Error(0)
+}
+
+func (m *MockRoleService) GetRolePermissions(ctx context.Context, roleName string) ([]string, error) {
+	args := m.Called(ctx, roleName)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRoleService) AssignUserRole(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID, roleName string) error {
+	args := m.Called(ctx, actorId, clientInfo, userId, roleName)
+	return args.Error(0)
+}
+
+func (m *MockRoleService) RevokeUserRole(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID, roleName string) error {
+	args := m.Called(ctx, actorId, clientInfo, userId, roleName)
+	return args.Error(0)
+}
+
+func (m *MockRoleService) ListUserRoles(ctx context.Context, userId uuid.UUID) ([]repository.Role, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).([]repository.Role), args.Error(1)
+}
+
+func TestRequirePermissionGranted(t *testing.T) {
+	mockService := new(MockRoleService)
+	handler := transportHttp.Handler{RoleService: mockService}
+
+	roles := []repository.Role{{ID: uuid.New(), Name: "admin"}}
+	mockService.On("ListRoles", mock.Anything).Return(roles, nil)
+
+	req, _ := http.NewRequest("GET", "/admin/roles/", nil)
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: uuid.New(), Permissions: []string{"roles.manage"}}))
+
+	rr := httptest.NewRecorder()
+	middleware.RequirePermission("roles.manage")(http.HandlerFunc(handler.ListRoles)).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response []response_dto.RoleDto
+	_ = json.Unmarshal(rr.Body.Bytes(), &response)
+
+	assert.Len(t, response, 1)
+	assert.Equal(t, "admin", response[0].Name)
+	mockService.AssertExpectations(t)
+}
+
+func TestRequirePermissionDenied(t *testing.T) {
+	mockService := new(MockRoleService)
+	handler := transportHttp.Handler{RoleService: mockService}
+
+	req, _ := http.NewRequest("GET", "/admin/roles/", nil)
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: uuid.New(), Permissions: []string{"users.read"}}))
+
+	rr := httptest.NewRecorder()
+	middleware.RequirePermission("roles.manage")(http.HandlerFunc(handler.ListRoles)).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	mockService.AssertNotCalled(t, "ListRoles", mock.Anything)
+}
+
+func TestRequirePermissionWithoutPrincipal(t *testing.T) {
+	mockService := new(MockRoleService)
+	handler := transportHttp.Handler{RoleService: mockService}
+
+	req, _ := http.NewRequest("GET", "/admin/roles/", nil)
+
+	rr := httptest.NewRecorder()
+	middleware.RequirePermission("roles.manage")(http.HandlerFunc(handler.ListRoles)).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestCreateRoleSuccessful(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), Permissions: []string{"roles.manage"}}
+	mockService := new(MockRoleService)
+	handler := transportHttp.Handler{RoleService: mockService}
+
+	role := repository.Role{ID: uuid.New(), Name: "support", Description: "Support staff"}
+	mockService.On("CreateRole", mock.Anything, staff.ID, mock.Anything, "support", "Support staff", []string{"users.read"}).Return(role, nil)
+
+	body, _ := json.Marshal(request_dto.CreateRoleRequestDto{Name: "support", Description: "Support staff", Permissions: []string{"users.read"}})
+	req := newAdminRequest("POST", "/admin/roles/", body, staff)
+
+	rr := httptest.NewRecorder()
+	handler.CreateRole(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var response response_dto.RoleDto
+	_ = json.Unmarshal(rr.Body.Bytes(), &response)
+
+	assert.Equal(t, role.ID, response.ID)
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateRoleDuplicate(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), Permissions: []string{"roles.manage"}}
+	mockService := new(MockRoleService)
+	handler := transportHttp.Handler{RoleService: mockService}
+
+	mockService.On("CreateRole", mock.Anything, staff.ID, mock.Anything, "admin", "", []string(nil)).Return(repository.Role{}, &repository.DuplicateKeyError{Key: "Role already exists."})
+
+	body, _ := json.Marshal(request_dto.CreateRoleRequestDto{Name: "admin"})
+	req := newAdminRequest("POST", "/admin/roles/", body, staff)
+
+	rr := httptest.NewRecorder()
+	handler.CreateRole(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestCreateRoleWithoutPrincipal(t *testing.T) {
+	mockService := new(MockRoleService)
+	handler := transportHttp.Handler{RoleService: mockService}
+
+	body, _ := json.Marshal(request_dto.CreateRoleRequestDto{Name: "support"})
+	req, _ := http.NewRequest("POST", "/admin/roles/", bytes.NewBuffer(body))
+
+	rr := httptest.NewRecorder()
+	handler.CreateRole(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	mockService.AssertNotCalled(t, "CreateRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteRoleSuccessful(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), Permissions: []string{"roles.manage"}}
+	mockService := new(MockRoleService)
+	handler := transportHttp.Handler{RoleService: mockService}
+
+	mockService.On("DeleteRole", mock.Anything, staff.ID, mock.MatchedBy(func(clientInfo repository.ClientInfo) bool {
+		return clientInfo.UserAgent == "test-agent"
+	}), "support").Return(nil)
+
+	req := newAdminRequest("DELETE", "/admin/roles/support/", nil, staff)
+	req.Header.Set("User-Agent", "test-agent")
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/roles/{name}/", handler.DeleteRole)
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAssignUserRoleSuccessful(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), Permissions: []string{"roles.manage"}}
+	userId := uuid.New()
+	mockService := new(MockRoleService)
+	handler := transportHttp.Handler{RoleService: mockService}
+
+	mockService.On("AssignUserRole", mock.Anything, staff.ID, mock.Anything, userId, "admin").Return(nil)
+
+	body, _ := json.Marshal(request_dto.AssignUserRoleRequestDto{Role: "admin"})
+	req := newAdminRequest("POST", "/admin/users/"+userId.String()+"/roles/", body, staff)
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/users/{id}/roles/", handler.AssignUserRole)
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAssignUserRoleUnknownRole(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), Permissions: []string{"roles.manage"}}
+	userId := uuid.New()
+	mockService := new(MockRoleService)
+	handler := transportHttp.Handler{RoleService: mockService}
+
+	mockService.On("AssignUserRole", mock.Anything, staff.ID, mock.Anything, userId, "unknown").Return(&repository.NotFoundError{Key: "Role not found."})
+
+	body, _ := json.Marshal(request_dto.AssignUserRoleRequestDto{Role: "unknown"})
+	req := newAdminRequest("POST", "/admin/users/"+userId.String()+"/roles/", body, staff)
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/users/{id}/roles/", handler.AssignUserRole)
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestRevokeUserRoleSuccessful(t *testing.T) {
+	staff := &jwt_util.Principal{ID: uuid.New(), Permissions: []string{"roles.manage"}}
+	userId := uuid.New()
+	mockService := new(MockRoleService)
+	handler := transportHttp.Handler{RoleService: mockService}
+
+	mockService.On("RevokeUserRole", mock.Anything, staff.ID, mock.Anything, userId, "admin").Return(nil)
+
+	req := newAdminRequest("DELETE", "/admin/users/"+userId.String()+"/roles/admin/", nil, staff)
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/users/{id}/roles/{role}/", handler.RevokeUserRole)
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,82 @@
+package http_test
+
+import (
+	"eau-de-go/internal/repository"
+	transportHttp "eau-de-go/internal/transport/http"
+	"eau-de-go/internal/transport/http/response_dto"
+	"eau-de-go/pkg/jwt_util"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListSessionsSuccessful(t *testing.T) {
+	userId := uuid.New()
+	mockService := new(MockAppUserService)
+	handler := transportHttp.Handler{AppUserService: mockService}
+
+	sessions := []repository.UserSession{{ID: uuid.New(), UserID: userId, UserAgent: "test-agent", IpAddress: "127.0.0.1"}}
+	mockService.On("ListActiveSessions", mock.Anything, userId).Return(sessions, nil)
+
+	req, _ := http.NewRequest("GET", "/api/user/me/sessions/", nil)
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: userId}))
+
+	rr := httptest.NewRecorder()
+	handler.ListSessions(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response []response_dto.UserSessionDto
+	_ = json.Unmarshal(rr.Body.Bytes(), &response)
+
+	assert.Len(t, response, 1)
+	assert.Equal(t, sessions[0].ID, response[0].ID)
+	assert.Equal(t, "test-agent", response[0].UserAgent)
+	assert.Equal(t, "127.0.0.1", response[0].IpAddress)
+	mockService.AssertExpectations(t)
+}
+
+func TestRevokeSessionSuccessful(t *testing.T) {
+	userId := uuid.New()
+	sessionId := uuid.New()
+	mockService := new(MockAppUserService)
+	handler := transportHttp.Handler{AppUserService: mockService}
+
+	mockService.On("RevokeSession", mock.Anything, userId, sessionId).Return(nil)
+
+	req, _ := http.NewRequest("DELETE", "/sessions/"+sessionId.String()+"/", nil)
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: userId}))
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/sessions/{id}/", handler.RevokeSession)
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestRevokeSessionNotFound(t *testing.T) {
+	userId := uuid.New()
+	sessionId := uuid.New()
+	mockService := new(MockAppUserService)
+	handler := transportHttp.Handler{AppUserService: mockService}
+
+	mockService.On("RevokeSession", mock.Anything, userId, sessionId).Return(&repository.NotFoundError{Key: "Session not found."})
+
+	req, _ := http.NewRequest("DELETE", "/sessions/"+sessionId.String()+"/", nil)
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: userId}))
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/sessions/{id}/", handler.RevokeSession)
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockService.AssertExpectations(t)
+}
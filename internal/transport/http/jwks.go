@@ -0,0 +1,45 @@
+package http
+
+import (
+	"eau-de-go/pkg/keys"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+type VerificationKeyStore interface {
+	GetVerificationKeys() (map[string]keys.VerificationKey, error)
+}
+
+// Consumers re-fetch the key set when they encounter an unknown kid, so a short cache lifetime only bounds
+// how long a retired key keeps being trusted by them.
+var jwksCacheMaxAge = 5 * time.Minute
+
+// GetJwks serves the current verification keys as a JSON Web Key Set, so that other services can verify access tokens.
+func (h *Handler) GetJwks(w http.ResponseWriter, r *http.Request) {
+	verificationKeys, err := h.VerificationKeyStore.GetVerificationKeys()
+	if err != nil {
+		log.Errorf("Error getting verification keys: %v", err)
+		http.Error(w, "Unable to get verification keys", http.StatusInternalServerError)
+		return
+	}
+
+	jwkSet := keys.NewJwkSet(verificationKeys)
+
+	jsonData, err := json.Marshal(jwkSet)
+	if err != nil {
+		log.Errorf("Error marshalling json: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(jwksCacheMaxAge.Seconds())))
+	_, err = w.Write(jsonData)
+	if err != nil {
+		log.Errorf("Error writing response: %v", err)
+		return
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"eau-de-go/internal/repository"
 	"eau-de-go/pkg/jwt_util"
 	"encoding/json"
-	"github.com/google/uuid"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 type AppUserLoginRequestDto struct {
@@ -53,21 +56,11 @@ func MakeUpdateAppUserParamsFromRequest(r *http.Request) (repository.UpdateAppUs
 	var dto UpdateAppUserRequestDto
 	var firstName, lastName sql.NullString
 
-	jwtClaims, ok := r.Context().Value("jwt_claims").(map[string]interface{})
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
 	if !ok {
 		return repository.UpdateAppUserParams{}, &jwt_util.InvalidTokenError{}
 	}
 
-	idStr, ok := jwtClaims["id"].(string)
-	if !ok {
-		return repository.UpdateAppUserParams{}, &jwt_util.InvalidTokenError{}
-	}
-
-	userId, err := uuid.Parse(idStr)
-	if err != nil {
-		return repository.UpdateAppUserParams{}, &jwt_util.InvalidTokenError{}
-	}
-
 	bodyBytes, err := io.ReadAll(r.Body)
 	if err != nil {
 		return repository.UpdateAppUserParams{}, err
@@ -91,7 +84,7 @@ func MakeUpdateAppUserParamsFromRequest(r *http.Request) (repository.UpdateAppUs
 	}
 
 	updateAppUserParams := repository.UpdateAppUserParams{
-		ID:        userId,
+		ID:        principal.ID,
 		FirstName: firstName,
 		LastName:  lastName,
 	}
@@ -102,3 +95,77 @@ type UpdateAppUserPasswordRequestDto struct {
 	OldPassword string `json:"old_password"`
 	NewPassword string `json:"new_password"`
 }
+
+type PasswordResetRequestDto struct {
+	Email string `json:"email"`
+}
+
+type PasswordResetConfirmRequestDto struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type AccountUnlockRequestDto struct {
+	Token string `json:"token"`
+}
+
+type EmailChangeRequestDto struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type UsernameChangeRequestDto struct {
+	Username string `json:"username"`
+}
+
+type StaffStatusRequestDto struct {
+	IsStaff *bool `json:"is_staff"`
+}
+
+// MakeAppUserFilterFromQuery reads the is_active, is_staff, email_verified, joined_after, joined_before and search
+// query parameters of a user listing, dates are RFC 3339 timestamps.
+func MakeAppUserFilterFromQuery(query url.Values) (repository.AppUserFilter, error) {
+	var filter repository.AppUserFilter
+	var err error
+	if filter.IsActive, err = parseOptionalBool(query, "is_active"); err != nil {
+		return repository.AppUserFilter{}, err
+	}
+	if filter.IsStaff, err = parseOptionalBool(query, "is_staff"); err != nil {
+		return repository.AppUserFilter{}, err
+	}
+	if filter.EmailVerified, err = parseOptionalBool(query, "email_verified"); err != nil {
+		return repository.AppUserFilter{}, err
+	}
+	if filter.JoinedAfter, err = parseOptionalTime(query, "joined_after"); err != nil {
+		return repository.AppUserFilter{}, err
+	}
+	if filter.JoinedBefore, err = parseOptionalTime(query, "joined_before"); err != nil {
+		return repository.AppUserFilter{}, err
+	}
+	filter.Search = query.Get("search")
+	return filter, nil
+}
+
+func parseOptionalBool(query url.Values, key string) (*bool, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be true or false", key)
+	}
+	return &parsed, nil
+}
+
+func parseOptionalTime(query url.Values, key string) (*time.Time, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", key)
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,30 @@
+package request_dto
+
+import (
+	"eau-de-go/internal/repository"
+	"errors"
+	"github.com/google/uuid"
+	"net/url"
+)
+
+// MakeAuditEventFilterFromQuery reads the user_id, action, since and until query parameters of an audit event listing,
+// dates are RFC 3339 timestamps.
+func MakeAuditEventFilterFromQuery(query url.Values) (repository.AuditEventFilter, error) {
+	var filter repository.AuditEventFilter
+	var err error
+	if value := query.Get("user_id"); value != "" {
+		userId, parseErr := uuid.Parse(value)
+		if parseErr != nil {
+			return repository.AuditEventFilter{}, errors.New("user_id must be a UUID")
+		}
+		filter.UserId = &userId
+	}
+	filter.Action = query.Get("action")
+	if filter.Since, err = parseOptionalTime(query, "since"); err != nil {
+		return repository.AuditEventFilter{}, err
+	}
+	if filter.Until, err = parseOptionalTime(query, "until"); err != nil {
+		return repository.AuditEventFilter{}, err
+	}
+	return filter, nil
+}
@@ -0,0 +1,47 @@
+package request_dto
+
+import (
+	"eau-de-go/internal/repository"
+	"eau-de-go/settings"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// MakeClientInfoFromRequest captures the user agent and IP address of the client making the request.
+// The X-Forwarded-For header is only honoured when the server is configured to trust proxy headers,
+// otherwise any client could spoof its address.
+func MakeClientInfoFromRequest(r *http.Request) repository.ClientInfo {
+	trustedProxyCount := 0
+	if settings.TrustProxyHeaders {
+		trustedProxyCount = settings.TrustedProxyCount
+	}
+	return repository.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IpAddress: getClientIpAddress(r, trustedProxyCount),
+	}
+}
+
+// getClientIpAddress returns the address the trustedProxyCount-th proxy from the server received the request from.
+// Every proxy appends the address of its peer to X-Forwarded-For, so entries left of those added by the trusted proxies
+// are set by the client and are ignored.
+func getClientIpAddress(r *http.Request, trustedProxyCount int) string {
+	if trustedProxyCount > 0 {
+		var forwardedFor []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			forwardedFor = append(forwardedFor, strings.Split(header, ",")...)
+		}
+		if len(forwardedFor) >= trustedProxyCount {
+			clientIp := strings.TrimSpace(forwardedFor[len(forwardedFor)-trustedProxyCount])
+			if net.ParseIP(clientIp) != nil {
+				return clientIp
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
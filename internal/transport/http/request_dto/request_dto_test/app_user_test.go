@@ -2,8 +2,8 @@ package request_dto_test
 
 import (
 	"bytes"
-	"context"
 	"eau-de-go/internal/transport/http/request_dto"
+	"eau-de-go/pkg/jwt_util"
 	"github.com/google/uuid"
 	"net/http"
 	"testing"
@@ -72,7 +72,7 @@ func TestMakeUpdateAppUserParamsFromRequest_HappyPath(t *testing.T) {
 		"last_name": "User"
 	}`
 	req, _ := http.NewRequest("PUT", "/appuser", bytes.NewBuffer([]byte(requestBody)))
-	req = req.WithContext(context.WithValue(req.Context(), "jwt_claims", map[string]interface{}{"id": uuid.New().String()}))
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: uuid.New()}))
 	params, err := request_dto.MakeUpdateAppUserParamsFromRequest(req)
 
 	if err != nil {
@@ -89,7 +89,7 @@ func TestMakeUpdateAppUserParamsFromRequest_MissingStringFieldGetNil(t *testing.
 		"first_name": "Updated"
 	}`
 	req, _ := http.NewRequest("PUT", "/appuser", bytes.NewBuffer([]byte(requestBody)))
-	req = req.WithContext(context.WithValue(req.Context(), "jwt_claims", map[string]interface{}{"id": uuid.New().String()}))
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: uuid.New()}))
 	params, err := request_dto.MakeUpdateAppUserParamsFromRequest(req)
 
 	if err != nil {
@@ -107,7 +107,7 @@ func TestMakeUpdateAppUserParamsFromRequest_BadRequest(t *testing.T) {
 		"last_name": "User"
 	}`
 	req, _ := http.NewRequest("PUT", "/appuser", bytes.NewBuffer([]byte(requestBody)))
-	req = req.WithContext(context.WithValue(req.Context(), "jwt_claims", map[string]interface{}{"id": uuid.New().String()}))
+	req = req.WithContext(jwt_util.ContextWithPrincipal(req.Context(), &jwt_util.Principal{ID: uuid.New()}))
 	_, err := request_dto.MakeUpdateAppUserParamsFromRequest(req)
 
 	if err == nil {
@@ -128,13 +128,12 @@ func TestMakeUpdateAppUserParamsFromRequest_NoJwtClaims(t *testing.T) {
 	}
 }
 
-func TestMakeUpdateAppUserParamsFromRequest_InvalidJwtClaims(t *testing.T) {
+func TestMakeUpdateAppUserParamsFromRequest_MissingPrincipal(t *testing.T) {
 	requestBody := `{
 		"first_name": "Updated",
 		"last_name": "User"
 	}`
 	req, _ := http.NewRequest("PUT", "/appuser", bytes.NewBuffer([]byte(requestBody)))
-	req = req.WithContext(context.WithValue(req.Context(), "jwt_claims", map[string]interface{}{"id": "invalid_uuid"}))
 	_, err := request_dto.MakeUpdateAppUserParamsFromRequest(req)
 
 	if err == nil {
@@ -0,0 +1,61 @@
+package request_dto_test
+
+import (
+	"eau-de-go/internal/transport/http/request_dto"
+	"eau-de-go/settings"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestMakeClientInfoFromRequest(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/auth/login/", nil)
+	req.RemoteAddr = "192.0.2.1:54321"
+	req.Header.Set("User-Agent", "test-agent")
+
+	clientInfo := request_dto.MakeClientInfoFromRequest(req)
+
+	assert.Equal(t, "test-agent", clientInfo.UserAgent)
+	assert.Equal(t, "192.0.2.1", clientInfo.IpAddress)
+}
+
+func TestMakeClientInfoFromRequest_IgnoresUntrustedForwardedFor(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/auth/login/", nil)
+	req.RemoteAddr = "192.0.2.1:54321"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+
+	clientInfo := request_dto.MakeClientInfoFromRequest(req)
+
+	assert.Equal(t, "192.0.2.1", clientInfo.IpAddress)
+}
+
+func TestMakeClientInfoFromRequest_UsesRightmostForwardedFor(t *testing.T) {
+	settings.TrustProxyHeaders = true
+	defer func() { settings.TrustProxyHeaders = false }()
+
+	req, _ := http.NewRequest("POST", "/auth/login/", nil)
+	req.RemoteAddr = "10.0.0.2:54321"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+
+	clientInfo := request_dto.MakeClientInfoFromRequest(req)
+
+	assert.Equal(t, "198.51.100.7", clientInfo.IpAddress)
+}
+
+func TestMakeClientInfoFromRequest_SkipsTrustedProxies(t *testing.T) {
+	settings.TrustProxyHeaders = true
+	settings.TrustedProxyCount = 2
+	defer func() {
+		settings.TrustProxyHeaders = false
+		settings.TrustedProxyCount = 1
+	}()
+
+	req, _ := http.NewRequest("POST", "/auth/login/", nil)
+	req.RemoteAddr = "10.0.0.2:54321"
+	req.Header.Add("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+	req.Header.Add("X-Forwarded-For", "10.0.0.1")
+
+	clientInfo := request_dto.MakeClientInfoFromRequest(req)
+
+	assert.Equal(t, "198.51.100.7", clientInfo.IpAddress)
+}
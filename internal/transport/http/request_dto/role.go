@@ -0,0 +1,11 @@
+package request_dto
+
+type CreateRoleRequestDto struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type AssignUserRoleRequestDto struct {
+	Role string `json:"role"`
+}
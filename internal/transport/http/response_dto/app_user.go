@@ -39,3 +39,20 @@ func ConvertDbRow(user repository.AppUser) AppUserDto {
 		IsActive:  user.IsActive,
 	}
 }
+
+// AdminAppUserDto describes a user to staff members, including the account status fields hidden from AppUserDto.
+type AdminAppUserDto struct {
+	AppUserDto
+	EmailVerified bool   `json:"email_verified"`
+	IsStaff       bool   `json:"is_staff"`
+	DateJoined    string `json:"date_joined"`
+}
+
+func ConvertAdminDbRow(user repository.AppUser) AdminAppUserDto {
+	return AdminAppUserDto{
+		AppUserDto:    ConvertDbRow(user),
+		EmailVerified: user.EmailVerified,
+		IsStaff:       user.IsStaff,
+		DateJoined:    user.DateJoined.String(),
+	}
+}
@@ -0,0 +1,38 @@
+package response_dto
+
+import (
+	"eau-de-go/internal/repository"
+	"encoding/json"
+	"github.com/google/uuid"
+)
+
+type AuditEventDto struct {
+	ID        uuid.UUID       `json:"id"`
+	ActorID   *uuid.UUID      `json:"actor_id"`
+	TargetID  *uuid.UUID      `json:"target_id"`
+	Action    string          `json:"action"`
+	IpAddress string          `json:"ip_address"`
+	UserAgent string          `json:"user_agent"`
+	Metadata  json.RawMessage `json:"metadata"`
+	CreatedAt string          `json:"created_at"`
+}
+
+func ConvertAuditEventDbRow(event repository.AuditEvent) AuditEventDto {
+	return AuditEventDto{
+		ID:        event.ID,
+		ActorID:   nullUuidPointer(event.ActorID),
+		TargetID:  nullUuidPointer(event.TargetID),
+		Action:    event.Action,
+		IpAddress: event.IpAddress,
+		UserAgent: event.UserAgent,
+		Metadata:  event.Metadata,
+		CreatedAt: event.CreatedAt.String(),
+	}
+}
+
+func nullUuidPointer(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
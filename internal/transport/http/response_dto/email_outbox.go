@@ -0,0 +1,34 @@
+package response_dto
+
+import (
+	"eau-de-go/internal/repository"
+	"github.com/google/uuid"
+)
+
+// EmailDeliveryStatusDto is the delivery status of an email of the outbox, without the message.
+type EmailDeliveryStatusDto struct {
+	ID            uuid.UUID `json:"id"`
+	Status        string    `json:"status"`
+	Attempts      int32     `json:"attempts"`
+	LastError     string    `json:"last_error"`
+	CreatedAt     string    `json:"created_at"`
+	NextAttemptAt string    `json:"next_attempt_at"`
+	SentAt        *string   `json:"sent_at,omitempty"`
+}
+
+func ConvertEmailOutboxDbRow(email repository.EmailOutbox) EmailDeliveryStatusDto {
+	var sentAt *string
+	if email.SentAt.Valid {
+		sentAtStr := email.SentAt.Time.String()
+		sentAt = &sentAtStr
+	}
+	return EmailDeliveryStatusDto{
+		ID:            email.ID,
+		Status:        email.Status,
+		Attempts:      email.Attempts,
+		LastError:     email.LastError,
+		CreatedAt:     email.CreatedAt.String(),
+		NextAttemptAt: email.NextAttemptAt.String(),
+		SentAt:        sentAt,
+	}
+}
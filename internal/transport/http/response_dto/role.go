@@ -0,0 +1,46 @@
+package response_dto
+
+import (
+	"eau-de-go/internal/repository"
+	"github.com/google/uuid"
+)
+
+type RoleDto struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   string    `json:"created_at"`
+}
+
+func ConvertRoleDbRow(role repository.Role) RoleDto {
+	return RoleDto{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		CreatedAt:   role.CreatedAt.String(),
+	}
+}
+
+func ConvertRoleDbRows(roles []repository.Role) []RoleDto {
+	roleDtos := make([]RoleDto, 0, len(roles))
+	for _, role := range roles {
+		roleDtos = append(roleDtos, ConvertRoleDbRow(role))
+	}
+	return roleDtos
+}
+
+type PermissionDto struct {
+	Codename    string `json:"codename"`
+	Description string `json:"description"`
+}
+
+func ConvertPermissionDbRows(permissions []repository.Permission) []PermissionDto {
+	permissionDtos := make([]PermissionDto, 0, len(permissions))
+	for _, permission := range permissions {
+		permissionDtos = append(permissionDtos, PermissionDto{
+			Codename:    permission.Codename,
+			Description: permission.Description,
+		})
+	}
+	return permissionDtos
+}
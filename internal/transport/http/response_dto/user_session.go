@@ -0,0 +1,32 @@
+package response_dto
+
+import (
+	"eau-de-go/internal/repository"
+	"github.com/google/uuid"
+)
+
+type UserSessionDto struct {
+	ID              uuid.UUID `json:"id"`
+	UserAgent       string    `json:"user_agent"`
+	IpAddress       string    `json:"ip_address"`
+	CreatedAt       string    `json:"created_at"`
+	LastRefreshedAt string    `json:"last_refreshed_at"`
+}
+
+func ConvertUserSessionDbRow(session repository.UserSession) UserSessionDto {
+	return UserSessionDto{
+		ID:              session.ID,
+		UserAgent:       session.UserAgent,
+		IpAddress:       session.IpAddress,
+		CreatedAt:       session.CreatedAt.String(),
+		LastRefreshedAt: session.LastRefreshedAt.String(),
+	}
+}
+
+func ConvertUserSessionDbRows(sessions []repository.UserSession) []UserSessionDto {
+	sessionDtos := make([]UserSessionDto, 0, len(sessions))
+	for _, session := range sessions {
+		sessionDtos = append(sessionDtos, ConvertUserSessionDbRow(session))
+	}
+	return sessionDtos
+}
@@ -0,0 +1,222 @@
+package http
+
+import (
+	"context"
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/transport/http/request_dto"
+	"eau-de-go/internal/transport/http/response_dto"
+	"eau-de-go/pkg/jwt_util"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type RoleService interface {
+	ListRoles(ctx context.Context) ([]repository.Role, error)
+	ListPermissions(ctx context.Context) ([]repository.Permission, error)
+	CreateRole(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, name string, description string, permissions []string) (repository.Role, error)
+	DeleteRole(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, roleName string) error
+	GetRolePermissions(ctx context.Context, roleName string) ([]string, error)
+	AssignUserRole(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID, roleName string) error
+	RevokeUserRole(ctx context.Context, actorId uuid.UUID, clientInfo repository.ClientInfo, userId uuid.UUID, roleName string) error
+	ListUserRoles(ctx context.Context, userId uuid.UUID) ([]repository.Role, error)
+}
+
+// writeRoleServiceError responds with 404 for unknown roles, users or permissions, 409 for duplicate roles and 500 otherwise.
+func writeRoleServiceError(w http.ResponseWriter, err error) {
+	var notFoundError *repository.NotFoundError
+	var duplicateKeyError *repository.DuplicateKeyError
+	switch {
+	case errors.As(err, &notFoundError):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &duplicateKeyError):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Unable to process role request", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.RoleService.ListRoles(r.Context())
+	if err != nil {
+		writeRoleServiceError(w, err)
+		return
+	}
+
+	jsonData, err := json.Marshal(response_dto.ConvertRoleDbRows(roles))
+	if err != nil {
+		log.Errorf("Error marshalling json: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(jsonData)
+	if err != nil {
+		log.Errorf("Error writing response: %v", err)
+		return
+	}
+}
+
+func (h *Handler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+		return
+	}
+
+	var createRoleDto request_dto.CreateRoleRequestDto
+	err := json.NewDecoder(r.Body).Decode(&createRoleDto)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if createRoleDto.Name == "" {
+		http.Error(w, "Role name is required", http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.RoleService.CreateRole(r.Context(), principal.ID, request_dto.MakeClientInfoFromRequest(r), createRoleDto.Name, createRoleDto.Description, createRoleDto.Permissions)
+	if err != nil {
+		writeRoleServiceError(w, err)
+		return
+	}
+
+	jsonData, err := json.Marshal(response_dto.ConvertRoleDbRow(role))
+	if err != nil {
+		log.Errorf("Error marshalling json: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_, err = w.Write(jsonData)
+	if err != nil {
+		log.Errorf("Error writing response: %v", err)
+		return
+	}
+}
+
+func (h *Handler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+		return
+	}
+
+	err := h.RoleService.DeleteRole(r.Context(), principal.ID, request_dto.MakeClientInfoFromRequest(r), mux.Vars(r)["name"])
+	if err != nil {
+		writeRoleServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetRolePermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, err := h.RoleService.GetRolePermissions(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		writeRoleServiceError(w, err)
+		return
+	}
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	jsonData, err := json.Marshal(permissions)
+	if err != nil {
+		log.Errorf("Error marshalling json: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(jsonData)
+	if err != nil {
+		log.Errorf("Error writing response: %v", err)
+		return
+	}
+}
+
+func (h *Handler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, err := h.RoleService.ListPermissions(r.Context())
+	if err != nil {
+		writeRoleServiceError(w, err)
+		return
+	}
+
+	jsonData, err := json.Marshal(response_dto.ConvertPermissionDbRows(permissions))
+	if err != nil {
+		log.Errorf("Error marshalling json: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(jsonData)
+	if err != nil {
+		log.Errorf("Error writing response: %v", err)
+		return
+	}
+}
+
+func (h *Handler) ListUserRoles(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	roles, err := h.RoleService.ListUserRoles(r.Context(), userId)
+	if err != nil {
+		writeRoleServiceError(w, err)
+		return
+	}
+
+	jsonData, err := json.Marshal(response_dto.ConvertRoleDbRows(roles))
+	if err != nil {
+		log.Errorf("Error marshalling json: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(jsonData)
+	if err != nil {
+		log.Errorf("Error writing response: %v", err)
+		return
+	}
+}
+
+func (h *Handler) AssignUserRole(w http.ResponseWriter, r *http.Request) {
+	principal, userId, ok := adminUserRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var assignRoleDto request_dto.AssignUserRoleRequestDto
+	err := json.NewDecoder(r.Body).Decode(&assignRoleDto)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.RoleService.AssignUserRole(r.Context(), principal.ID, request_dto.MakeClientInfoFromRequest(r), userId, assignRoleDto.Role)
+	if err != nil {
+		writeRoleServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) RevokeUserRole(w http.ResponseWriter, r *http.Request) {
+	principal, userId, ok := adminUserRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.RoleService.RevokeUserRole(r.Context(), principal.ID, request_dto.MakeClientInfoFromRequest(r), userId, mux.Vars(r)["role"])
+	if err != nil {
+		writeRoleServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
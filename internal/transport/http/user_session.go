@@ -0,0 +1,67 @@
+package http
+
+import (
+	"eau-de-go/internal/repository"
+	"eau-de-go/internal/transport/http/response_dto"
+	"eau-de-go/pkg/jwt_util"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+)
+
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.AppUserService.ListActiveSessions(r.Context(), principal.ID)
+	if err != nil {
+		http.Error(w, "Unable to list sessions", http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(response_dto.ConvertUserSessionDbRows(sessions))
+	if err != nil {
+		log.Errorf("Error marshalling json: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(jsonData)
+	if err != nil {
+		log.Errorf("Error writing response: %v", err)
+		return
+	}
+}
+
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	principal, ok := jwt_util.PrincipalFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+		return
+	}
+
+	sessionId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.AppUserService.RevokeSession(r.Context(), principal.ID, sessionId)
+	if err != nil {
+		var notFoundError *repository.NotFoundError
+		if errors.As(err, &notFoundError) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Unable to revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
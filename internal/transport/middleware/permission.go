@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"eau-de-go/pkg/jwt_util"
+	"net/http"
+)
+
+// RequirePermission only lets through requests of principals granted the permission,
+// it must run after JwtAuthMiddleware, e.g. on ProtectedRouter routes.
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := jwt_util.PrincipalFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+				return
+			}
+			if !principal.HasPermission(permission) {
+				http.Error(w, "Permission denied", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireStaff only lets through requests of staff members, it must run after JwtAuthMiddleware.
+func RequireStaff(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := jwt_util.PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Error parsing access token", http.StatusUnauthorized)
+			return
+		}
+		if !principal.IsStaff {
+			http.Error(w, "Permission denied", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,41 @@
+package email_util
+
+import (
+	"eau-de-go/settings"
+	"fmt"
+	"os"
+)
+
+const (
+	SmtpEmailBackend    = "smtp"
+	ConsoleEmailBackend = "console"
+	FileEmailBackend    = "file"
+	MemoryEmailBackend  = "memory"
+)
+
+// EmailBackend delivers a message, already encoded as bytes, to its recipients.
+type EmailBackend interface {
+	SendMail(from string, recipients []string, message []byte) error
+}
+
+// GetEmailBackendForBackend returns the email backend of the given name, configured from settings.
+func GetEmailBackendForBackend(backend string) (EmailBackend, error) {
+	switch backend {
+	case SmtpEmailBackend:
+		return NewSmtpBackend(SmtpBackendConfig{
+			Host:     settings.EmailHost,
+			Port:     settings.EmailPort,
+			Username: settings.EmailHostUser,
+			Password: settings.EmailHostPassword,
+			Security: settings.EmailSecurity,
+		})
+	case ConsoleEmailBackend:
+		return NewConsoleBackend(os.Stdout), nil
+	case FileEmailBackend:
+		return NewFileBackend(settings.EmailFilePath), nil
+	case MemoryEmailBackend:
+		return DefaultMemoryBackend, nil
+	default:
+		return nil, fmt.Errorf("unknown email backend: %s", backend)
+	}
+}
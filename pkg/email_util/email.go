@@ -2,64 +2,93 @@ package email_util
 
 import (
 	"eau-de-go/settings"
+	"errors"
 	"fmt"
-	"net/smtp"
-	"strings"
+	log "github.com/sirupsen/logrus"
+	"net/mail"
 )
 
 type EmailSender interface {
+	Send(message Message) error
 	SendSingleEmail(recipientEmail string, mailSubject string, mailBody string) error
 	SendMassEmail(recipientEmails []string, mailSubject string, mailBody string) error
+	SendTemplatedEmail(recipientEmail string, templateName string, data TemplateData) error
 }
 
 type emailSender struct {
-	EmailHost         string
-	EmailPort         string
-	EmailHostUser     string
-	EmailHostPassword string
+	Backend          EmailBackend
+	DefaultFromEmail string
 }
 
-// NewEmailSender creates a new EmailSender
+// NewEmailSender creates a new EmailSender, sending through the backend selected by EMAIL_BACKEND
 func NewEmailSender() *emailSender {
-	return &emailSender{
-		EmailHost:         settings.EmailHost,
-		EmailPort:         settings.EmailPort,
-		EmailHostUser:     settings.EmailHostUser,
-		EmailHostPassword: settings.EmailHostPassword,
+	backend, err := GetEmailBackendForBackend(settings.EmailBackend)
+	if err != nil {
+		log.Fatalf("Failed to get email backend: %s", err)
 	}
+	return NewEmailSenderForBackend(backend)
 }
 
-func (e *emailSender) makeMailBytes(toAddresses []string, mailSubject string, mailMessage string) []byte {
-	mail := fmt.Sprintf("Subject: %s\r\n\r\n%s", mailSubject, mailMessage)
-
-	if len(toAddresses) > 0 {
-		mail = fmt.Sprintf("To: %s\r\n", strings.Join(toAddresses, ", ")) + mail
+// NewEmailSenderForBackend creates a new EmailSender sending through the given backend
+func NewEmailSenderForBackend(backend EmailBackend) *emailSender {
+	return &emailSender{
+		Backend:          backend,
+		DefaultFromEmail: settings.DefaultFromEmail,
 	}
+}
 
-	return []byte(mail)
+// Send sends the message to its To, Cc and Bcc recipients, from DefaultFromEmail unless the message has a From address
+func (e *emailSender) Send(message Message) error {
+	if message.From == "" {
+		message.From = e.DefaultFromEmail
+	}
+	recipients, err := message.Recipients()
+	if err != nil {
+		return err
+	}
+	mailBytes, err := message.Bytes()
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(message.From)
+	if err != nil {
+		return &InvalidEmailError{Key: err.Error()}
+	}
+	return e.Backend.SendMail(from.Address, recipients, mailBytes)
 }
 
-// SendSingleEmail sends an email to a single user
+// SendSingleEmail sends a plain text email to a single user
 func (e *emailSender) SendSingleEmail(recipientEmail string, mailSubject string, mailBody string) error {
-
-	recipients := []string{recipientEmail}
-
-	mailBytes := e.makeMailBytes(recipients, mailSubject, mailBody)
-
-	fullServerAddress := e.EmailHost + ":" + e.EmailPort
-	auth := smtp.PlainAuth("", e.EmailHostUser, e.EmailHostPassword, e.EmailHost)
-	err := smtp.SendMail(fullServerAddress, auth, e.EmailHostUser, recipients, mailBytes)
-
-	return err
+	return e.Send(Message{
+		To:       []string{recipientEmail},
+		Subject:  mailSubject,
+		TextBody: mailBody,
+	})
 }
 
-// SendMassEmail sends an email to multiple users, where user emails are not included in the email body to avoid recipients from seeing each other's email addresses
+// SendMassEmail sends a plain text email to multiple users, as a separate message to each user
+// so that recipients do not see each other's email addresses, and a rejected recipient does not fail the delivery to the others
 func (e *emailSender) SendMassEmail(recipientEmails []string, mailSubject string, mailBody string) error {
-	mailBytes := e.makeMailBytes([]string{}, mailSubject, mailBody)
-
-	fullServerAddress := e.EmailHost + ":" + e.EmailPort
-	auth := smtp.PlainAuth("", e.EmailHostUser, e.EmailHostPassword, e.EmailHost)
-	err := smtp.SendMail(fullServerAddress, auth, e.EmailHostUser, recipientEmails, mailBytes)
+	var errs []error
+	for _, recipientEmail := range recipientEmails {
+		err := e.SendSingleEmail(recipientEmail, mailSubject, mailBody)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sending to %s: %w", recipientEmail, err))
+		}
+	}
+	return errors.Join(errs...)
+}
 
-	return err
+// SendTemplatedEmail renders the named email template and sends it to a single user as a multipart/alternative email
+func (e *emailSender) SendTemplatedEmail(recipientEmail string, templateName string, data TemplateData) error {
+	renderedEmail, err := RenderTemplate(templateName, data)
+	if err != nil {
+		return err
+	}
+	return e.Send(Message{
+		To:       []string{recipientEmail},
+		Subject:  renderedEmail.Subject,
+		TextBody: renderedEmail.TextBody,
+		HtmlBody: renderedEmail.HtmlBody,
+	})
 }
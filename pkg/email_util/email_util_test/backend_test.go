@@ -0,0 +1,169 @@
+package email_util_test
+
+import (
+	"bufio"
+	"bytes"
+	"eau-de-go/pkg/email_util"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmailSenderMemoryBackend(t *testing.T) {
+	backend := email_util.NewMemoryBackend()
+	sender := email_util.NewEmailSenderForBackend(backend)
+
+	err := sender.Send(email_util.Message{
+		From:     "Eau de Go <noreply@example.com>",
+		To:       []string{"to@example.com"},
+		Bcc:      []string{"bcc@example.com"},
+		Subject:  "Subject",
+		TextBody: "Body",
+	})
+	assert.NoError(t, err)
+
+	outbox := backend.Outbox()
+	assert.Len(t, outbox, 1)
+	assert.Equal(t, "noreply@example.com", outbox[0].From)
+	assert.Equal(t, []string{"to@example.com", "bcc@example.com"}, outbox[0].Recipients)
+	message, err := outbox[0].Message()
+	assert.NoError(t, err)
+	assert.Equal(t, "Subject", message.Header.Get("Subject"))
+
+	backend.Clear()
+	assert.Empty(t, backend.Outbox())
+}
+
+func TestEmailSenderMassEmailMemoryBackend(t *testing.T) {
+	backend := email_util.NewMemoryBackend()
+	sender := email_util.NewEmailSenderForBackend(backend)
+	sender.DefaultFromEmail = "noreply@example.com"
+
+	err := sender.SendMassEmail([]string{"a@example.com", "b@example.com"}, "Subject", "Body")
+	assert.NoError(t, err)
+
+	outbox := backend.Outbox()
+	assert.Len(t, outbox, 2)
+	assert.Equal(t, []string{"a@example.com"}, outbox[0].Recipients)
+	assert.Equal(t, []string{"b@example.com"}, outbox[1].Recipients)
+}
+
+func TestFileBackend(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "emails")
+	backend := email_util.NewFileBackend(dir)
+
+	assert.NoError(t, backend.SendMail("from@example.com", []string{"to@example.com"}, []byte("Subject: One\r\n\r\nBody")))
+	assert.NoError(t, backend.SendMail("from@example.com", []string{"to@example.com"}, []byte("Subject: Two\r\n\r\nBody")))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+	content, err := os.ReadFile(files[0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "Body")
+}
+
+func TestConsoleBackend(t *testing.T) {
+	var output bytes.Buffer
+	backend := email_util.NewConsoleBackend(&output)
+
+	assert.NoError(t, backend.SendMail("from@example.com", []string{"to@example.com"}, []byte("Subject: Hello\r\n\r\nBody")))
+
+	assert.Contains(t, output.String(), "Subject: Hello")
+}
+
+func TestGetEmailBackendForBackend(t *testing.T) {
+	backend, err := email_util.GetEmailBackendForBackend(email_util.MemoryEmailBackend)
+	assert.NoError(t, err)
+	assert.Same(t, email_util.DefaultMemoryBackend, backend)
+
+	_, err = email_util.GetEmailBackendForBackend("carrier-pigeon")
+	assert.Error(t, err)
+}
+
+func TestNewSmtpBackendUnknownSecurity(t *testing.T) {
+	_, err := email_util.NewSmtpBackend(email_util.SmtpBackendConfig{Host: "localhost", Port: "25", Security: "ssl"})
+	assert.Error(t, err)
+}
+
+// serveSmtp accepts a single connection on a local port and answers as a minimal SMTP server without STARTTLS
+// or AUTH support, returning the address and a channel receiving the transcript of commands and data.
+func serveSmtp(t *testing.T) (string, string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	transcript := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var received strings.Builder
+		defer func() { transcript <- received.String() }()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			received.WriteString(line)
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					reply("250 OK")
+				}
+				continue
+			}
+			switch command := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(command, "EHLO"):
+				reply("250 localhost")
+			case command == "DATA":
+				inData = true
+				reply("354 Go ahead")
+			case command == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	return host, port, transcript
+}
+
+func TestSmtpBackendWithoutSecurityOrAuth(t *testing.T) {
+	host, port, transcript := serveSmtp(t)
+	backend, err := email_util.NewSmtpBackend(email_util.SmtpBackendConfig{Host: host, Port: port, Security: email_util.SmtpSecurityNone})
+	assert.NoError(t, err)
+
+	err = backend.SendMail("from@example.com", []string{"to@example.com"}, []byte("Subject: Hello\r\n\r\nBody\r\n"))
+	assert.NoError(t, err)
+
+	received := <-transcript
+	assert.Contains(t, received, "MAIL FROM:<from@example.com>")
+	assert.Contains(t, received, "RCPT TO:<to@example.com>")
+	assert.Contains(t, received, "Subject: Hello")
+	assert.NotContains(t, received, "AUTH")
+}
+
+func TestSmtpBackendRequiresStartTls(t *testing.T) {
+	host, port, transcript := serveSmtp(t)
+	backend, err := email_util.NewSmtpBackend(email_util.SmtpBackendConfig{Host: host, Port: port, Security: email_util.SmtpSecurityStartTls})
+	assert.NoError(t, err)
+
+	err = backend.SendMail("from@example.com", []string{"to@example.com"}, []byte("Subject: Hello\r\n\r\nBody\r\n"))
+	assert.ErrorContains(t, err, "STARTTLS")
+
+	assert.NotContains(t, <-transcript, "MAIL FROM")
+}
@@ -0,0 +1,150 @@
+package email_util_test
+
+import (
+	"bytes"
+	"eau-de-go/pkg/email_util"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readMessage(t *testing.T, message email_util.Message) *mail.Message {
+	messageBytes, err := message.Bytes()
+	assert.NoError(t, err)
+	parsed, err := mail.ReadMessage(bytes.NewReader(messageBytes))
+	assert.NoError(t, err)
+	return parsed
+}
+
+func TestMessageHeaders(t *testing.T) {
+	defer func() { email_util.NowFunc = time.Now }()
+	email_util.NowFunc = func() time.Time {
+		return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	}
+
+	parsed := readMessage(t, email_util.Message{
+		From:     "Eau de Go <noreply@example.com>",
+		ReplyTo:  []string{"support@example.com"},
+		To:       []string{"Zoë <zoe@example.com>"},
+		Cc:       []string{"cc@example.com"},
+		Bcc:      []string{"bcc@example.com"},
+		Subject:  "Bienvenue à bord",
+		TextBody: "Hello",
+		Headers:  map[string]string{"X-Campaign": "welcome"},
+	})
+
+	from, err := parsed.Header.AddressList("From")
+	assert.NoError(t, err)
+	assert.Equal(t, "noreply@example.com", from[0].Address)
+	assert.Equal(t, "Eau de Go", from[0].Name)
+
+	to, err := parsed.Header.AddressList("To")
+	assert.NoError(t, err)
+	assert.Equal(t, "Zoë", to[0].Name)
+
+	assert.Equal(t, "<support@example.com>", parsed.Header.Get("Reply-To"))
+	assert.Equal(t, "<cc@example.com>", parsed.Header.Get("Cc"))
+	assert.Empty(t, parsed.Header.Get("Bcc"), "Expected Bcc recipients to be left out of the headers")
+	assert.Equal(t, "welcome", parsed.Header.Get("X-Campaign"))
+	assert.Equal(t, "1.0", parsed.Header.Get("MIME-Version"))
+	assert.True(t, strings.HasSuffix(parsed.Header.Get("Message-ID"), "@example.com>"))
+
+	date, err := parsed.Header.Date()
+	assert.NoError(t, err)
+	assert.True(t, date.Equal(email_util.NowFunc()))
+
+	assert.NotContains(t, parsed.Header.Get("Subject"), "à", "Expected non-ASCII subject to be encoded")
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Bienvenue à bord", subject)
+}
+
+func TestMessageRecipients(t *testing.T) {
+	message := email_util.Message{
+		To:  []string{"Jane <jane@example.com>"},
+		Cc:  []string{"cc@example.com"},
+		Bcc: []string{"bcc@example.com"},
+	}
+	recipients, err := message.Recipients()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"jane@example.com", "cc@example.com", "bcc@example.com"}, recipients)
+
+	_, err = (&email_util.Message{}).Recipients()
+	assert.Error(t, err)
+}
+
+func TestMessageAlternativeBody(t *testing.T) {
+	parsed := readMessage(t, email_util.Message{
+		From:     "noreply@example.com",
+		To:       []string{"jane@example.com"},
+		Subject:  "Hello",
+		TextBody: "Hello Jane",
+		HtmlBody: "<p>Hello Jane</p>",
+	})
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	textPart, err := reader.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=UTF-8", textPart.Header.Get("Content-Type"))
+	text, _ := io.ReadAll(textPart)
+	assert.Equal(t, "Hello Jane", string(text))
+
+	htmlPart, err := reader.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "text/html; charset=UTF-8", htmlPart.Header.Get("Content-Type"))
+	html, _ := io.ReadAll(htmlPart)
+	assert.Equal(t, "<p>Hello Jane</p>", string(html))
+}
+
+func TestMessageAttachments(t *testing.T) {
+	parsed := readMessage(t, email_util.Message{
+		From:        "noreply@example.com",
+		To:          []string{"jane@example.com"},
+		Subject:     "Report",
+		TextBody:    "See attached",
+		Attachments: []email_util.Attachment{{Filename: "report.csv", Data: []byte(strings.Repeat("a,b,c\n", 50))}},
+	})
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	bodyPart, err := reader.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=UTF-8", bodyPart.Header.Get("Content-Type"))
+
+	attachmentPart, err := reader.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "report.csv", attachmentPart.FileName())
+	assert.Contains(t, attachmentPart.Header.Get("Content-Type"), "text/csv")
+	// Attachments are base64 encoded in lines of at most 76 characters
+	encoded, _ := io.ReadAll(attachmentPart)
+	for _, line := range strings.Split(strings.TrimSpace(string(encoded)), "\r\n") {
+		assert.LessOrEqual(t, len(line), 76)
+	}
+}
+
+func TestMessageRejectsHeaderInjection(t *testing.T) {
+	_, err := (&email_util.Message{
+		From:     "noreply@example.com",
+		To:       []string{"jane@example.com"},
+		TextBody: "Hello",
+		Headers:  map[string]string{"X-Custom": "value\r\nBcc: victim@example.com"},
+	}).Bytes()
+	assert.Error(t, err)
+}
+
+func TestMessageRequiresBody(t *testing.T) {
+	_, err := (&email_util.Message{From: "noreply@example.com", To: []string{"jane@example.com"}}).Bytes()
+	assert.Error(t, err)
+}
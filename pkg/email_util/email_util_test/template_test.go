@@ -0,0 +1,70 @@
+package email_util_test
+
+import (
+	"eau-de-go/pkg/email_util"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	for _, name := range []string{
+		email_util.VerificationEmailTemplate,
+		email_util.PasswordResetEmailTemplate,
+		email_util.WelcomeEmailTemplate,
+		email_util.SecurityAlertEmailTemplate,
+		email_util.EmailChangeEmailTemplate,
+		email_util.AccountLockedEmailTemplate,
+	} {
+		renderedEmail, err := email_util.RenderTemplate(name, email_util.TemplateData{
+			Name:      "Jane",
+			Link:      "https://example.com/link?token=abc",
+			ExpiresIn: time.Hour,
+			Event:     "The password of your account was changed.",
+		})
+		assert.NoError(t, err, "Expected %s template to render", name)
+		assert.NotEmpty(t, renderedEmail.Subject)
+		assert.NotContains(t, renderedEmail.Subject, "\n")
+		assert.Contains(t, renderedEmail.TextBody, "Hi Jane,")
+		assert.Contains(t, renderedEmail.HtmlBody, "Hi Jane,")
+		assert.Contains(t, renderedEmail.HtmlBody, "<title>"+renderedEmail.Subject+"</title>")
+	}
+}
+
+func TestRenderTemplateVerification(t *testing.T) {
+	renderedEmail, err := email_util.RenderTemplate(email_util.VerificationEmailTemplate, email_util.TemplateData{
+		Link:      "https://example.com/verify-email?token=abc",
+		ExpiresIn: 12 * time.Hour,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Verify your email address", renderedEmail.Subject)
+	assert.True(t, strings.HasPrefix(renderedEmail.TextBody, "Hi,"))
+	assert.Contains(t, renderedEmail.TextBody, "https://example.com/verify-email?token=abc")
+	assert.Contains(t, renderedEmail.TextBody, "12 hours")
+	assert.Contains(t, renderedEmail.HtmlBody, `href="https://example.com/verify-email?token=abc"`)
+}
+
+func TestRenderTemplateEscapesHtml(t *testing.T) {
+	renderedEmail, err := email_util.RenderTemplate(email_util.WelcomeEmailTemplate, email_util.TemplateData{
+		Name: "<script>alert(1)</script>",
+	})
+	assert.NoError(t, err)
+	assert.NotContains(t, renderedEmail.HtmlBody, "<script>")
+}
+
+func TestRenderTemplateUnknown(t *testing.T) {
+	_, err := email_util.RenderTemplate("unknown", email_util.TemplateData{})
+	assert.Error(t, err)
+}
+
+func TestFrontendUrl(t *testing.T) {
+	link, err := email_util.FrontendUrl("/reset-password", url.Values{"token": {"a+b/c"}})
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(link)
+	assert.NoError(t, err)
+	assert.Equal(t, "/reset-password", parsed.Path)
+	assert.Equal(t, "a+b/c", parsed.Query().Get("token"))
+}
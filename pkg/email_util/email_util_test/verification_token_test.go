@@ -0,0 +1,30 @@
+package email_util_test
+
+import (
+	"eau-de-go/pkg/email_util"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestNewVerificationToken(t *testing.T) {
+	token, tokenHash, err := email_util.NewVerificationToken()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.Equal(t, url.QueryEscape(token), token, "Expected token to be URL safe")
+	assert.Equal(t, email_util.HashVerificationToken(token), tokenHash)
+	assert.Len(t, tokenHash, 64)
+}
+
+func TestNewVerificationTokenIsRandom(t *testing.T) {
+	token, _, err := email_util.NewVerificationToken()
+	assert.NoError(t, err)
+	otherToken, _, err := email_util.NewVerificationToken()
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, otherToken)
+}
+
+func TestHashVerificationTokenDiffersFromToken(t *testing.T) {
+	assert.NotEqual(t, "token", email_util.HashVerificationToken("token"))
+	assert.Equal(t, email_util.HashVerificationToken("token"), email_util.HashVerificationToken("token"))
+}
@@ -0,0 +1,51 @@
+package email_util
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Console backend, for local development. Messages are written to the writer instead of being sent.
+type consoleBackend struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func NewConsoleBackend(writer io.Writer) *consoleBackend {
+	return &consoleBackend{writer: writer}
+}
+
+func (b *consoleBackend) SendMail(from string, recipients []string, message []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, err := fmt.Fprintf(b.writer, "%s\n%s\n", message, strings.Repeat("-", 79))
+	return err
+}
+
+// File backend, for local development. Each message is written to a .eml file in the directory,
+// which can be opened with a mail client.
+type fileBackend struct {
+	dir string
+}
+
+func NewFileBackend(dir string) *fileBackend {
+	return &fileBackend{dir: dir}
+}
+
+func (b *fileBackend) SendMail(from string, recipients []string, message []byte) error {
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		return err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	filename := fmt.Sprintf("%s-%s.eml", NowFunc().UTC().Format("20060102-150405"), hex.EncodeToString(suffix))
+	return os.WriteFile(filepath.Join(b.dir, filename), message, 0o644)
+}
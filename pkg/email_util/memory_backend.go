@@ -0,0 +1,57 @@
+package email_util
+
+import (
+	"bytes"
+	"net/mail"
+	"sync"
+)
+
+// SentEmail is a message captured by the in-memory backend.
+type SentEmail struct {
+	From       string
+	Recipients []string
+	Data       []byte
+}
+
+// Message parses the captured message, to inspect its headers and body.
+func (e SentEmail) Message() (*mail.Message, error) {
+	return mail.ReadMessage(bytes.NewReader(e.Data))
+}
+
+// MemoryBackend captures messages instead of sending them, for tests.
+type MemoryBackend struct {
+	mu     sync.Mutex
+	outbox []SentEmail
+}
+
+// DefaultMemoryBackend is the backend used when EMAIL_BACKEND is memory.
+var DefaultMemoryBackend = NewMemoryBackend()
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{}
+}
+
+func (b *MemoryBackend) SendMail(from string, recipients []string, message []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.outbox = append(b.outbox, SentEmail{
+		From:       from,
+		Recipients: append([]string(nil), recipients...),
+		Data:       append([]byte(nil), message...),
+	})
+	return nil
+}
+
+// Outbox returns the messages captured so far, in the order they were sent.
+func (b *MemoryBackend) Outbox() []SentEmail {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]SentEmail(nil), b.outbox...)
+}
+
+// Clear discards the captured messages.
+func (b *MemoryBackend) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.outbox = nil
+}
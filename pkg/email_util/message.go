@@ -0,0 +1,298 @@
+package email_util
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+var NowFunc = time.Now
+
+// Attachment is a file attached to a Message.
+type Attachment struct {
+	Filename string
+	// ContentType defaults to the type of the filename's extension, or application/octet-stream.
+	ContentType string
+	Data        []byte
+}
+
+// Message is an email with a plain text and/or an HTML body and optional attachments.
+// Addresses may include a display name, e.g. "Jane Doe <jane@example.com>".
+type Message struct {
+	From        string
+	ReplyTo     []string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	Subject     string
+	TextBody    string
+	HtmlBody    string
+	Headers     map[string]string
+	Attachments []Attachment
+}
+
+// Recipients returns the envelope recipients of the message, including Bcc recipients.
+func (m *Message) Recipients() ([]string, error) {
+	var recipients []string
+	for _, addresses := range [][]string{m.To, m.Cc, m.Bcc} {
+		parsed, err := parseAddresses(addresses)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range parsed {
+			recipients = append(recipients, address.Address)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("message has no recipients")
+	}
+	return recipients, nil
+}
+
+// Bytes renders the message in the Internet Message Format, with a Date, Message-ID and MIME headers.
+// Bcc recipients are left out of the headers, and non-ASCII subjects and display names are RFC 2047 encoded.
+func (m *Message) Bytes() ([]byte, error) {
+	if m.TextBody == "" && m.HtmlBody == "" {
+		return nil, errors.New("message has no body")
+	}
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, &InvalidEmailError{Key: err.Error()}
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("From", from.String())
+	for name, addresses := range map[string][]string{"Reply-To": m.ReplyTo, "To": m.To, "Cc": m.Cc} {
+		if len(addresses) == 0 {
+			continue
+		}
+		parsed, err := parseAddresses(addresses)
+		if err != nil {
+			return nil, err
+		}
+		header.Set(name, formatAddresses(parsed))
+	}
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header.Set("Date", NowFunc().Format(time.RFC1123Z))
+	messageId, err := newMessageId(from.Address)
+	if err != nil {
+		return nil, err
+	}
+	header.Set("Message-ID", messageId)
+	header.Set("MIME-Version", "1.0")
+	for name, value := range m.Headers {
+		header.Set(name, value)
+	}
+	for name, values := range header {
+		for _, value := range values {
+			if strings.ContainsAny(name+value, "\r\n") {
+				return nil, fmt.Errorf("invalid header %s: line breaks are not allowed", name)
+			}
+		}
+	}
+
+	var body bytes.Buffer
+	bodyHeader, err := m.writeBody(&body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range bodyHeader {
+		header[name] = values
+	}
+
+	var message bytes.Buffer
+	writeHeader(&message, header)
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+// writeBody writes the MIME body of the message and returns the content headers of the body:
+// a single text part, a multipart/alternative of the text and HTML parts, wrapped in a multipart/mixed along with the attachments if any.
+func (m *Message) writeBody(w io.Writer) (textproto.MIMEHeader, error) {
+	if len(m.Attachments) == 0 {
+		return m.writeContent(w)
+	}
+
+	writer := multipart.NewWriter(w)
+	var content bytes.Buffer
+	contentHeader, err := m.writeContent(&content)
+	if err != nil {
+		return nil, err
+	}
+	part, err := writer.CreatePart(contentHeader)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(content.Bytes()); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range m.Attachments {
+		if err := writeAttachment(writer, attachment); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return textproto.MIMEHeader{
+		"Content-Type": {mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": writer.Boundary()})},
+	}, nil
+}
+
+func (m *Message) writeContent(w io.Writer) (textproto.MIMEHeader, error) {
+	if m.HtmlBody == "" {
+		return writeTextPart(w, "text/plain", m.TextBody)
+	}
+	if m.TextBody == "" {
+		return writeTextPart(w, "text/html", m.HtmlBody)
+	}
+
+	writer := multipart.NewWriter(w)
+	for _, alternative := range []struct {
+		mediaType string
+		content   string
+	}{
+		{"text/plain", m.TextBody},
+		{"text/html", m.HtmlBody},
+	} {
+		var content bytes.Buffer
+		partHeader, err := writeTextPart(&content, alternative.mediaType, alternative.content)
+		if err != nil {
+			return nil, err
+		}
+		part, err := writer.CreatePart(partHeader)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(content.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return textproto.MIMEHeader{
+		"Content-Type": {mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": writer.Boundary()})},
+	}, nil
+}
+
+func writeTextPart(w io.Writer, mediaType string, content string) (textproto.MIMEHeader, error) {
+	encoder := quotedprintable.NewWriter(w)
+	if _, err := encoder.Write([]byte(content)); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(mediaType, map[string]string{"charset": "UTF-8"})},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	}, nil
+}
+
+func writeAttachment(writer *multipart.Writer, attachment Attachment) error {
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(attachment.Filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Base64 lines must not exceed 76 characters
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	for len(encoded) > 76 {
+		if _, err := fmt.Fprintf(part, "%s\r\n", encoded[:76]); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = fmt.Fprintf(part, "%s\r\n", encoded)
+	return err
+}
+
+// headerOrder lists the well-known headers in the conventional order, they are written before any other header.
+var headerOrder = []string{"From", "Reply-To", "To", "Cc", "Subject", "Date", "Message-Id", "Mime-Version"}
+
+// headerNames maps the canonical form of header names to their conventional spelling.
+var headerNames = map[string]string{
+	"Message-Id":   "Message-ID",
+	"Mime-Version": "MIME-Version",
+}
+
+func writeHeader(w io.Writer, header textproto.MIMEHeader) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		if !slices.Contains(headerOrder, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range append(headerOrder, names...) {
+		headerName := name
+		if conventionalName, ok := headerNames[name]; ok {
+			headerName = conventionalName
+		}
+		for _, value := range header[name] {
+			fmt.Fprintf(w, "%s: %s\r\n", headerName, value)
+		}
+	}
+	fmt.Fprint(w, "\r\n")
+}
+
+func parseAddresses(addresses []string) ([]*mail.Address, error) {
+	parsed := make([]*mail.Address, 0, len(addresses))
+	for _, address := range addresses {
+		mailAddress, err := mail.ParseAddress(address)
+		if err != nil {
+			return nil, &InvalidEmailError{Key: err.Error()}
+		}
+		parsed = append(parsed, mailAddress)
+	}
+	return parsed, nil
+}
+
+func formatAddresses(addresses []*mail.Address) string {
+	formatted := make([]string, len(addresses))
+	for i, address := range addresses {
+		formatted[i] = address.String()
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// newMessageId returns a unique Message-ID in the domain of the sender's address.
+func newMessageId(fromAddress string) (string, error) {
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(fromAddress, "@"); at >= 0 {
+		domain = fromAddress[at+1:]
+	}
+	return fmt.Sprintf("<%d.%s@%s>", NowFunc().UnixNano(), hex.EncodeToString(randomBytes), domain), nil
+}
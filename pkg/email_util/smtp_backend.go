@@ -0,0 +1,116 @@
+package email_util
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// Connection security of the SMTP backend.
+const (
+	// SmtpSecurityStartTls upgrades the connection with STARTTLS, failing if the server does not support it.
+	SmtpSecurityStartTls = "starttls"
+	// SmtpSecurityTls connects with implicit TLS, usually on port 465.
+	SmtpSecurityTls = "tls"
+	// SmtpSecurityNone sends in plain text, for local mail servers only.
+	SmtpSecurityNone = "none"
+)
+
+var smtpDialTimeout = 30 * time.Second
+
+type SmtpBackendConfig struct {
+	Host string
+	Port string
+	// Username and Password are used for PLAIN authentication, authentication is skipped when Username is empty.
+	Username  string
+	Password  string
+	Security  string
+	TlsConfig *tls.Config
+}
+
+type smtpBackend struct {
+	config SmtpBackendConfig
+}
+
+func NewSmtpBackend(config SmtpBackendConfig) (*smtpBackend, error) {
+	switch config.Security {
+	case SmtpSecurityStartTls, SmtpSecurityTls, SmtpSecurityNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP security: %s", config.Security)
+	}
+	if config.TlsConfig == nil {
+		config.TlsConfig = &tls.Config{ServerName: config.Host}
+	}
+	return &smtpBackend{config: config}, nil
+}
+
+func (b *smtpBackend) dial() (*smtp.Client, error) {
+	address := net.JoinHostPort(b.config.Host, b.config.Port)
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+
+	var conn net.Conn
+	var err error
+	if b.config.Security == SmtpSecurityTls {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, b.config.TlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, b.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if b.config.Security == SmtpSecurityStartTls {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(b.config.TlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// SendMail sends the message over a new connection to the SMTP server
+func (b *smtpBackend) SendMail(from string, recipients []string, message []byte) error {
+	client, err := b.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if b.config.Username != "" {
+		auth := smtp.PlainAuth("", b.config.Username, b.config.Password, b.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
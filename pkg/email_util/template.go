@@ -0,0 +1,113 @@
+package email_util
+
+import (
+	"bytes"
+	"eau-de-go/settings"
+	"embed"
+	"fmt"
+	htmlTemplate "html/template"
+	"net/url"
+	"strings"
+	textTemplate "text/template"
+	"time"
+)
+
+const (
+	VerificationEmailTemplate  = "verification"
+	PasswordResetEmailTemplate = "password_reset"
+	WelcomeEmailTemplate       = "welcome"
+	SecurityAlertEmailTemplate = "security_alert"
+	EmailChangeEmailTemplate   = "email_change"
+	AccountLockedEmailTemplate = "account_locked"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// TemplateData is the data available to email templates.
+type TemplateData struct {
+	// Name is used to greet the recipient, the greeting is generic when empty.
+	Name string
+	// Link is the frontend link of the email's call to action.
+	Link string
+	// ExpiresIn is how long the link is valid for.
+	ExpiresIn time.Duration
+	// Event describes what happened to the account, for security alerts.
+	Event string
+}
+
+// RenderedEmail is an email template rendered as a subject with a plain text and an HTML body.
+type RenderedEmail struct {
+	Subject  string
+	TextBody string
+	HtmlBody string
+}
+
+type htmlTemplateData struct {
+	TemplateData
+	Subject string
+}
+
+var templateFuncs = map[string]interface{}{
+	"duration": formatDuration,
+}
+
+// RenderTemplate renders the named email template. Each template is made of templates/<name>.txt.tmpl, which also defines the subject,
+// and templates/<name>.html.tmpl, which defines the content of the HTML layout.
+func RenderTemplate(name string, data TemplateData) (RenderedEmail, error) {
+	textTmpl, err := textTemplate.New(name+".txt.tmpl").Funcs(templateFuncs).ParseFS(templateFiles, "templates/"+name+".txt.tmpl")
+	if err != nil {
+		return RenderedEmail{}, fmt.Errorf("unknown email template %s: %w", name, err)
+	}
+	var subject, textBody bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return RenderedEmail{}, err
+	}
+	if err := textTmpl.Execute(&textBody, data); err != nil {
+		return RenderedEmail{}, err
+	}
+
+	htmlTmpl, err := htmlTemplate.New(name+".html.tmpl").Funcs(templateFuncs).ParseFS(templateFiles, "templates/layout.html.tmpl", "templates/"+name+".html.tmpl")
+	if err != nil {
+		return RenderedEmail{}, fmt.Errorf("unknown email template %s: %w", name, err)
+	}
+	var htmlBody bytes.Buffer
+	if err := htmlTmpl.ExecuteTemplate(&htmlBody, "layout", htmlTemplateData{TemplateData: data, Subject: subject.String()}); err != nil {
+		return RenderedEmail{}, err
+	}
+
+	return RenderedEmail{
+		Subject:  strings.TrimSpace(subject.String()),
+		TextBody: textBody.String(),
+		HtmlBody: htmlBody.String(),
+	}, nil
+}
+
+// FrontendUrl builds a link to the path of the frontend at FRONTEND_BASE_URL, with the query parameters.
+func FrontendUrl(path string, query url.Values) (string, error) {
+	baseUrl, err := url.Parse(settings.FrontendBaseUrl)
+	if err != nil {
+		return "", err
+	}
+	link := baseUrl.JoinPath(path)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return pluralize(int(d/time.Hour), "hour")
+	case d >= time.Minute:
+		return pluralize(int(d/time.Minute), "minute")
+	default:
+		return pluralize(int(d/time.Second), "second")
+	}
+}
+
+func pluralize(count int, unit string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
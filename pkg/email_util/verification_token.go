@@ -0,0 +1,27 @@
+package email_util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const verificationTokenBytes = 32
+
+// NewVerificationToken generates a random, URL safe token to be emailed to the user, along with the hash of the token.
+// Only the hash is meant to be persisted, so that leaked token records cannot be used to verify an email address.
+func NewVerificationToken() (string, string, error) {
+	tokenBytes := make([]byte, verificationTokenBytes)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+	return token, HashVerificationToken(token), nil
+}
+
+// HashVerificationToken returns the hex encoded SHA-256 hash of the token, which is used to look up the persisted token.
+func HashVerificationToken(token string) string {
+	tokenHash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(tokenHash[:])
+}
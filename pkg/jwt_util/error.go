@@ -1,6 +1,10 @@
 package jwt_util
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+)
 
 type InvalidTokenError struct {
 	token string
@@ -13,3 +17,64 @@ func (e *InvalidTokenError) Error() string {
 	}
 	return "Invalid token."
 }
+
+type RevokedTokenError struct {
+	Jti string
+}
+
+func (e *RevokedTokenError) Error() string {
+	return "Token has been revoked."
+}
+
+type ExpiredTokenError struct{}
+
+func (e *ExpiredTokenError) Error() string {
+	return "Token has expired."
+}
+
+type TokenNotValidYetError struct{}
+
+func (e *TokenNotValidYetError) Error() string {
+	return "Token is not valid yet."
+}
+
+// InvalidSignatureError is returned when the token signature does not verify,
+// or the token is signed with an algorithm that is not allowed.
+type InvalidSignatureError struct {
+	msg string
+}
+
+func (e *InvalidSignatureError) Error() string {
+	return fmt.Sprintf("Invalid token signature: %s", e.msg)
+}
+
+type InvalidAudienceError struct{}
+
+func (e *InvalidAudienceError) Error() string {
+	return "Token audience is invalid."
+}
+
+type InvalidIssuerError struct{}
+
+func (e *InvalidIssuerError) Error() string {
+	return "Token issuer is invalid."
+}
+
+// newParseError maps a jwt parse error to the error types of this package.
+func newParseError(tokenString string, err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return &ExpiredTokenError{}
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return &TokenNotValidYetError{}
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return &InvalidSignatureError{msg: err.Error()}
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return &InvalidAudienceError{}
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return &InvalidIssuerError{}
+	default:
+		msg := err.Error()
+		return &InvalidTokenError{token: tokenString, msg: &msg}
+	}
+}
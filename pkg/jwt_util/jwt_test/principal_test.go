@@ -0,0 +1,70 @@
+package jwt_test
+
+import (
+	"context"
+	"eau-de-go/pkg/jwt_util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewPrincipal(t *testing.T) {
+	userId := uuid.New()
+	claims := map[string]interface{}{
+		"id":             userId.String(),
+		"username":       "testuser",
+		"email":          "testuser@example.com",
+		"first_name":     "Test",
+		"last_name":      "User",
+		"is_active":      true,
+		"is_staff":       false,
+		"email_verified": true,
+	}
+
+	principal, err := jwt_util.NewPrincipal(claims)
+	assert.NoError(t, err)
+	assert.Equal(t, userId, principal.ID)
+	assert.Equal(t, "testuser", principal.Username)
+	assert.Equal(t, "testuser@example.com", principal.Email)
+	assert.Equal(t, "Test", principal.FirstName)
+	assert.Equal(t, "User", principal.LastName)
+	assert.True(t, principal.IsActive)
+	assert.False(t, principal.IsStaff)
+	assert.True(t, principal.EmailVerified)
+	assert.Equal(t, claims, principal.Claims)
+}
+
+func TestNewPrincipalPermissions(t *testing.T) {
+	principal, err := jwt_util.NewPrincipal(map[string]interface{}{
+		"id":          uuid.New().String(),
+		"permissions": []interface{}{"users.read", "users.write"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"users.read", "users.write"}, principal.Permissions)
+	assert.True(t, principal.HasPermission("users.read"))
+	assert.False(t, principal.HasPermission("roles.manage"))
+
+	principal, err = jwt_util.NewPrincipal(map[string]interface{}{"id": uuid.New().String()})
+	assert.NoError(t, err)
+	assert.False(t, principal.HasPermission("users.read"))
+}
+
+func TestNewPrincipalInvalidId(t *testing.T) {
+	_, err := jwt_util.NewPrincipal(map[string]interface{}{"username": "testuser"})
+	assert.Error(t, err, "Expected error for missing id claim")
+
+	_, err = jwt_util.NewPrincipal(map[string]interface{}{"id": "invalid_uuid"})
+	assert.Error(t, err, "Expected error for invalid id claim")
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	_, ok := jwt_util.PrincipalFromContext(context.Background())
+	assert.False(t, ok, "Expected no principal in an empty context")
+
+	principal := &jwt_util.Principal{ID: uuid.New()}
+	ctx := jwt_util.ContextWithPrincipal(context.Background(), principal)
+
+	principalFromContext, ok := jwt_util.PrincipalFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, principal, principalFromContext)
+}
@@ -1,9 +1,15 @@
 package jwt_test
 
 import (
+	"context"
 	"eau-de-go/pkg/jwt_util"
+	"eau-de-go/pkg/keys"
 	"eau-de-go/settings"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -43,7 +49,7 @@ func TestDecodeToken(t *testing.T) {
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
-	decodedClaims, err := jwtUtil.DecodeToken(jwt_util.Refresh, token)
+	decodedClaims, err := jwtUtil.DecodeToken(context.Background(), jwt_util.Refresh, token)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -55,7 +61,7 @@ func TestDecodeToken(t *testing.T) {
 func TestDecodeInvalidToken(t *testing.T) {
 	jwtUtil := jwt_util.NewJwtUtil()
 
-	_, err := jwtUtil.DecodeToken(jwt_util.Refresh, "invalidToken")
+	_, err := jwtUtil.DecodeToken(context.Background(), jwt_util.Refresh, "invalidToken")
 	if err == nil {
 		t.Error("Expected error for invalid token")
 	}
@@ -76,9 +82,258 @@ func TestTokenExpiry(t *testing.T) {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
-	_, err = jwtUtil.DecodeToken(jwt_util.Refresh, token)
+	_, err = jwtUtil.DecodeToken(context.Background(), jwt_util.Refresh, token)
 	if err == nil {
 		t.Error("Expected error for expired token")
 	}
 	jwt_util.NowFunc = time.Now
 }
+
+type stubRevocationChecker struct {
+	revoked bool
+}
+
+func (c *stubRevocationChecker) IsRevoked(ctx context.Context, tokenType jwt_util.TokenType, jti string) (bool, error) {
+	return c.revoked, nil
+}
+
+func TestDecodeRevokedToken(t *testing.T) {
+	claims := map[string]interface{}{
+		"username": "testuser",
+	}
+
+	jwtUtil := jwt_util.NewJwtUtil()
+	jwtUtil.RevocationChecker = &stubRevocationChecker{revoked: true}
+
+	token, tokenClaims, err := jwtUtil.CreateRefreshToken(claims)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	_, err = jwtUtil.DecodeToken(context.Background(), jwt_util.Refresh, token)
+	var revokedTokenError *jwt_util.RevokedTokenError
+	assert.True(t, errors.As(err, &revokedTokenError), "Expected RevokedTokenError for revoked token")
+	assert.Equal(t, tokenClaims["jti"], revokedTokenError.Jti)
+}
+
+func TestDecodeNotRevokedToken(t *testing.T) {
+	claims := map[string]interface{}{
+		"username": "testuser",
+	}
+
+	jwtUtil := jwt_util.NewJwtUtil()
+	jwtUtil.RevocationChecker = &stubRevocationChecker{revoked: false}
+
+	token, _, err := jwtUtil.CreateRefreshToken(claims)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	_, err = jwtUtil.DecodeToken(context.Background(), jwt_util.Refresh, token)
+	assert.NoError(t, err)
+}
+
+func TestDecodeTokenAfterKeyRotation(t *testing.T) {
+	claims := map[string]interface{}{
+		"username": "testuser",
+	}
+
+	jwtUtil := jwt_util.NewJwtUtil()
+
+	token, _, err := jwtUtil.CreateRefreshToken(claims)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	_, err = jwtUtil.KeyStore.RotateKeyPair()
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	decodedClaims, err := jwtUtil.DecodeToken(context.Background(), jwt_util.Refresh, token)
+	assert.NoError(t, err, "Expected token signed with a retired key to still be valid")
+	assert.Equal(t, "testuser", decodedClaims["username"])
+}
+
+func TestCreateTokenSetsKid(t *testing.T) {
+	claims := map[string]interface{}{
+		"username": "testuser",
+	}
+
+	jwtUtil := jwt_util.NewJwtUtil()
+
+	tokenString, _, err := jwtUtil.CreateAccessToken(claims)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+	assert.NoError(t, err)
+
+	kid, _, err := jwtUtil.KeyStore.GetSigningKey()
+	assert.NoError(t, err)
+	assert.Equal(t, kid, token.Header["kid"])
+}
+
+func TestCreateTokenSigningAlgorithms(t *testing.T) {
+	claims := map[string]interface{}{
+		"username": "testuser",
+	}
+
+	for _, algorithm := range []string{keys.PS256, keys.ES256, keys.EdDSA} {
+		dir := t.TempDir()
+		jwtUtil := jwt_util.NewJwtUtil()
+		jwtUtil.KeyStore = keys.NewFileKeyStore(filepath.Join(dir, "jwt.pem"), filepath.Join(dir, "jwt.pub"), algorithm, true)
+		jwtUtil.Algorithms = []string{algorithm}
+
+		tokenString, _, err := jwtUtil.CreateAccessToken(claims)
+		assert.NoError(t, err)
+
+		token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+		assert.NoError(t, err)
+		assert.Equal(t, algorithm, token.Header["alg"])
+
+		decodedClaims, err := jwtUtil.DecodeToken(context.Background(), jwt_util.Access, tokenString)
+		assert.NoError(t, err, "Expected %s token to be valid", algorithm)
+		assert.Equal(t, "testuser", decodedClaims["username"])
+	}
+}
+
+func TestCreateTokenAfterSigningAlgorithmChange(t *testing.T) {
+	defer func(algorithm string) { settings.JwtSigningAlgorithm = algorithm }(settings.JwtSigningAlgorithm)
+	dir := t.TempDir()
+	signingKeyPath := filepath.Join(dir, "jwt.pem")
+	verificationKeyPath := filepath.Join(dir, "jwt.pub")
+	rsaKid, _, err := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.PS256, true).GetSigningKey()
+	assert.NoError(t, err)
+
+	settings.JwtSigningAlgorithm = keys.ES256
+	jwtUtil := jwt_util.NewJwtUtil()
+	jwtUtil.KeyStore = keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, settings.JwtSigningAlgorithm, false)
+
+	rsaToken, _, err := jwtUtil.CreateAccessToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+	token, _, err := jwt.NewParser().ParseUnverified(rsaToken, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, keys.PS256, token.Header["alg"], "Expected the active RSA key to keep signing with its own algorithm")
+	assert.Equal(t, rsaKid, token.Header["kid"])
+	_, err = jwtUtil.DecodeToken(context.Background(), jwt_util.Access, rsaToken)
+	assert.NoError(t, err, "Expected a token signed with the active RSA key to be valid")
+
+	_, err = jwtUtil.KeyStore.RotateKeyPair()
+	assert.NoError(t, err)
+	ecToken, _, err := jwtUtil.CreateAccessToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+	token, _, err = jwt.NewParser().ParseUnverified(ecToken, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, keys.ES256, token.Header["alg"], "Expected the rotated key to sign with the configured algorithm")
+
+	_, err = jwtUtil.DecodeToken(context.Background(), jwt_util.Access, ecToken)
+	assert.NoError(t, err)
+	_, err = jwtUtil.DecodeToken(context.Background(), jwt_util.Access, rsaToken)
+	assert.NoError(t, err, "Expected a token signed with the retired RSA key to remain valid")
+}
+
+func TestDecodeTokenAlgorithmDoesNotMatchKey(t *testing.T) {
+	dir := t.TempDir()
+	jwtUtil := jwt_util.NewJwtUtil()
+	jwtUtil.KeyStore = keys.NewFileKeyStore(filepath.Join(dir, "jwt.pem"), filepath.Join(dir, "jwt.pub"), keys.PS256, true)
+	kid, signingKey, err := jwtUtil.KeyStore.GetSigningKey()
+	assert.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"token_type": string(jwt_util.Access),
+		"exp":        time.Now().Add(time.Minute).Unix(),
+		"iat":        time.Now().Unix(),
+	})
+	token.Header["kid"] = kid
+	tokenString, err := token.SignedString(signingKey)
+	assert.NoError(t, err)
+
+	_, err = jwtUtil.DecodeToken(context.Background(), jwt_util.Access, tokenString)
+	var invalidTokenError *jwt_util.InvalidTokenError
+	assert.ErrorAs(t, err, &invalidTokenError, "Expected a token signed with another algorithm than its key's to be rejected")
+}
+
+func TestCreateTokenSetsIssuerAndAudience(t *testing.T) {
+	jwtUtil := jwt_util.NewJwtUtil()
+	jwtUtil.Issuer = "https://auth.example.com"
+	jwtUtil.Audience = "eau-de-go"
+
+	_, tokenClaims, err := jwtUtil.CreateAccessToken(map[string]interface{}{"iss": "spoofed", "aud": "spoofed"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://auth.example.com", tokenClaims["iss"])
+	assert.Equal(t, "eau-de-go", tokenClaims["aud"])
+	assert.NotNil(t, tokenClaims["nbf"], "Expected nbf to be set")
+}
+
+func TestDecodeTokenWrongAudience(t *testing.T) {
+	jwtUtil := jwt_util.NewJwtUtil()
+	jwtUtil.Audience = "another-service"
+	token, _, err := jwtUtil.CreateAccessToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+
+	jwtUtil.Audience = "eau-de-go"
+	_, err = jwtUtil.DecodeToken(context.Background(), jwt_util.Access, token)
+	var invalidAudienceError *jwt_util.InvalidAudienceError
+	assert.ErrorAs(t, err, &invalidAudienceError)
+}
+
+func TestDecodeTokenWrongIssuer(t *testing.T) {
+	jwtUtil := jwt_util.NewJwtUtil()
+	jwtUtil.Issuer = "another-issuer"
+	token, _, err := jwtUtil.CreateAccessToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+
+	jwtUtil.Issuer = "eau-de-go"
+	_, err = jwtUtil.DecodeToken(context.Background(), jwt_util.Access, token)
+	var invalidIssuerError *jwt_util.InvalidIssuerError
+	assert.ErrorAs(t, err, &invalidIssuerError)
+}
+
+func TestDecodeTokenExpiredError(t *testing.T) {
+	defer func() { jwt_util.NowFunc = time.Now }()
+	jwtUtil := jwt_util.NewJwtUtil()
+	jwtUtil.Leeway = 30 * time.Second
+
+	jwt_util.NowFunc = func() time.Time {
+		return time.Now().Add(-settings.AccessTokenLife - 10*time.Second)
+	}
+	token, _, err := jwtUtil.CreateAccessToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+	jwt_util.NowFunc = time.Now
+
+	_, err = jwtUtil.DecodeToken(context.Background(), jwt_util.Access, token)
+	assert.NoError(t, err, "Expected token expired within the leeway to be valid")
+
+	jwtUtil.Leeway = 0
+	_, err = jwtUtil.DecodeToken(context.Background(), jwt_util.Access, token)
+	var expiredTokenError *jwt_util.ExpiredTokenError
+	assert.ErrorAs(t, err, &expiredTokenError)
+}
+
+func TestDecodeTokenAlgorithmNotAllowed(t *testing.T) {
+	jwtUtil := jwt_util.NewJwtUtil()
+	token, _, err := jwtUtil.CreateAccessToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+
+	jwtUtil.Algorithms = []string{keys.ES256}
+	_, err = jwtUtil.DecodeToken(context.Background(), jwt_util.Access, token)
+	var invalidSignatureError *jwt_util.InvalidSignatureError
+	assert.ErrorAs(t, err, &invalidSignatureError)
+}
+
+func TestDecodeTokenBadSignature(t *testing.T) {
+	jwtUtil := jwt_util.NewJwtUtil()
+	token, _, err := jwtUtil.CreateAccessToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	tamperedClaims, _, err := jwtUtil.CreateAccessToken(map[string]interface{}{"username": "admin"})
+	assert.NoError(t, err)
+	tamperedToken := parts[0] + "." + strings.Split(tamperedClaims, ".")[1] + "." + parts[2]
+
+	_, err = jwtUtil.DecodeToken(context.Background(), jwt_util.Access, tamperedToken)
+	var invalidSignatureError *jwt_util.InvalidSignatureError
+	assert.ErrorAs(t, err, &invalidSignatureError)
+}
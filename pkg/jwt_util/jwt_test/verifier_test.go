@@ -0,0 +1,168 @@
+package jwt_test
+
+import (
+	"eau-de-go/pkg/jwt_util"
+	"eau-de-go/pkg/keys"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newJwksServer(t *testing.T, keyStore keys.KeyStore, requestCount *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requestCount, 1)
+		verificationKeys, err := keyStore.GetVerificationKeys()
+		assert.NoError(t, err)
+		_ = json.NewEncoder(w).Encode(keys.NewJwkSet(verificationKeys))
+	}))
+}
+
+func TestVerifyToken(t *testing.T) {
+	jwtUtil := jwt_util.NewJwtUtil()
+	var requestCount int32
+	server := newJwksServer(t, jwtUtil.KeyStore, &requestCount)
+	defer server.Close()
+
+	verifier := jwt_util.NewVerifier(jwt_util.VerifierConfig{JwksUrl: server.URL})
+
+	token, _, err := jwtUtil.CreateAccessToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+
+	claims, err := verifier.VerifyToken(jwt_util.Access, token)
+	assert.NoError(t, err)
+	assert.Equal(t, "testuser", claims["username"])
+
+	_, err = verifier.VerifyToken(jwt_util.Access, token)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requestCount), "Verification keys should be cached")
+}
+
+func TestVerifyTokenWrongTokenType(t *testing.T) {
+	jwtUtil := jwt_util.NewJwtUtil()
+	var requestCount int32
+	server := newJwksServer(t, jwtUtil.KeyStore, &requestCount)
+	defer server.Close()
+
+	verifier := jwt_util.NewVerifier(jwt_util.VerifierConfig{JwksUrl: server.URL})
+
+	token, _, err := jwtUtil.CreateRefreshToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+
+	_, err = verifier.VerifyToken(jwt_util.Access, token)
+	assert.Error(t, err, "Expected error for refresh token used as access token")
+}
+
+func TestVerifyTokenExpired(t *testing.T) {
+	jwtUtil := jwt_util.NewJwtUtil()
+	var requestCount int32
+	server := newJwksServer(t, jwtUtil.KeyStore, &requestCount)
+	defer server.Close()
+	defer func() { jwt_util.NowFunc = time.Now }()
+
+	verifier := jwt_util.NewVerifier(jwt_util.VerifierConfig{JwksUrl: server.URL})
+
+	jwt_util.NowFunc = func() time.Time {
+		return time.Now().Add(-time.Hour)
+	}
+	token, _, err := jwtUtil.CreateAccessToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+	jwt_util.NowFunc = time.Now
+
+	_, err = verifier.VerifyToken(jwt_util.Access, token)
+	assert.Error(t, err, "Expected error for expired token")
+}
+
+func TestVerifyTokenIssuerAndAudience(t *testing.T) {
+	jwtUtil := jwt_util.NewJwtUtil()
+	var requestCount int32
+	server := newJwksServer(t, jwtUtil.KeyStore, &requestCount)
+	defer server.Close()
+
+	verifier := jwt_util.NewVerifier(jwt_util.VerifierConfig{
+		JwksUrl:  server.URL,
+		Issuer:   "https://auth.example.com",
+		Audience: "orders",
+	})
+
+	jwtUtil.Issuer = "https://auth.example.com"
+	jwtUtil.Audience = "orders"
+	token, _, err := jwtUtil.CreateAccessToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+	_, err = verifier.VerifyToken(jwt_util.Access, token)
+	assert.NoError(t, err)
+
+	jwtUtil.Audience = "billing"
+	token, _, err = jwtUtil.CreateAccessToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+	_, err = verifier.VerifyToken(jwt_util.Access, token)
+	var invalidAudienceError *jwt_util.InvalidAudienceError
+	assert.ErrorAs(t, err, &invalidAudienceError, "Expected error for wrong audience")
+
+	jwtUtil.Issuer = "https://evil.example.com"
+	jwtUtil.Audience = "orders"
+	token, _, err = jwtUtil.CreateAccessToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+	_, err = verifier.VerifyToken(jwt_util.Access, token)
+	var invalidIssuerError *jwt_util.InvalidIssuerError
+	assert.ErrorAs(t, err, &invalidIssuerError, "Expected error for wrong issuer")
+}
+
+func TestVerifyTokenRefreshesOnUnknownKid(t *testing.T) {
+	jwtUtil := jwt_util.NewJwtUtil()
+	var requestCount int32
+	server := newJwksServer(t, jwtUtil.KeyStore, &requestCount)
+	defer server.Close()
+	defer func() { jwt_util.NowFunc = time.Now }()
+
+	verifier := jwt_util.NewVerifier(jwt_util.VerifierConfig{JwksUrl: server.URL})
+
+	token, _, err := jwtUtil.CreateAccessToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+	_, err = verifier.VerifyToken(jwt_util.Access, token)
+	assert.NoError(t, err)
+
+	_, err = jwtUtil.KeyStore.RotateKeyPair()
+	assert.NoError(t, err)
+	token, _, err = jwtUtil.CreateAccessToken(map[string]interface{}{"username": "testuser"})
+	assert.NoError(t, err)
+
+	jwt_util.NowFunc = func() time.Time {
+		return time.Now().Add(time.Minute)
+	}
+	_, err = verifier.VerifyToken(jwt_util.Access, token)
+	assert.NoError(t, err, "Expected key set to be refreshed for a token signed with a new key")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requestCount))
+}
+
+func TestVerifierJwtAuthMiddleware(t *testing.T) {
+	jwtUtil := jwt_util.NewJwtUtil()
+	var requestCount int32
+	server := newJwksServer(t, jwtUtil.KeyStore, &requestCount)
+	defer server.Close()
+
+	verifier := jwt_util.NewVerifier(jwt_util.VerifierConfig{JwksUrl: server.URL})
+	handler := verifier.JwtAuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := jwt_util.PrincipalFromContext(r.Context())
+		assert.True(t, ok)
+		_, _ = w.Write([]byte(principal.Username))
+	}))
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	token, _, err := jwtUtil.CreateAccessToken(map[string]interface{}{"id": uuid.New().String(), "username": "testuser"})
+	assert.NoError(t, err)
+	req, _ = http.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "testuser", rr.Body.String())
+}
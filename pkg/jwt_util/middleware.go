@@ -0,0 +1,50 @@
+package jwt_util
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+func GetBearerToken(r *http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return "", fmt.Errorf("authorization header not found")
+	}
+	splitToken := strings.Split(authHeader, "Bearer ")
+	if len(splitToken) != 2 {
+		return "", fmt.Errorf("Bearer token not found in authorization header")
+	}
+
+	accessTokenString := splitToken[1]
+	return accessTokenString, nil
+}
+
+// JwtAuthMiddleware verifies the bearer access token of the request and stores its Principal in the request context,
+// see PrincipalFromContext, responding with 401 Unauthorized when the token is missing or invalid.
+func (v *verifier) JwtAuthMiddleware(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		accessTokenString, err := GetBearerToken(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := v.VerifyToken(Access, accessTokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		principal, err := NewPrincipal(claims)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+		r = r.WithContext(ContextWithPrincipal(r.Context(), principal))
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,92 @@
+package jwt_util
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"slices"
+)
+
+// Principal is the authenticated user of a request, as described by the claims of its access token.
+type Principal struct {
+	ID            uuid.UUID
+	Username      string
+	Email         string
+	FirstName     string
+	LastName      string
+	IsActive      bool
+	IsStaff       bool
+	EmailVerified bool
+	// Permissions are the codenames of the permissions granted to the user by their roles.
+	Permissions []string
+	// Claims are all claims of the access token, including those not mapped to a field.
+	Claims map[string]interface{}
+}
+
+type principalContextKey struct{}
+
+// NewPrincipal maps the claims of an access token to a Principal, the id claim is required to be a valid uuid.
+func NewPrincipal(claims map[string]interface{}) (*Principal, error) {
+	idStr, ok := claims["id"].(string)
+	if !ok {
+		msg := "Missing id claim."
+		return nil, &InvalidTokenError{msg: &msg}
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		msg := "Invalid id claim."
+		return nil, &InvalidTokenError{msg: &msg}
+	}
+
+	principal := &Principal{ID: id, Claims: claims}
+	principal.Username, _ = claims["username"].(string)
+	principal.Email, _ = claims["email"].(string)
+	principal.FirstName, _ = claims["first_name"].(string)
+	principal.LastName, _ = claims["last_name"].(string)
+	principal.IsActive, _ = claims["is_active"].(bool)
+	principal.IsStaff, _ = claims["is_staff"].(bool)
+	principal.EmailVerified, _ = claims["email_verified"].(bool)
+	principal.Permissions = permissionsFromClaim(claims["permissions"])
+	return principal, nil
+}
+
+// permissionsFromClaim reads the permissions claim, a list of strings once the token is decoded.
+func permissionsFromClaim(claim interface{}) []string {
+	switch values := claim.(type) {
+	case []string:
+		return values
+	case []interface{}:
+		permissions := make([]string, 0, len(values))
+		for _, value := range values {
+			if permission, ok := value.(string); ok {
+				permissions = append(permissions, permission)
+			}
+		}
+		return permissions
+	default:
+		return nil
+	}
+}
+
+// HasPermission reports whether the principal was granted the permission.
+func (principal *Principal) HasPermission(permission string) bool {
+	return slices.Contains(principal.Permissions, permission)
+}
+
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal stored in the request context by the auth middleware.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// ClaimsFromContext returns the access token claims of the principal stored in the request context.
+func ClaimsFromContext(ctx context.Context) (map[string]interface{}, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return principal.Claims, true
+}
@@ -0,0 +1,107 @@
+package jwt_util
+
+import (
+	"crypto"
+	"eau-de-go/pkg/keys"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Cached keys are re-fetched after jwksCacheLifetime, or earlier when a token carries an unknown kid.
+// Re-fetching on unknown kid is limited to once per jwksMinRefreshInterval, so that tokens with made up
+// key ids cannot be used to flood the issuer with requests.
+var jwksCacheLifetime = time.Hour
+var jwksMinRefreshInterval = 30 * time.Second
+
+// remoteKey is a verification key of a remote key set, along with the algorithm advertised for it, if any.
+type remoteKey struct {
+	PublicKey crypto.PublicKey
+	Alg       string
+}
+
+// remoteKeySet fetches verification keys from a JSON Web Key Set published by the issuer and caches them in memory.
+type remoteKeySet struct {
+	mu         sync.Mutex
+	url        string
+	client     *http.Client
+	keys       map[string]remoteKey
+	fetchedAt  time.Time
+	attemptAt  time.Time
+	attempted  bool
+	fetchError error
+}
+
+func newRemoteKeySet(url string, client *http.Client) *remoteKeySet {
+	return &remoteKeySet{
+		url:    url,
+		client: client,
+		keys:   make(map[string]remoteKey),
+	}
+}
+
+func (s *remoteKeySet) GetVerificationKey(kid string) (remoteKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := NowFunc()
+	key, ok := s.keys[kid]
+	if ok && now.Sub(s.fetchedAt) < jwksCacheLifetime {
+		return key, nil
+	}
+
+	if !s.attempted || now.Sub(s.attemptAt) >= jwksMinRefreshInterval {
+		s.attempted = true
+		s.attemptAt = now
+		s.fetchError = s.fetch()
+		if s.fetchError == nil {
+			s.fetchedAt = now
+		}
+	}
+
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	if s.fetchError != nil {
+		return remoteKey{}, s.fetchError
+	}
+	return remoteKey{}, &keys.UnknownKeyError{Kid: kid}
+}
+
+func (s *remoteKeySet) fetch() error {
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		log.Errorf("Failed to fetch JWKS from %s: %s", s.url, err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Errorf("Failed to fetch JWKS from %s: %s", s.url, resp.Status)
+		return fmt.Errorf("unexpected JWKS response status: %s", resp.Status)
+	}
+
+	var jwkSet keys.JwkSet
+	if err := json.NewDecoder(resp.Body).Decode(&jwkSet); err != nil {
+		log.Errorf("Failed to decode JWKS from %s: %s", s.url, err)
+		return err
+	}
+
+	verificationKeys := make(map[string]remoteKey, len(jwkSet.Keys))
+	for _, jwk := range jwkSet.Keys {
+		if jwk.Kid == "" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		publicKey, err := keys.ParseJwk(jwk)
+		if err != nil {
+			log.Warnf("Skipping invalid JWK %s: %s", jwk.Kid, err)
+			continue
+		}
+		verificationKeys[jwk.Kid] = remoteKey{PublicKey: publicKey, Alg: jwk.Alg}
+	}
+	s.keys = verificationKeys
+	return nil
+}
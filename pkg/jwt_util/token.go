@@ -1,8 +1,10 @@
 package jwt_util
 
 import (
+	"context"
 	"eau-de-go/pkg/keys"
 	"eau-de-go/settings"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"time"
@@ -20,17 +22,36 @@ var NowFunc = time.Now
 type JwtUtil interface {
 	CreateRefreshToken(claims map[string]interface{}) (string, map[string]interface{}, error)
 	CreateAccessToken(claims map[string]interface{}) (string, map[string]interface{}, error)
-	DecodeToken(tokenType TokenType, tokenString string) (map[string]interface{}, error)
+	DecodeToken(ctx context.Context, tokenType TokenType, tokenString string) (map[string]interface{}, error)
 	CopyTokenClaims(claims map[string]interface{}) map[string]interface{}
 }
 
+// RevocationChecker reports whether a token identified by its jti has been revoked.
+// Implementations are expected to be backed by a persisted token store.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, tokenType TokenType, jti string) (bool, error)
+}
+
 type jwtUtil struct {
-	KeyStore keys.RsaKeyStore
+	KeyStore          keys.KeyStore
+	RevocationChecker RevocationChecker
+	// Issuer and Audience are set as the iss and aud claims of created tokens, and required of decoded tokens.
+	Issuer   string
+	Audience string
+	// Algorithms are the signing algorithms accepted when decoding tokens, in addition to the algorithm recorded for the key.
+	// When empty, tokens signed with the algorithm of any key in the key store are accepted.
+	Algorithms []string
+	// Leeway is the clock skew tolerated when validating exp, nbf and iat.
+	Leeway time.Duration
 }
 
 func NewJwtUtil() *jwtUtil {
 	return &jwtUtil{
-		KeyStore: keys.GetInMemoryRsaKeyStore(),
+		KeyStore:   keys.GetKeyStore(),
+		Issuer:     settings.JwtIssuer,
+		Audience:   settings.JwtAudience,
+		Algorithms: settings.JwtAllowedAlgorithms,
+		Leeway:     settings.JwtLeeway,
 	}
 }
 
@@ -38,15 +59,37 @@ func (j *jwtUtil) injectStandardClaims(claims map[string]interface{}) {
 
 	now := NowFunc()
 	claims["iat"] = now.Unix()
+	claims["nbf"] = now.Unix()
 	claims["jti"] = uuid.New().String()
+	if j.Issuer != "" {
+		claims["iss"] = j.Issuer
+	}
+	if j.Audience != "" {
+		claims["aud"] = j.Audience
+	}
 }
 
 func (j *jwtUtil) createToken(claims map[string]interface{}) (string, map[string]interface{}, error) {
 
+	kid, signingKey, err := j.KeyStore.GetSigningKey()
+	if err != nil {
+		return "", nil, err
+	}
+	// Keys are signed with the algorithm they were created for, which is JWT_SIGNING_ALGORITHM unless the key was created
+	// before the setting was changed.
+	verificationKey, err := j.KeyStore.GetVerificationKey(kid)
+	if err != nil {
+		return "", nil, err
+	}
+	signingMethod := jwt.GetSigningMethod(verificationKey.Algorithm)
+	if signingMethod == nil {
+		return "", nil, fmt.Errorf("unsupported signing algorithm: %s", verificationKey.Algorithm)
+	}
+
 	j.injectStandardClaims(claims)
-	token := jwt.NewWithClaims(jwt.SigningMethodPS256, jwt.MapClaims(claims))
+	token := jwt.NewWithClaims(signingMethod, jwt.MapClaims(claims))
+	token.Header["kid"] = kid
 
-	signingKey, err := j.KeyStore.GetSigningKey()
 	tokenString, err := token.SignedString(signingKey)
 	if err != nil {
 		return "", nil, err
@@ -55,21 +98,18 @@ func (j *jwtUtil) createToken(claims map[string]interface{}) (string, map[string
 	return tokenString, claims, nil
 }
 
-func (j *jwtUtil) DecodeToken(tokenType TokenType, tokenString string) (map[string]interface{}, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return j.KeyStore.GetVerificationKey()
-	})
+func (j *jwtUtil) DecodeToken(ctx context.Context, tokenType TokenType, tokenString string) (map[string]interface{}, error) {
+	token, err := j.newParser().Parse(tokenString, j.getVerificationKey)
 
 	if err != nil {
-		msg := err.Error()
-		return nil, &InvalidTokenError{token: tokenString, msg: &msg}
+		return nil, newParseError(tokenString, err)
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok {
 		if err := j.validateTokenTypes(claims, tokenType); err != nil {
 			return nil, err
 		}
-		if err := j.validateJti(claims); err != nil {
+		if err := j.validateJti(ctx, claims); err != nil {
 			return nil, err
 		}
 		return claims, nil
@@ -78,6 +118,46 @@ func (j *jwtUtil) DecodeToken(tokenType TokenType, tokenString string) (map[stri
 	}
 }
 
+func (j *jwtUtil) newParser() *jwt.Parser {
+	parserOptions := []jwt.ParserOption{
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(j.Leeway),
+	}
+	if len(j.Algorithms) > 0 {
+		parserOptions = append(parserOptions, jwt.WithValidMethods(j.Algorithms))
+	}
+	if j.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(j.Issuer))
+	}
+	if j.Audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(j.Audience))
+	}
+	return jwt.NewParser(parserOptions...)
+}
+
+// getVerificationKey selects the verification key by the kid header of the token, which must be signed with
+// the algorithm recorded for the key. Tokens without a kid header are verified against the active signing key.
+func (j *jwtUtil) getVerificationKey(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		signingKid, _, err := j.KeyStore.GetSigningKey()
+		if err != nil {
+			return nil, err
+		}
+		kid = signingKid
+	}
+	verificationKey, err := j.KeyStore.GetVerificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	if verificationKey.Algorithm != token.Method.Alg() {
+		msg := "Signing algorithm does not match the key."
+		return nil, &InvalidTokenError{msg: &msg}
+	}
+	return verificationKey.PublicKey, nil
+}
+
 func (j *jwtUtil) validateTokenTypes(claims map[string]interface{}, tokenType TokenType) error {
 	if claims["token_type"] != string(tokenType) {
 		msg := "Invalid token type."
@@ -86,7 +166,26 @@ func (j *jwtUtil) validateTokenTypes(claims map[string]interface{}, tokenType To
 	return nil
 }
 
-func (j *jwtUtil) validateJti(claims map[string]interface{}) error {
+func (j *jwtUtil) validateJti(ctx context.Context, claims map[string]interface{}) error {
+	if j.RevocationChecker == nil {
+		return nil
+	}
+
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		msg := "Missing jti."
+		return &InvalidTokenError{msg: &msg}
+	}
+
+	tokenType, _ := claims["token_type"].(string)
+	revoked, err := j.RevocationChecker.IsRevoked(ctx, TokenType(tokenType), jti)
+	if err != nil {
+		msg := err.Error()
+		return &InvalidTokenError{msg: &msg}
+	}
+	if revoked {
+		return &RevokedTokenError{Jti: jti}
+	}
 	return nil
 }
 
@@ -107,9 +206,17 @@ func (j *jwtUtil) CreateAccessToken(claims map[string]interface{}) (string, map[
 func (j *jwtUtil) CopyTokenClaims(claims map[string]interface{}) map[string]interface{} {
 	copiedClaimns := make(map[string]interface{})
 	for key, value := range claims {
-		if key != "exp" && key != "iat" && key != "jti" && key != "token_type" {
+		if !isStandardClaim(key) {
 			copiedClaimns[key] = value
 		}
 	}
 	return copiedClaimns
 }
+
+func isStandardClaim(key string) bool {
+	switch key {
+	case "exp", "iat", "nbf", "jti", "iss", "aud", "token_type":
+		return true
+	}
+	return false
+}
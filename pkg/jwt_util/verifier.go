@@ -0,0 +1,99 @@
+package jwt_util
+
+import (
+	"eau-de-go/pkg/keys"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"time"
+)
+
+// Verifier verifies tokens issued by eau-de-go in other services, using the verification keys published
+// at the JWKS endpoint of the issuer instead of sharing the key store.
+type Verifier interface {
+	VerifyToken(tokenType TokenType, tokenString string) (map[string]interface{}, error)
+	JwtAuthMiddleware(next http.Handler) http.Handler
+}
+
+type VerifierConfig struct {
+	// JwksUrl is the URL of the JWKS endpoint of the issuer, e.g. https://auth.example.com/.well-known/jwks.json
+	JwksUrl string
+	// Issuer and Audience are checked against the iss and aud claims when set.
+	Issuer   string
+	Audience string
+	// Leeway is the clock skew tolerated when validating exp, nbf and iat.
+	Leeway time.Duration
+	// Algorithms are the accepted signing algorithms, defaults to all algorithms supported by the key store.
+	// A token must also be signed with the algorithm advertised for its key in the key set.
+	Algorithms []string
+	HttpClient *http.Client
+}
+
+type verifier struct {
+	keySet *remoteKeySet
+	parser *jwt.Parser
+}
+
+func NewVerifier(config VerifierConfig) *verifier {
+	client := config.HttpClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	algorithms := config.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = []string{keys.PS256, keys.RS256, keys.ES256, keys.EdDSA}
+	}
+
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods(algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(config.Leeway),
+	}
+	if config.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(config.Audience))
+	}
+
+	return &verifier{
+		keySet: newRemoteKeySet(config.JwksUrl, client),
+		parser: jwt.NewParser(parserOptions...),
+	}
+}
+
+func (v *verifier) VerifyToken(tokenType TokenType, tokenString string) (map[string]interface{}, error) {
+	token, err := v.parser.Parse(tokenString, v.getVerificationKey)
+	if err != nil {
+		return nil, newParseError(tokenString, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, &InvalidTokenError{token: tokenString}
+	}
+	if claims["token_type"] != string(tokenType) {
+		msg := "Invalid token type."
+		return nil, &InvalidTokenError{token: tokenString, msg: &msg}
+	}
+	return claims, nil
+}
+
+// getVerificationKey selects the verification key by the kid header of the token,
+// tokens without a kid header cannot be verified against a remote key set.
+func (v *verifier) getVerificationKey(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		msg := "Missing kid header."
+		return nil, &InvalidTokenError{msg: &msg}
+	}
+	key, err := v.keySet.GetVerificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.Alg != "" && key.Alg != token.Method.Alg() {
+		msg := "Signing algorithm does not match the key."
+		return nil, &InvalidTokenError{msg: &msg}
+	}
+	return key.PublicKey, nil
+}
@@ -0,0 +1,173 @@
+package keys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"eau-de-go/settings"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const aesKeySize = 32
+
+// AesKeyRing encrypts with its primary key and decrypts with any of its keys.
+// The key id (kid) of the encrypting key is embedded in the ciphertext, so that a new primary key can be introduced
+// without invalidating existing ciphertexts, and an old key retired by removing it from the ring once nothing encrypted with it is in use.
+type AesKeyRing struct {
+	primaryKid string
+	ciphers    map[string]cipher.AEAD
+}
+
+// NewAesKeyRing creates a key ring from AES-128, AES-192 or AES-256 keys indexed by key id.
+func NewAesKeyRing(primaryKid string, aesKeys map[string][]byte) (*AesKeyRing, error) {
+	if _, ok := aesKeys[primaryKid]; !ok {
+		return nil, &UnknownKeyError{Kid: primaryKid}
+	}
+	ring := &AesKeyRing{
+		primaryKid: primaryKid,
+		ciphers:    make(map[string]cipher.AEAD, len(aesKeys)),
+	}
+	for kid, key := range aesKeys {
+		if kid == "" || len(kid) > 255 {
+			return nil, fmt.Errorf("invalid AES key id: %q", kid)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AES key %s: %w", kid, err)
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		ring.ciphers[kid] = gcm
+	}
+	return ring, nil
+}
+
+// PrimaryKid returns the id of the key used for encryption.
+func (ring *AesKeyRing) PrimaryKid() string {
+	return ring.primaryKid
+}
+
+// Encrypt seals the plaintext with AES-GCM using the primary key.
+// The ciphertext is laid out as kid length (1 byte), kid, nonce and sealed data, with the kid authenticated as additional data.
+func (ring *AesKeyRing) Encrypt(plaintext []byte) ([]byte, error) {
+	gcm := ring.ciphers[ring.primaryKid]
+	kid := []byte(ring.primaryKid)
+
+	header := make([]byte, 0, 1+len(kid)+gcm.NonceSize())
+	header = append(header, byte(len(kid)))
+	header = append(header, kid...)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	header = append(header, nonce...)
+
+	return gcm.Seal(header, nonce, plaintext, kid), nil
+}
+
+// Decrypt opens a ciphertext created by Encrypt with the key it was encrypted with.
+func (ring *AesKeyRing) Decrypt(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 1 || len(ciphertext) < 1+int(ciphertext[0]) {
+		return nil, errors.New("ciphertext too short")
+	}
+	kid := ciphertext[1 : 1+int(ciphertext[0])]
+	gcm, ok := ring.ciphers[string(kid)]
+	if !ok {
+		return nil, &UnknownKeyError{Kid: string(kid)}
+	}
+
+	data := ciphertext[1+len(kid):]
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, kid)
+}
+
+// GenerateAesKey returns a random AES-256 key.
+func GenerateAesKey() ([]byte, error) {
+	key := make([]byte, aesKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ParseAesKeyRing parses key ring entries of the form kid:base64-key, the first entry being the primary key.
+func ParseAesKeyRing(entries []string) (*AesKeyRing, error) {
+	var primaryKid string
+	aesKeys := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		kid, encodedKey, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, errors.New("AES key entries must be of the form kid:base64-key")
+		}
+		kid = strings.TrimSpace(kid)
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid AES key %s: %w", kid, err)
+		}
+		if _, ok := aesKeys[kid]; ok {
+			return nil, fmt.Errorf("duplicate AES key id: %s", kid)
+		}
+		if primaryKid == "" {
+			primaryKid = kid
+		}
+		aesKeys[kid] = key
+	}
+	if primaryKid == "" {
+		return nil, errors.New("AES key ring is empty")
+	}
+	return NewAesKeyRing(primaryKid, aesKeys)
+}
+
+// parseAesKeyRingFile parses a key ring file holding one kid:base64-key entry per line, ignoring blank lines and # comments.
+func parseAesKeyRingFile(data []byte) (*AesKeyRing, error) {
+	var entries []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			entries = append(entries, line)
+		}
+	}
+	return ParseAesKeyRing(entries)
+}
+
+// LoadAesKeyRingFile reads the key ring from a key ring file.
+func LoadAesKeyRingFile(path string) (*AesKeyRing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseAesKeyRingFile(data)
+}
+
+// GetAesKeyRingForBackend returns the AES key ring configured with AES_KEYS,
+// or else loaded from AES_KEY_RING_PATH of the key store backend, either file or s3.
+// The memory backend has no key ring of its own: a key generated on startup would be lost on restart and differ
+// between instances, so the outbox messages and cursors encrypted with it could not be decrypted.
+func GetAesKeyRingForBackend(backend string) (*AesKeyRing, error) {
+	if len(settings.AesKeys) > 0 {
+		return ParseAesKeyRing(settings.AesKeys)
+	}
+	switch backend {
+	case MemoryKeyStoreBackend:
+		return nil, errors.New("the memory key store backend has no persistent AES key ring, set AES_KEYS or use the file or s3 key store backend")
+	case FileKeyStoreBackend:
+		return LoadAesKeyRingFile(settings.AesKeyRingPath)
+	case S3KeyStoreBackend:
+		return LoadAwsS3AesKeyRing(AwsS3KeyStoreConfig{
+			Region:         settings.AwsS3KeyStoreRegion,
+			Endpoint:       settings.AwsS3KeyStoreEndpoint,
+			ForcePathStyle: settings.AwsS3KeyStoreForcePathStyle,
+			Bucket:         settings.AwsS3KeyStoreBucket,
+		}, settings.AesKeyRingPath)
+	default:
+		return nil, fmt.Errorf("unknown key store backend: %s", backend)
+	}
+}
@@ -0,0 +1,58 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+)
+
+// Supported JWT signing algorithms, as named by RFC 7518 and RFC 8037.
+const (
+	PS256 = "PS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+const rsaKeySize = 2048
+
+// GenerateSigningKey creates a new private key for the signing algorithm.
+func GenerateSigningKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case PS256, RS256:
+		return rsa.GenerateKey(rand.Reader, rsaKeySize)
+	case ES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case EdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+}
+
+// AlgorithmForKey returns the signing algorithm to use with the key. The preferred algorithm is used when it fits the key,
+// which lets RSA keys be used with either PS256 or RS256, otherwise the algorithm is implied by the key type,
+// so keys created before a change of algorithm keep their own algorithm until they are rotated out.
+func AlgorithmForKey(publicKey crypto.PublicKey, preferredAlgorithm string) (string, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if preferredAlgorithm == RS256 {
+			return RS256, nil
+		}
+		return PS256, nil
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return "", fmt.Errorf("unsupported elliptic curve: %s", key.Curve.Params().Name)
+		}
+		return ES256, nil
+	case ed25519.PublicKey:
+		return EdDSA, nil
+	default:
+		return "", fmt.Errorf("unsupported key type: %T", publicKey)
+	}
+}
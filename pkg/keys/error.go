@@ -0,0 +1,11 @@
+package keys
+
+import "fmt"
+
+type UnknownKeyError struct {
+	Kid string
+}
+
+func (e *UnknownKeyError) Error() string {
+	return fmt.Sprintf("Unknown key id: %s", e.Kid)
+}
@@ -0,0 +1,235 @@
+package keys
+
+import (
+	"crypto"
+	"eau-de-go/settings"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// File key store, for deployments with keys provisioned on local disk, e.g. from a mounted secret.
+// The active private key is read from JwtSigningKeyPath as a PKCS#8 PEM, or a PKCS#1 (RSA) or SEC 1 (ECDSA) PEM, and the public keys of the active
+// and retired key pairs from JwtVerificationKeyPath as PKIX PEM blocks. The verification key file is optional,
+// the public key of the signing key is always trusted. Missing keys are generated on first access when enabled.
+// The files are read again whenever they change, so that keys rotated by another process, e.g. cmd/rotate-keys, are picked up.
+type fileKeyStore struct {
+	mu     sync.Mutex
+	ring   *keyRing
+	loaded bool
+	// signingKeyInfo and verificationKeyInfo describe the key files as they were last read or written.
+	signingKeyInfo      fs.FileInfo
+	verificationKeyInfo fs.FileInfo
+	signingKeyPath      string
+	verificationKeyPath string
+	algorithm           string
+	generateMissingKeys bool
+}
+
+var fileKeyStoreInstance KeyStore
+var fileKeyStoreOnce sync.Once
+
+func GetFileKeyStore() KeyStore {
+	fileKeyStoreOnce.Do(func() {
+		fileKeyStoreInstance = NewFileKeyStore(settings.JwtSigningKeyPath, settings.JwtVerificationKeyPath, settings.JwtSigningAlgorithm, settings.JwtGenerateMissingKeys)
+	})
+	return fileKeyStoreInstance
+}
+
+func NewFileKeyStore(signingKeyPath string, verificationKeyPath string, algorithm string, generateMissingKeys bool) KeyStore {
+	return &fileKeyStore{
+		ring:                newKeyRing(settings.RefreshTokenLife, algorithm),
+		signingKeyPath:      signingKeyPath,
+		verificationKeyPath: verificationKeyPath,
+		algorithm:           algorithm,
+		generateMissingKeys: generateMissingKeys,
+	}
+}
+
+// RotateKeyPair creates a new key pair and writes it to the key files as the active key pair,
+// keeping the previous public keys in the verification key file.
+func (keyStore *fileKeyStore) RotateKeyPair() (string, error) {
+	keyStore.mu.Lock()
+	defer keyStore.mu.Unlock()
+
+	// The files are read again even when loaded, so that keys added by another process are kept in the rewritten files.
+	if err := keyStore.readFiles(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	return keyStore.rotateKeyPair()
+}
+
+func (keyStore *fileKeyStore) rotateKeyPair() (string, error) {
+	signingKey, err := GenerateSigningKey(keyStore.algorithm)
+	if err != nil {
+		log.Errorf("Failed to create private key: %s", err)
+		return "", err
+	}
+	kid, err := keyStore.ring.activate(signingKey, keyStore.algorithm)
+	if err != nil {
+		return "", err
+	}
+	if err := keyStore.writeFiles(); err != nil {
+		log.Errorf("Failed to write key pair: %s", err)
+		return "", err
+	}
+	keyStore.loaded = true
+	log.Infof("Created new key pair %s", kid)
+	return kid, nil
+}
+
+// ensureLoaded reads the key files on first access, and again whenever they changed since they were last read.
+// A failed re-read keeps the cached keys in use.
+func (keyStore *fileKeyStore) ensureLoaded() error {
+	keyStore.mu.Lock()
+	defer keyStore.mu.Unlock()
+	if keyStore.loaded {
+		if !keyStore.filesChanged() {
+			return nil
+		}
+		if err := keyStore.readFiles(); err != nil {
+			log.Warnf("Failed to reload keys from %s, using cached keys: %s", keyStore.signingKeyPath, err)
+		}
+		return nil
+	}
+
+	err := keyStore.readFiles()
+	if errors.Is(err, fs.ErrNotExist) && keyStore.generateMissingKeys {
+		log.Warnf("No signing key found at %s, creating the first key pair", keyStore.signingKeyPath)
+		_, err = keyStore.rotateKeyPair()
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	keyStore.loaded = true
+	return nil
+}
+
+// filesChanged reports whether either key file was replaced or modified since it was last read or written.
+func (keyStore *fileKeyStore) filesChanged() bool {
+	return fileChanged(keyStore.signingKeyPath, keyStore.signingKeyInfo) || fileChanged(keyStore.verificationKeyPath, keyStore.verificationKeyInfo)
+}
+
+func fileChanged(path string, info fs.FileInfo) bool {
+	currentInfo, err := os.Stat(path)
+	if err != nil || info == nil {
+		// The file was created or removed.
+		return (err == nil) == (info == nil)
+	}
+	return !os.SameFile(info, currentInfo) || !info.ModTime().Equal(currentInfo.ModTime()) || info.Size() != currentInfo.Size()
+}
+
+// statFiles records the key files as they are now, the files are statted before they are read,
+// so that a change made while reading them is picked up by the next filesChanged.
+func (keyStore *fileKeyStore) statFiles() {
+	keyStore.signingKeyInfo, _ = os.Stat(keyStore.signingKeyPath)
+	keyStore.verificationKeyInfo, _ = os.Stat(keyStore.verificationKeyPath)
+}
+
+func (keyStore *fileKeyStore) readFiles() error {
+	keyStore.statFiles()
+	privateKeyPem, err := os.ReadFile(keyStore.signingKeyPath)
+	if err != nil {
+		log.Errorf("Failed to read private key: %s", err)
+		return err
+	}
+	privateKey, err := decodePrivateKeyPem(privateKeyPem)
+	if err != nil {
+		log.Errorf("Failed to parse private key: %s", err)
+		return err
+	}
+
+	var verificationKeys []*verificationKeyEntry
+	publicKeyPem, err := os.ReadFile(keyStore.verificationKeyPath)
+	if err == nil {
+		verificationKeys, err = decodeVerificationKeyBundlePem(publicKeyPem)
+		if err != nil {
+			log.Errorf("Failed to parse public key: %s", err)
+			return err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		log.Errorf("Failed to read public key: %s", err)
+		return err
+	}
+
+	return keyStore.ring.load(privateKey, verificationKeys)
+}
+
+func (keyStore *fileKeyStore) writeFiles() error {
+	kid, signingKey, err := keyStore.ring.getSigningKey()
+	if err != nil {
+		return err
+	}
+	publicKeyBundle, err := encodeVerificationKeyBundlePem(kid, keyStore.ring.getVerificationKeys())
+	if err != nil {
+		log.Errorf("Failed to marshal public keys: %s", err)
+		return err
+	}
+
+	privateKeyPem, err := encodePrivateKeyPem(signingKey)
+	if err != nil {
+		log.Errorf("Failed to marshal private key: %s", err)
+		return err
+	}
+
+	if err := writeFileAtomically(keyStore.signingKeyPath, privateKeyPem, 0600); err != nil {
+		return err
+	}
+	if err := writeFileAtomically(keyStore.verificationKeyPath, publicKeyBundle, 0644); err != nil {
+		return err
+	}
+	keyStore.statFiles()
+	return nil
+}
+
+// writeFileAtomically writes the file through a temporary file in the same directory, so that readers never observe
+// a partially written key, and the file never exists with permissions wider than perm.
+func writeFileAtomically(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := tmpFile.Chmod(perm); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
+
+func (keyStore *fileKeyStore) GetSigningKey() (string, crypto.Signer, error) {
+	if err := keyStore.ensureLoaded(); err != nil {
+		return "", nil, err
+	}
+	return keyStore.ring.getSigningKey()
+}
+
+func (keyStore *fileKeyStore) GetVerificationKey(kid string) (VerificationKey, error) {
+	if err := keyStore.ensureLoaded(); err != nil {
+		return VerificationKey{}, err
+	}
+	return keyStore.ring.getVerificationKey(kid)
+}
+
+func (keyStore *fileKeyStore) GetVerificationKeys() (map[string]VerificationKey, error) {
+	if err := keyStore.ensureLoaded(); err != nil {
+		return nil, err
+	}
+	return verificationKeysOf(keyStore.ring.getVerificationKeys()), nil
+}
@@ -0,0 +1,171 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"math/big"
+	"sort"
+)
+
+// Jwk is a JSON Web Key as defined by RFC 7517, carrying the public parameters of a verification key.
+type Jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JwkSet is a JSON Web Key Set as defined by RFC 7517.
+type JwkSet struct {
+	Keys []Jwk `json:"keys"`
+}
+
+func NewJwk(kid string, publicKey crypto.PublicKey, alg string) (Jwk, error) {
+	jwk := Jwk{Use: "sig", Alg: alg, Kid: kid}
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return Jwk{}, fmt.Errorf("unsupported elliptic curve: %s", key.Curve.Params().Name)
+		}
+		jwk.Kty = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	default:
+		return Jwk{}, fmt.Errorf("unsupported key type: %T", publicKey)
+	}
+	return jwk, nil
+}
+
+// ParseJwk returns the public key described by the JWK.
+func ParseJwk(jwk Jwk) (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("invalid RSA public key parameters")
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported elliptic curve: %s", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, fmt.Errorf("invalid EC public key parameters")
+		}
+		return publicKey, nil
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve: %s", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", jwk.Kty)
+	}
+}
+
+// NewJwkSet builds a key set of the verification keys, ordered by key id so the output is stable.
+// Each key is advertised with the algorithm recorded for it, which it keeps when the configured algorithm changes.
+func NewJwkSet(verificationKeys map[string]VerificationKey) JwkSet {
+	kids := make([]string, 0, len(verificationKeys))
+	for kid := range verificationKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwkSet := JwkSet{Keys: make([]Jwk, 0, len(kids))}
+	for _, kid := range kids {
+		jwk, err := NewJwk(kid, verificationKeys[kid].PublicKey, verificationKeys[kid].Algorithm)
+		if err != nil {
+			log.Warnf("Skipping verification key %s: %s", kid, err)
+			continue
+		}
+		jwkSet.Keys = append(jwkSet.Keys, jwk)
+	}
+	return jwkSet
+}
+
+// KeyId returns the RFC 7638 JWK thumbprint of the public key, which is used as the key id (kid).
+// Deriving the key id from the key itself means it never needs to be persisted alongside the key.
+func KeyId(publicKey crypto.PublicKey) (string, error) {
+	jwk, err := NewJwk("", publicKey, "")
+	if err != nil {
+		return "", err
+	}
+
+	// The thumbprint covers only the required members of the key type, in lexicographic order.
+	var thumbprintInput []byte
+	switch jwk.Kty {
+	case "RSA":
+		thumbprintInput, err = json.Marshal(struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{E: jwk.E, Kty: jwk.Kty, N: jwk.N})
+	case "EC":
+		thumbprintInput, err = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{Crv: jwk.Crv, Kty: jwk.Kty, X: jwk.X, Y: jwk.Y})
+	case "OKP":
+		thumbprintInput, err = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{Crv: jwk.Crv, Kty: jwk.Kty, X: jwk.X})
+	}
+	if err != nil {
+		return "", err
+	}
+	thumbprint := sha256.Sum256(thumbprintInput)
+	return base64.RawURLEncoding.EncodeToString(thumbprint[:]), nil
+}
@@ -0,0 +1,171 @@
+package keys
+
+import (
+	"crypto"
+	"errors"
+	"sync"
+	"time"
+)
+
+var NowFunc = time.Now
+
+// VerificationKey is the public key of a signing key, along with the algorithm the signing key signs with.
+type VerificationKey struct {
+	PublicKey crypto.PublicKey
+	Algorithm string
+}
+
+type verificationKeyEntry struct {
+	PublicKey crypto.PublicKey
+	// Algorithm is empty for keys stored before algorithms were recorded, see keyRing.algorithmFor.
+	Algorithm string
+	RetiredAt time.Time
+}
+
+// keyRing keeps the active signing key along with the verification keys of recently retired signing keys,
+// so that tokens signed before a rotation can still be verified until they expire.
+type keyRing struct {
+	mu               sync.RWMutex
+	signingKid       string
+	signingKey       crypto.Signer
+	verificationKeys map[string]*verificationKeyEntry
+	retention        time.Duration
+	// algorithm is the configured signing algorithm, assumed for stored keys without a recorded algorithm when it fits the key.
+	algorithm string
+}
+
+func newKeyRing(retention time.Duration, algorithm string) *keyRing {
+	return &keyRing{
+		verificationKeys: make(map[string]*verificationKeyEntry),
+		retention:        retention,
+		algorithm:        algorithm,
+	}
+}
+
+func (ring *keyRing) hasSigningKey() bool {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	return ring.signingKey != nil
+}
+
+// activate makes the key the active signing key for the algorithm, retiring the previously active one,
+// and drops expired retired keys.
+func (ring *keyRing) activate(signingKey crypto.Signer, algorithm string) (string, error) {
+	kid, err := KeyId(signingKey.Public())
+	if err != nil {
+		return "", err
+	}
+
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	now := NowFunc()
+	if ring.signingKey != nil && ring.signingKid != kid {
+		ring.verificationKeys[ring.signingKid].RetiredAt = now
+	}
+	ring.signingKid = kid
+	ring.signingKey = signingKey
+	ring.verificationKeys[kid] = &verificationKeyEntry{PublicKey: signingKey.Public(), Algorithm: algorithm}
+
+	for verificationKid, verificationKey := range ring.verificationKeys {
+		if ring.isExpired(verificationKey, now) {
+			delete(ring.verificationKeys, verificationKid)
+		}
+	}
+	return kid, nil
+}
+
+// load activates the stored signing key and replaces the verification keys with the stored ones, so that a key
+// removed from the store, e.g. a compromised key being revoked, is no longer trusted.
+// Keys stored as active other than the signing key, e.g. while another instance is rotating, are kept as retired keys.
+func (ring *keyRing) load(signingKey crypto.Signer, verificationKeys []*verificationKeyEntry) error {
+	signingKid, err := KeyId(signingKey.Public())
+	if err != nil {
+		return err
+	}
+
+	now := NowFunc()
+	signingKeyEntry := &verificationKeyEntry{PublicKey: signingKey.Public()}
+	loadedKeys := map[string]*verificationKeyEntry{signingKid: signingKeyEntry}
+	for _, verificationKey := range verificationKeys {
+		kid, err := KeyId(verificationKey.PublicKey)
+		if err != nil {
+			return err
+		}
+		if kid == signingKid {
+			signingKeyEntry.Algorithm = verificationKey.Algorithm
+			continue
+		}
+		retiredAt := verificationKey.RetiredAt
+		if retiredAt.IsZero() {
+			retiredAt = now
+		}
+		algorithm, err := ring.algorithmFor(verificationKey)
+		if err != nil {
+			return err
+		}
+		loadedKey := &verificationKeyEntry{PublicKey: verificationKey.PublicKey, Algorithm: algorithm, RetiredAt: retiredAt}
+		if !ring.isExpired(loadedKey, now) {
+			loadedKeys[kid] = loadedKey
+		}
+	}
+	signingKeyEntry.Algorithm, err = ring.algorithmFor(signingKeyEntry)
+	if err != nil {
+		return err
+	}
+
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	ring.signingKid = signingKid
+	ring.signingKey = signingKey
+	ring.verificationKeys = loadedKeys
+	return nil
+}
+
+// algorithmFor returns the algorithm recorded for the stored key, or for keys stored before algorithms were recorded,
+// the configured algorithm if it fits the key and otherwise the algorithm implied by the key type.
+func (ring *keyRing) algorithmFor(verificationKey *verificationKeyEntry) (string, error) {
+	if verificationKey.Algorithm != "" {
+		return verificationKey.Algorithm, nil
+	}
+	return AlgorithmForKey(verificationKey.PublicKey, ring.algorithm)
+}
+
+func (ring *keyRing) isExpired(verificationKey *verificationKeyEntry, now time.Time) bool {
+	return !verificationKey.RetiredAt.IsZero() && now.After(verificationKey.RetiredAt.Add(ring.retention))
+}
+
+func (ring *keyRing) getSigningKey() (string, crypto.Signer, error) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	if ring.signingKey == nil {
+		return "", nil, errors.New("no signing key available")
+	}
+	return ring.signingKid, ring.signingKey, nil
+}
+
+func (ring *keyRing) getVerificationKey(kid string) (VerificationKey, error) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	verificationKey, ok := ring.verificationKeys[kid]
+	if !ok || ring.isExpired(verificationKey, NowFunc()) {
+		return VerificationKey{}, &UnknownKeyError{Kid: kid}
+	}
+	return VerificationKey{PublicKey: verificationKey.PublicKey, Algorithm: verificationKey.Algorithm}, nil
+}
+
+func (ring *keyRing) getVerificationKeys() map[string]*verificationKeyEntry {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	now := NowFunc()
+	verificationKeys := make(map[string]*verificationKeyEntry)
+	for kid, verificationKey := range ring.verificationKeys {
+		if !ring.isExpired(verificationKey, now) {
+			verificationKeys[kid] = &verificationKeyEntry{PublicKey: verificationKey.PublicKey, Algorithm: verificationKey.Algorithm, RetiredAt: verificationKey.RetiredAt}
+		}
+	}
+	return verificationKeys
+}
@@ -0,0 +1,132 @@
+package keys
+
+import (
+	"crypto"
+	"eau-de-go/settings"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"sync"
+)
+
+// KeyStore holds the active signing key, identified by its key id (kid),
+// as well as the verification keys of retired signing keys until they are aged out.
+// New signing keys are created for the signing algorithm the key store is configured with,
+// and every key keeps the algorithm it was created for, which is recorded along with its verification key.
+type KeyStore interface {
+	RotateKeyPair() (string, error)
+	GetSigningKey() (string, crypto.Signer, error)
+	GetVerificationKey(kid string) (VerificationKey, error)
+	GetVerificationKeys() (map[string]VerificationKey, error)
+}
+
+const (
+	MemoryKeyStoreBackend = "memory"
+	FileKeyStoreBackend   = "file"
+	S3KeyStoreBackend     = "s3"
+)
+
+// GetKeyStoreForBackend returns the key store of the backend, one of memory, file or s3.
+func GetKeyStoreForBackend(backend string) (KeyStore, error) {
+	switch backend {
+	case MemoryKeyStoreBackend:
+		return GetInMemoryKeyStore(), nil
+	case FileKeyStoreBackend:
+		return GetFileKeyStore(), nil
+	case S3KeyStoreBackend:
+		return GetAwsS3KeyStore()
+	default:
+		return nil, fmt.Errorf("unknown key store backend: %s", backend)
+	}
+}
+
+// GetKeyStore returns the key store of the backend selected by KEY_STORE_BACKEND.
+func GetKeyStore() KeyStore {
+	keyStore, err := GetKeyStoreForBackend(settings.KeyStoreBackend)
+	if err != nil {
+		log.Fatalf("Failed to get key store: %s", err)
+	}
+	return keyStore
+}
+
+// In-memory key store, for monolithic deployment and development.
+// Key pair is generated on first access and kept only in memory.
+type inMemoryKeyStore struct {
+	mu        sync.Mutex
+	ring      *keyRing
+	algorithm string
+}
+
+var inMemoryKeyStoreInstance *inMemoryKeyStore
+var inMemoryKeyStoreOnce sync.Once
+
+func GetInMemoryKeyStore() KeyStore {
+	inMemoryKeyStoreOnce.Do(func() {
+		inMemoryKeyStoreInstance = &inMemoryKeyStore{
+			ring:      newKeyRing(settings.RefreshTokenLife, settings.JwtSigningAlgorithm),
+			algorithm: settings.JwtSigningAlgorithm,
+		}
+	})
+	return inMemoryKeyStoreInstance
+}
+
+func (keyStore *inMemoryKeyStore) RotateKeyPair() (string, error) {
+	keyStore.mu.Lock()
+	defer keyStore.mu.Unlock()
+	return keyStore.rotateKeyPair()
+}
+
+func (keyStore *inMemoryKeyStore) rotateKeyPair() (string, error) {
+	signingKey, err := GenerateSigningKey(keyStore.algorithm)
+	if err != nil {
+		log.Errorf("Failed to create private key: %s", err)
+		return "", err
+	}
+	kid, err := keyStore.ring.activate(signingKey, keyStore.algorithm)
+	if err != nil {
+		return "", err
+	}
+	log.Infof("Created new key pair %s", kid)
+	return kid, nil
+}
+
+func (keyStore *inMemoryKeyStore) ensureSigningKey() error {
+	if keyStore.ring.hasSigningKey() {
+		return nil
+	}
+	keyStore.mu.Lock()
+	defer keyStore.mu.Unlock()
+	if keyStore.ring.hasSigningKey() {
+		return nil
+	}
+	_, err := keyStore.rotateKeyPair()
+	return err
+}
+
+func (keyStore *inMemoryKeyStore) GetSigningKey() (string, crypto.Signer, error) {
+	if err := keyStore.ensureSigningKey(); err != nil {
+		return "", nil, err
+	}
+	return keyStore.ring.getSigningKey()
+}
+
+func (keyStore *inMemoryKeyStore) GetVerificationKey(kid string) (VerificationKey, error) {
+	if err := keyStore.ensureSigningKey(); err != nil {
+		return VerificationKey{}, err
+	}
+	return keyStore.ring.getVerificationKey(kid)
+}
+
+func (keyStore *inMemoryKeyStore) GetVerificationKeys() (map[string]VerificationKey, error) {
+	if err := keyStore.ensureSigningKey(); err != nil {
+		return nil, err
+	}
+	return verificationKeysOf(keyStore.ring.getVerificationKeys()), nil
+}
+
+func verificationKeysOf(verificationKeyEntries map[string]*verificationKeyEntry) map[string]VerificationKey {
+	verificationKeys := make(map[string]VerificationKey, len(verificationKeyEntries))
+	for kid, verificationKey := range verificationKeyEntries {
+		verificationKeys[kid] = VerificationKey{PublicKey: verificationKey.PublicKey, Algorithm: verificationKey.Algorithm}
+	}
+	return verificationKeys
+}
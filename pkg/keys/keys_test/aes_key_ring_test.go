@@ -0,0 +1,127 @@
+package keys_test
+
+import (
+	"eau-de-go/pkg/keys"
+	"eau-de-go/settings"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestAesKeyEntry(t *testing.T, kid string) string {
+	key, err := keys.GenerateAesKey()
+	assert.NoError(t, err)
+	return kid + ":" + base64.StdEncoding.EncodeToString(key)
+}
+
+func TestAesKeyRing_EncryptDecrypt(t *testing.T) {
+	ring, err := keys.ParseAesKeyRing([]string{newTestAesKeyEntry(t, "2024-01")})
+	assert.NoError(t, err)
+
+	ciphertext, err := ring.Encrypt([]byte("secret"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), "secret")
+
+	plaintext, err := ring.Decrypt(ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+}
+
+func TestAesKeyRing_DecryptsWithRetiredKey(t *testing.T) {
+	oldEntry := newTestAesKeyEntry(t, "2024-01")
+	oldRing, err := keys.ParseAesKeyRing([]string{oldEntry})
+	assert.NoError(t, err)
+	ciphertext, err := oldRing.Encrypt([]byte("secret"))
+	assert.NoError(t, err)
+
+	rotatedRing, err := keys.ParseAesKeyRing([]string{newTestAesKeyEntry(t, "2024-06"), oldEntry})
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-06", rotatedRing.PrimaryKid())
+
+	plaintext, err := rotatedRing.Decrypt(ciphertext)
+	assert.NoError(t, err, "Expected ciphertext of a previous primary key to be decrypted")
+	assert.Equal(t, "secret", string(plaintext))
+
+	retiredRing, err := keys.ParseAesKeyRing([]string{newTestAesKeyEntry(t, "2024-06")})
+	assert.NoError(t, err)
+	_, err = retiredRing.Decrypt(ciphertext)
+	var unknownKeyError *keys.UnknownKeyError
+	assert.ErrorAs(t, err, &unknownKeyError)
+	assert.Equal(t, "2024-01", unknownKeyError.Kid)
+}
+
+func TestAesKeyRing_TamperedCiphertext(t *testing.T) {
+	ring, err := keys.ParseAesKeyRing([]string{newTestAesKeyEntry(t, "2024-01")})
+	assert.NoError(t, err)
+	ciphertext, err := ring.Encrypt([]byte("secret"))
+	assert.NoError(t, err)
+
+	ciphertext[len(ciphertext)-1] ^= 0xff
+	_, err = ring.Decrypt(ciphertext)
+	assert.Error(t, err)
+
+	_, err = ring.Decrypt([]byte{200})
+	assert.Error(t, err)
+}
+
+func TestParseAesKeyRing_InvalidEntries(t *testing.T) {
+	_, err := keys.ParseAesKeyRing(nil)
+	assert.Error(t, err, "Expected empty key ring to be rejected")
+
+	_, err = keys.ParseAesKeyRing([]string{"no-separator"})
+	assert.Error(t, err)
+
+	_, err = keys.ParseAesKeyRing([]string{"short:" + base64.StdEncoding.EncodeToString([]byte("too short"))})
+	assert.Error(t, err, "Expected invalid key length to be rejected")
+
+	entry := newTestAesKeyEntry(t, "2024-01")
+	_, err = keys.ParseAesKeyRing([]string{entry, entry})
+	assert.Error(t, err, "Expected duplicate key id to be rejected")
+}
+
+func TestLoadAesKeyRingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aes.keys")
+	content := "# primary key first\n" + newTestAesKeyEntry(t, "2024-06") + "\n\n" + newTestAesKeyEntry(t, "2024-01") + "\n"
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	ring, err := keys.LoadAesKeyRingFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-06", ring.PrimaryKid())
+}
+
+func TestLoadAwsS3AesKeyRing(t *testing.T) {
+	server := newFakeS3(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL+"/keys/rsa/aes.keys", strings.NewReader(newTestAesKeyEntry(t, "2024-01")))
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	ring, err := keys.LoadAwsS3AesKeyRing(keys.AwsS3KeyStoreConfig{
+		Region:         "ca-central-1",
+		Endpoint:       server.URL,
+		ForcePathStyle: true,
+		Bucket:         "keys",
+	}, "rsa/aes.keys")
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-01", ring.PrimaryKid())
+}
+
+func TestGetAesKeyRingForBackend_MemoryRequiresAesKeys(t *testing.T) {
+	defer func(aesKeys []string) { settings.AesKeys = aesKeys }(settings.AesKeys)
+
+	settings.AesKeys = nil
+	_, err := keys.GetAesKeyRingForBackend(keys.MemoryKeyStoreBackend)
+	assert.Error(t, err, "Expected the memory backend to refuse generating a key ring that is lost on restart")
+
+	settings.AesKeys = []string{newTestAesKeyEntry(t, "2024-01")}
+	ring, err := keys.GetAesKeyRingForBackend(keys.MemoryKeyStoreBackend)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-01", ring.PrimaryKid())
+}
@@ -0,0 +1,37 @@
+package keys_test
+
+import (
+	"eau-de-go/pkg/keys"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAlgorithmForKey(t *testing.T) {
+	rsaKey, err := keys.GenerateSigningKey(keys.PS256)
+	assert.NoError(t, err)
+	ecKey, err := keys.GenerateSigningKey(keys.ES256)
+	assert.NoError(t, err)
+	edKey, err := keys.GenerateSigningKey(keys.EdDSA)
+	assert.NoError(t, err)
+
+	algorithm, err := keys.AlgorithmForKey(rsaKey.Public(), keys.RS256)
+	assert.NoError(t, err)
+	assert.Equal(t, keys.RS256, algorithm)
+
+	algorithm, err = keys.AlgorithmForKey(rsaKey.Public(), keys.ES256)
+	assert.NoError(t, err)
+	assert.Equal(t, keys.PS256, algorithm, "RSA key created before a change of algorithm should keep using an RSA algorithm")
+
+	algorithm, err = keys.AlgorithmForKey(ecKey.Public(), keys.PS256)
+	assert.NoError(t, err)
+	assert.Equal(t, keys.ES256, algorithm)
+
+	algorithm, err = keys.AlgorithmForKey(edKey.Public(), keys.PS256)
+	assert.NoError(t, err)
+	assert.Equal(t, keys.EdDSA, algorithm)
+}
+
+func TestGenerateSigningKey_UnsupportedAlgorithm(t *testing.T) {
+	_, err := keys.GenerateSigningKey("HS256")
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+package keys_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"eau-de-go/pkg/keys"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tests for FileKeyStore
+//
+
+func TestFileKeyStore_GeneratesMissingKeys(t *testing.T) {
+	dir := t.TempDir()
+	signingKeyPath := filepath.Join(dir, "rsa", "jwt.pem")
+	verificationKeyPath := filepath.Join(dir, "rsa", "jwt.pub")
+	keyStore := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.PS256, true)
+
+	kid, signingKey, err := keyStore.GetSigningKey()
+	assert.Nil(t, err, "GetSigningKey should generate a missing key")
+
+	signingKeyInfo, err := os.Stat(signingKeyPath)
+	assert.Nil(t, err, "Private key should be written")
+	assert.Equal(t, os.FileMode(0600), signingKeyInfo.Mode().Perm(), "Private key should only be readable by the owner")
+	_, err = os.Stat(verificationKeyPath)
+	assert.Nil(t, err, "Public key should be written")
+
+	reloadedKeyStore := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.PS256, false)
+	reloadedKid, reloadedSigningKey, err := reloadedKeyStore.GetSigningKey()
+	assert.Nil(t, err)
+	assert.Equal(t, kid, reloadedKid, "Generated key should be loaded from disk")
+	assert.Equal(t, signingKey.Public(), reloadedSigningKey.Public())
+}
+
+func TestFileKeyStore_MissingKeys(t *testing.T) {
+	dir := t.TempDir()
+	keyStore := keys.NewFileKeyStore(filepath.Join(dir, "jwt.pem"), filepath.Join(dir, "jwt.pub"), keys.PS256, false)
+
+	_, _, err := keyStore.GetSigningKey()
+	assert.Error(t, err, "GetSigningKey should return an error when keys are missing and generation is disabled")
+}
+
+func TestFileKeyStore_LoadsPkcs8PrivateKey(t *testing.T) {
+	dir := t.TempDir()
+	signingKeyPath := filepath.Join(dir, "jwt.pem")
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	assert.Nil(t, err)
+	err = os.WriteFile(signingKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyBytes}), 0600)
+	assert.Nil(t, err)
+
+	keyStore := keys.NewFileKeyStore(signingKeyPath, filepath.Join(dir, "jwt.pub"), keys.PS256, false)
+
+	kid, signingKey, err := keyStore.GetSigningKey()
+	assert.Nil(t, err, "GetSigningKey should load a PKCS#8 private key without a public key file")
+	assert.True(t, privateKey.Equal(signingKey))
+
+	verificationKey, err := keyStore.GetVerificationKey(kid)
+	assert.Nil(t, err)
+	assert.Equal(t, &privateKey.PublicKey, verificationKey.PublicKey)
+	assert.Equal(t, keys.PS256, verificationKey.Algorithm, "Key stored without an algorithm should use the configured one")
+}
+
+func TestFileKeyStore_RotateKeyPair(t *testing.T) {
+	dir := t.TempDir()
+	signingKeyPath := filepath.Join(dir, "jwt.pem")
+	verificationKeyPath := filepath.Join(dir, "jwt.pub")
+	keyStore := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.PS256, true)
+
+	oldKid, _, err := keyStore.GetSigningKey()
+	assert.Nil(t, err)
+	newKid, err := keyStore.RotateKeyPair()
+	assert.Nil(t, err)
+
+	reloadedKeyStore := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.PS256, false)
+	activeKid, _, err := reloadedKeyStore.GetSigningKey()
+	assert.Nil(t, err)
+	assert.Equal(t, newKid, activeKid, "Rotated key should be persisted as the active signing key")
+
+	verificationKeys, err := reloadedKeyStore.GetVerificationKeys()
+	assert.Nil(t, err)
+	assert.Contains(t, verificationKeys, oldKid, "Retired verification key should be persisted")
+	assert.Contains(t, verificationKeys, newKid)
+}
+
+func TestFileKeyStore_PicksUpRotationByAnotherProcess(t *testing.T) {
+	dir := t.TempDir()
+	signingKeyPath := filepath.Join(dir, "jwt.pem")
+	verificationKeyPath := filepath.Join(dir, "jwt.pub")
+	serverKeyStore := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.PS256, true)
+	firstKid, _, err := serverKeyStore.GetSigningKey()
+	assert.Nil(t, err)
+
+	cliKid, err := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.PS256, false).RotateKeyPair()
+	assert.Nil(t, err)
+
+	activeKid, _, err := serverKeyStore.GetSigningKey()
+	assert.Nil(t, err)
+	assert.Equal(t, cliKid, activeKid, "Key rotated by another process should be loaded")
+
+	serverKid, err := serverKeyStore.RotateKeyPair()
+	assert.Nil(t, err)
+
+	verificationKeys, err := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.PS256, false).GetVerificationKeys()
+	assert.Nil(t, err)
+	assert.Contains(t, verificationKeys, firstKid)
+	assert.Contains(t, verificationKeys, cliKid, "Rotation should keep the key added by another process")
+	assert.Contains(t, verificationKeys, serverKid)
+}
+
+func TestGetKeyStoreForBackend(t *testing.T) {
+	keyStore, err := keys.GetKeyStoreForBackend(keys.MemoryKeyStoreBackend)
+	assert.Nil(t, err)
+	assert.Equal(t, keys.GetInMemoryKeyStore(), keyStore)
+
+	_, err = keys.GetKeyStoreForBackend("unknown")
+	assert.Error(t, err, "GetKeyStoreForBackend should return an error for an unknown backend")
+}
+
+func TestFileKeyStore_ChangeAlgorithm(t *testing.T) {
+	dir := t.TempDir()
+	signingKeyPath := filepath.Join(dir, "jwt.pem")
+	verificationKeyPath := filepath.Join(dir, "jwt.pub")
+
+	rsaKeyStore := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.PS256, true)
+	rsaKid, _, err := rsaKeyStore.GetSigningKey()
+	assert.Nil(t, err)
+
+	ecKeyStore := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.ES256, false)
+	kid, signingKey, err := ecKeyStore.GetSigningKey()
+	assert.Nil(t, err)
+	assert.Equal(t, rsaKid, kid, "Existing key should be used until the key pair is rotated")
+	assert.IsType(t, &rsa.PrivateKey{}, signingKey)
+
+	ecKid, err := ecKeyStore.RotateKeyPair()
+	assert.Nil(t, err)
+	_, signingKey, err = ecKeyStore.GetSigningKey()
+	assert.Nil(t, err)
+	assert.IsType(t, &ecdsa.PrivateKey{}, signingKey, "Rotated key should be created for the configured algorithm")
+
+	reloadedKeyStore := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.ES256, false)
+	verificationKeys, err := reloadedKeyStore.GetVerificationKeys()
+	assert.Nil(t, err)
+	assert.IsType(t, &rsa.PublicKey{}, verificationKeys[rsaKid].PublicKey, "Retired RSA key should still be available for verification")
+	assert.Equal(t, keys.PS256, verificationKeys[rsaKid].Algorithm)
+	assert.IsType(t, &ecdsa.PublicKey{}, verificationKeys[ecKid].PublicKey)
+	assert.Equal(t, keys.ES256, verificationKeys[ecKid].Algorithm)
+}
+
+func TestFileKeyStore_RetiredKeyKeepsItsAlgorithm(t *testing.T) {
+	dir := t.TempDir()
+	signingKeyPath := filepath.Join(dir, "jwt.pem")
+	verificationKeyPath := filepath.Join(dir, "jwt.pub")
+
+	psKid, _, err := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.PS256, true).GetSigningKey()
+	assert.Nil(t, err)
+
+	rsKeyStore := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.RS256, false)
+	verificationKey, err := rsKeyStore.GetVerificationKey(psKid)
+	assert.Nil(t, err)
+	assert.Equal(t, keys.PS256, verificationKey.Algorithm, "Existing key should keep the algorithm it was created for")
+
+	rsKid, err := rsKeyStore.RotateKeyPair()
+	assert.Nil(t, err)
+
+	verificationKeys, err := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.RS256, false).GetVerificationKeys()
+	assert.Nil(t, err)
+	assert.Equal(t, keys.PS256, verificationKeys[psKid].Algorithm, "Retired key should keep the algorithm it was created for")
+	assert.Equal(t, keys.RS256, verificationKeys[rsKid].Algorithm)
+}
+
+func TestFileKeyStore_ReloadDropsRemovedVerificationKey(t *testing.T) {
+	dir := t.TempDir()
+	signingKeyPath := filepath.Join(dir, "jwt.pem")
+	verificationKeyPath := filepath.Join(dir, "jwt.pub")
+	keyStore := keys.NewFileKeyStore(signingKeyPath, verificationKeyPath, keys.ES256, true)
+	revokedKid, _, err := keyStore.GetSigningKey()
+	assert.Nil(t, err)
+	activeKid, err := keyStore.RotateKeyPair()
+	assert.Nil(t, err)
+	_, err = keyStore.GetVerificationKey(revokedKid)
+	assert.Nil(t, err)
+
+	// Rewrite the verification key file with only the active key, as when revoking a compromised key.
+	bundle, err := os.ReadFile(verificationKeyPath)
+	assert.Nil(t, err)
+	block, _ := pem.Decode(bundle)
+	assert.NotNil(t, block)
+	err = os.WriteFile(verificationKeyPath, pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: block.Bytes}), 0644)
+	assert.Nil(t, err)
+
+	_, err = keyStore.GetVerificationKey(revokedKid)
+	assert.IsType(t, &keys.UnknownKeyError{}, err, "Key removed from the verification key file should no longer be trusted")
+	_, err = keyStore.GetVerificationKey(activeKid)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,68 @@
+package keys_test
+
+import (
+	"eau-de-go/pkg/keys"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewJwkSet(t *testing.T) {
+	rsaKey, err := keys.GenerateSigningKey(keys.RS256)
+	assert.NoError(t, err)
+	ecKey, err := keys.GenerateSigningKey(keys.ES256)
+	assert.NoError(t, err)
+	edKey, err := keys.GenerateSigningKey(keys.EdDSA)
+	assert.NoError(t, err)
+
+	jwkSet := keys.NewJwkSet(map[string]keys.VerificationKey{
+		"d": {PublicKey: rsaKey.Public(), Algorithm: keys.PS256},
+		"c": {PublicKey: edKey.Public(), Algorithm: keys.EdDSA},
+		"b": {PublicKey: ecKey.Public(), Algorithm: keys.ES256},
+		"a": {PublicKey: rsaKey.Public(), Algorithm: keys.RS256},
+	})
+
+	assert.Len(t, jwkSet.Keys, 4)
+	assert.Equal(t, "a", jwkSet.Keys[0].Kid, "Keys should be ordered by key id")
+	assert.Equal(t, "RSA", jwkSet.Keys[0].Kty)
+	assert.Equal(t, keys.RS256, jwkSet.Keys[0].Alg)
+	assert.Equal(t, "EC", jwkSet.Keys[1].Kty)
+	assert.Equal(t, "P-256", jwkSet.Keys[1].Crv)
+	assert.Equal(t, keys.ES256, jwkSet.Keys[1].Alg)
+	assert.Equal(t, "OKP", jwkSet.Keys[2].Kty)
+	assert.Equal(t, "Ed25519", jwkSet.Keys[2].Crv)
+	assert.Equal(t, keys.EdDSA, jwkSet.Keys[2].Alg)
+	assert.Equal(t, keys.PS256, jwkSet.Keys[3].Alg, "Each key should be advertised with its own algorithm")
+}
+
+func TestParseJwk_RoundTrip(t *testing.T) {
+	for _, algorithm := range []string{keys.PS256, keys.ES256, keys.EdDSA} {
+		signingKey, err := keys.GenerateSigningKey(algorithm)
+		assert.NoError(t, err)
+
+		jwk, err := keys.NewJwk("kid", signingKey.Public(), algorithm)
+		assert.NoError(t, err)
+		publicKey, err := keys.ParseJwk(jwk)
+		assert.NoError(t, err)
+		assert.Equal(t, signingKey.Public(), publicKey, "Parsed %s key should equal the original key", algorithm)
+	}
+}
+
+func TestParseJwk_UnsupportedKeyType(t *testing.T) {
+	_, err := keys.ParseJwk(keys.Jwk{Kty: "oct"})
+	assert.Error(t, err)
+}
+
+func TestKeyId_Rfc7638Thumbprint(t *testing.T) {
+	// Example key from RFC 7638 section 3.1.
+	jwk := keys.Jwk{
+		Kty: "RSA",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:   "AQAB",
+	}
+	publicKey, err := keys.ParseJwk(jwk)
+	assert.NoError(t, err)
+
+	kid, err := keys.KeyId(publicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", kid)
+}
@@ -0,0 +1,100 @@
+package keys_test
+
+import (
+	"eau-de-go/pkg/keys"
+	"eau-de-go/settings"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Tests for InMemoryKeyStore
+//
+
+func TestGetInMemoryKeyStore_Singleton(t *testing.T) {
+	keyStore1 := keys.GetInMemoryKeyStore()
+	keyStore2 := keys.GetInMemoryKeyStore()
+
+	assert.Equal(t, keyStore1, keyStore2, "GetInMemoryKeyStore should always return the same instance")
+}
+
+func TestInMemoryKeyStore_GetVerificationKey(t *testing.T) {
+	keyStore := keys.GetInMemoryKeyStore()
+
+	kid, signingKey, err := keyStore.GetSigningKey()
+	assert.Nil(t, err, "GetSigningKey should not return an error")
+
+	verificationKey1, err1 := keyStore.GetVerificationKey(kid)
+	assert.Nil(t, err1, "GetVerificationKey should not return an error")
+
+	verificationKey2, err2 := keyStore.GetVerificationKey(kid)
+	assert.Nil(t, err2, "GetVerificationKey should not return an error on subsequent calls")
+
+	assert.Equal(t, verificationKey1, verificationKey2, "GetVerificationKey should always return the same key")
+	assert.Equal(t, signingKey.Public(), verificationKey1.PublicKey, "GetVerificationKey should return the public key of the signing key")
+}
+
+func TestInMemoryKeyStore_GetSigningKey(t *testing.T) {
+	keyStore := keys.GetInMemoryKeyStore()
+
+	kid1, signingKey1, err1 := keyStore.GetSigningKey()
+	assert.Nil(t, err1, "GetSigningKey should not return an error")
+
+	kid2, signingKey2, err2 := keyStore.GetSigningKey()
+	assert.Nil(t, err2, "GetSigningKey should not return an error on subsequent calls")
+
+	assert.Equal(t, signingKey1, signingKey2, "GetSigningKey should always return the same key")
+	assert.Equal(t, kid1, kid2, "GetSigningKey should always return the same key id")
+}
+
+func TestInMemoryKeyStore_GetVerificationKeyUnknownKid(t *testing.T) {
+	keyStore := keys.GetInMemoryKeyStore()
+
+	_, err := keyStore.GetVerificationKey("unknown")
+	assert.Error(t, err, "GetVerificationKey should return an error for an unknown key id")
+}
+
+func TestInMemoryKeyStore_RotateKeyPair(t *testing.T) {
+	keyStore := keys.GetInMemoryKeyStore()
+
+	oldKid, oldSigningKey, err := keyStore.GetSigningKey()
+	assert.Nil(t, err)
+
+	newKid, err := keyStore.RotateKeyPair()
+	assert.Nil(t, err, "RotateKeyPair should not return an error")
+	assert.NotEqual(t, oldKid, newKid, "RotateKeyPair should create a key with a new key id")
+
+	activeKid, _, err := keyStore.GetSigningKey()
+	assert.Nil(t, err)
+	assert.Equal(t, newKid, activeKid, "RotateKeyPair should make the new key the active signing key")
+
+	retiredVerificationKey, err := keyStore.GetVerificationKey(oldKid)
+	assert.Nil(t, err, "Retired verification key should still be available")
+	assert.Equal(t, oldSigningKey.Public(), retiredVerificationKey.PublicKey)
+
+	verificationKeys, err := keyStore.GetVerificationKeys()
+	assert.Nil(t, err)
+	assert.Contains(t, verificationKeys, oldKid)
+	assert.Contains(t, verificationKeys, newKid)
+}
+
+func TestInMemoryKeyStore_RetiredKeyAgedOut(t *testing.T) {
+	keyStore := keys.GetInMemoryKeyStore()
+	defer func() { keys.NowFunc = time.Now }()
+
+	oldKid, _, err := keyStore.GetSigningKey()
+	assert.Nil(t, err)
+	_, err = keyStore.RotateKeyPair()
+	assert.Nil(t, err)
+
+	keys.NowFunc = func() time.Time {
+		return time.Now().Add(settings.RefreshTokenLife + time.Minute)
+	}
+
+	_, err = keyStore.GetVerificationKey(oldKid)
+	assert.Error(t, err, "Retired verification key should be aged out after the refresh token lifetime")
+
+	verificationKeys, err := keyStore.GetVerificationKeys()
+	assert.Nil(t, err)
+	assert.NotContains(t, verificationKeys, oldKid)
+}
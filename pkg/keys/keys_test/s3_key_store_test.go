@@ -0,0 +1,258 @@
+package keys_test
+
+import (
+	"crypto/md5"
+	"eau-de-go/pkg/keys"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is an in-process stand-in for S3, serving path-style GetObject and PutObject requests,
+// including conditional writes with If-None-Match and If-Match.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3(t *testing.T) *httptest.Server {
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	switch r.Method {
+	case http.MethodGet:
+		object, ok := f.objects[key]
+		if !ok {
+			writeFakeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", fakeS3ETag(object))
+		_, _ = w.Write(object)
+	case http.MethodPut:
+		existing, ok := f.objects[key]
+		if ok && r.Header.Get("If-None-Match") == "*" {
+			writeFakeS3Error(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && (!ok || ifMatch != fakeS3ETag(existing)) {
+			writeFakeS3Error(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		object, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeFakeS3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.objects[key] = object
+		w.Header().Set("ETag", fakeS3ETag(object))
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeFakeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func fakeS3ETag(object []byte) string {
+	return fmt.Sprintf("\"%x\"", md5.Sum(object))
+}
+
+func writeFakeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func newTestAwsS3KeyStore(t *testing.T, endpoint string, generateMissingKeys bool) keys.KeyStore {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	keyStore, err := keys.NewAwsS3KeyStore(keys.AwsS3KeyStoreConfig{
+		Region:              "ca-central-1",
+		Endpoint:            endpoint,
+		ForcePathStyle:      true,
+		Bucket:              "keys",
+		SigningKeyPath:      "rsa/jwt.pem",
+		VerificationKeyPath: "rsa/jwt.pub",
+		Algorithm:           keys.ES256,
+		GenerateMissingKeys: generateMissingKeys,
+		RefreshInterval:     5 * time.Minute,
+	})
+	assert.Nil(t, err)
+	return keyStore
+}
+
+// Tests for AwsS3KeyStore
+//
+
+func TestAwsS3KeyStore_BootstrapsEmptyBucket(t *testing.T) {
+	server := newFakeS3(t)
+	keyStore := newTestAwsS3KeyStore(t, server.URL, true)
+
+	kid, signingKey, err := keyStore.GetSigningKey()
+	assert.Nil(t, err, "GetSigningKey should create the first key pair in an empty bucket")
+
+	reloadedKeyStore := newTestAwsS3KeyStore(t, server.URL, false)
+	reloadedKid, reloadedSigningKey, err := reloadedKeyStore.GetSigningKey()
+	assert.Nil(t, err)
+	assert.Equal(t, kid, reloadedKid, "Created key pair should be loaded from S3")
+	assert.Equal(t, signingKey.Public(), reloadedSigningKey.Public())
+
+	verificationKeys, err := reloadedKeyStore.GetVerificationKeys()
+	assert.Nil(t, err)
+	assert.Contains(t, verificationKeys, kid)
+}
+
+func TestAwsS3KeyStore_EmptyBucketWithoutBootstrap(t *testing.T) {
+	server := newFakeS3(t)
+	keyStore := newTestAwsS3KeyStore(t, server.URL, false)
+
+	_, _, err := keyStore.GetSigningKey()
+	assert.Error(t, err, "GetSigningKey should return an error when the bucket is empty and generation is disabled")
+}
+
+func TestAwsS3KeyStore_ConcurrentBootstrap(t *testing.T) {
+	server := newFakeS3(t)
+	keyStores := []keys.KeyStore{
+		newTestAwsS3KeyStore(t, server.URL, true),
+		newTestAwsS3KeyStore(t, server.URL, true),
+		newTestAwsS3KeyStore(t, server.URL, true),
+	}
+
+	kids := make([]string, len(keyStores))
+	var wg sync.WaitGroup
+	for i, keyStore := range keyStores {
+		wg.Add(1)
+		go func(i int, keyStore keys.KeyStore) {
+			defer wg.Done()
+			kid, _, err := keyStore.GetSigningKey()
+			assert.Nil(t, err)
+			kids[i] = kid
+		}(i, keyStore)
+	}
+	wg.Wait()
+
+	for _, kid := range kids {
+		assert.Equal(t, kids[0], kid, "Instances bootstrapping concurrently should agree on a single signing key")
+	}
+}
+
+func TestAwsS3KeyStore_RotateKeyPair(t *testing.T) {
+	server := newFakeS3(t)
+	keyStore := newTestAwsS3KeyStore(t, server.URL, true)
+
+	oldKid, _, err := keyStore.GetSigningKey()
+	assert.Nil(t, err)
+	newKid, err := keyStore.RotateKeyPair()
+	assert.Nil(t, err)
+	assert.NotEqual(t, oldKid, newKid)
+
+	reloadedKeyStore := newTestAwsS3KeyStore(t, server.URL, false)
+	activeKid, _, err := reloadedKeyStore.GetSigningKey()
+	assert.Nil(t, err)
+	assert.Equal(t, newKid, activeKid, "Rotated key should be pushed as the active signing key")
+
+	verificationKeys, err := reloadedKeyStore.GetVerificationKeys()
+	assert.Nil(t, err)
+	assert.Contains(t, verificationKeys, oldKid, "Retired verification key should be kept in the bundle")
+	assert.Contains(t, verificationKeys, newKid)
+}
+
+func TestAwsS3KeyStore_ConcurrentRotation(t *testing.T) {
+	server := newFakeS3(t)
+	_, _, err := newTestAwsS3KeyStore(t, server.URL, true).GetSigningKey()
+	assert.Nil(t, err)
+	keyStores := []keys.KeyStore{
+		newTestAwsS3KeyStore(t, server.URL, false),
+		newTestAwsS3KeyStore(t, server.URL, false),
+		newTestAwsS3KeyStore(t, server.URL, false),
+	}
+
+	kids := make([]string, len(keyStores))
+	var wg sync.WaitGroup
+	for i, keyStore := range keyStores {
+		wg.Add(1)
+		go func(i int, keyStore keys.KeyStore) {
+			defer wg.Done()
+			kid, err := keyStore.RotateKeyPair()
+			assert.Nil(t, err)
+			kids[i] = kid
+		}(i, keyStore)
+	}
+	wg.Wait()
+
+	reloadedKeyStore := newTestAwsS3KeyStore(t, server.URL, false)
+	activeKid, _, err := reloadedKeyStore.GetSigningKey()
+	assert.Nil(t, err)
+	assert.Contains(t, kids, activeKid)
+	verificationKeys, err := reloadedKeyStore.GetVerificationKeys()
+	assert.Nil(t, err)
+	for _, kid := range kids {
+		assert.Contains(t, verificationKeys, kid, "Keys rotated concurrently should all be kept in the bundle")
+	}
+}
+
+func TestAwsS3KeyStore_RotationKeepsKeysRotatedByAnotherInstance(t *testing.T) {
+	server := newFakeS3(t)
+	keyStore := newTestAwsS3KeyStore(t, server.URL, true)
+	firstKid, _, err := keyStore.GetSigningKey()
+	assert.Nil(t, err)
+
+	otherKid, err := newTestAwsS3KeyStore(t, server.URL, false).RotateKeyPair()
+	assert.Nil(t, err)
+	kid, err := keyStore.RotateKeyPair()
+	assert.Nil(t, err)
+
+	activeKid, _, err := keyStore.GetSigningKey()
+	assert.Nil(t, err)
+	assert.Equal(t, kid, activeKid)
+	verificationKeys, err := newTestAwsS3KeyStore(t, server.URL, false).GetVerificationKeys()
+	assert.Nil(t, err)
+	assert.Contains(t, verificationKeys, firstKid)
+	assert.Contains(t, verificationKeys, otherKid, "Rotation should keep the key rotated in by another instance")
+	assert.Contains(t, verificationKeys, kid)
+}
+
+func TestAwsS3KeyStore_RefreshesRotatedKeys(t *testing.T) {
+	server := newFakeS3(t)
+	defer func() { keys.NowFunc = time.Now }()
+
+	rotatingKeyStore := newTestAwsS3KeyStore(t, server.URL, true)
+	oldKid, _, err := rotatingKeyStore.GetSigningKey()
+	assert.Nil(t, err)
+
+	keyStore := newTestAwsS3KeyStore(t, server.URL, false)
+	kid, _, err := keyStore.GetSigningKey()
+	assert.Nil(t, err)
+	assert.Equal(t, oldKid, kid)
+
+	newKid, err := rotatingKeyStore.RotateKeyPair()
+	assert.Nil(t, err)
+
+	kid, _, err = keyStore.GetSigningKey()
+	assert.Nil(t, err)
+	assert.Equal(t, oldKid, kid, "Keys should be cached until the refresh interval has passed")
+
+	keys.NowFunc = func() time.Time {
+		return time.Now().Add(6 * time.Minute)
+	}
+	assert.Eventually(t, func() bool {
+		kid, _, err = keyStore.GetSigningKey()
+		return err == nil && kid == newKid
+	}, 5*time.Second, 10*time.Millisecond, "Rotated key should be picked up after the refresh interval")
+
+	_, err = keyStore.GetVerificationKey(oldKid)
+	assert.Nil(t, err, "Retired verification key should still be available after refresh")
+}
@@ -0,0 +1,139 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	algorithmPemHeader = "Algorithm"
+	retiredAtPemHeader = "Retired-At"
+)
+
+func encodePrivateKeyPem(privateKey crypto.Signer) ([]byte, error) {
+	privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyBytes}), nil
+}
+
+// decodePrivateKeyPem parses a PKCS#8 encoded RSA, ECDSA or Ed25519 private key,
+// as well as PKCS#1 encoded RSA and SEC 1 encoded ECDSA private keys.
+func decodePrivateKeyPem(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("failed to decode private key PEM")
+	}
+
+	var privateKey crypto.Signer
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		rsaPrivateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		privateKey = rsaPrivateKey
+	case "EC PRIVATE KEY":
+		ecPrivateKey, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		privateKey = ecPrivateKey
+	default:
+		parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := parsedKey.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key type")
+		}
+		privateKey = signer
+	}
+
+	if _, err := AlgorithmForKey(privateKey.Public(), ""); err != nil {
+		return nil, err
+	}
+	return privateKey, nil
+}
+
+// encodeVerificationKeyBundlePem encodes the verification keys as concatenated PKIX PEM blocks,
+// the active key first followed by retired keys, which carry a Retired-At header.
+// Every key carries an Algorithm header with the algorithm it signs with.
+func encodeVerificationKeyBundlePem(signingKid string, verificationKeys map[string]*verificationKeyEntry) ([]byte, error) {
+	kids := make([]string, 0, len(verificationKeys))
+	for kid := range verificationKeys {
+		kids = append(kids, kid)
+	}
+	sort.Slice(kids, func(i, j int) bool {
+		if kids[i] == signingKid || kids[j] == signingKid {
+			return kids[i] == signingKid
+		}
+		return verificationKeys[kids[i]].RetiredAt.After(verificationKeys[kids[j]].RetiredAt)
+	})
+
+	var bundle []byte
+	for _, kid := range kids {
+		verificationKey := verificationKeys[kid]
+		publicKeyBytes, err := x509.MarshalPKIXPublicKey(verificationKey.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		block := &pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes, Headers: map[string]string{}}
+		if verificationKey.Algorithm != "" {
+			block.Headers[algorithmPemHeader] = verificationKey.Algorithm
+		}
+		if !verificationKey.RetiredAt.IsZero() {
+			block.Headers[retiredAtPemHeader] = verificationKey.RetiredAt.UTC().Format(time.RFC3339)
+		}
+		bundle = append(bundle, pem.EncodeToMemory(block)...)
+	}
+	return bundle, nil
+}
+
+// decodeVerificationKeyBundlePem parses concatenated PKIX PEM blocks, returning the keys along with their algorithm,
+// which is empty for keys without an Algorithm header, and their retirement time, which is zero for keys without a Retired-At header.
+func decodeVerificationKeyBundlePem(data []byte) ([]*verificationKeyEntry, error) {
+	var verificationKeys []*verificationKeyEntry
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		parsedKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := AlgorithmForKey(parsedKey, ""); err != nil {
+			return nil, err
+		}
+
+		verificationKey := &verificationKeyEntry{PublicKey: parsedKey}
+		if algorithm, ok := block.Headers[algorithmPemHeader]; ok {
+			if keyAlgorithm, err := AlgorithmForKey(parsedKey, algorithm); err != nil || keyAlgorithm != algorithm {
+				return nil, fmt.Errorf("algorithm %s does not match the key type %T", algorithm, parsedKey)
+			}
+			verificationKey.Algorithm = algorithm
+		}
+		if retiredAt, ok := block.Headers[retiredAtPemHeader]; ok {
+			verificationKey.RetiredAt, err = time.Parse(time.RFC3339, retiredAt)
+			if err != nil {
+				return nil, err
+			}
+		}
+		verificationKeys = append(verificationKeys, verificationKey)
+	}
+
+	if len(verificationKeys) == 0 {
+		return nil, errors.New("failed to decode public key PEM")
+	}
+	return verificationKeys, nil
+}
@@ -0,0 +1,33 @@
+package keys
+
+import (
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// ScheduleKeyRotation rotates the key pair of the key store at the given interval, until the returned stop function is called.
+func ScheduleKeyRotation(keyStore KeyStore, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				kid, err := keyStore.RotateKeyPair()
+				if err != nil {
+					log.Errorf("Failed to rotate key pair: %s", err)
+					continue
+				}
+				log.Infof("Rotated signing key, new key id %s", kid)
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
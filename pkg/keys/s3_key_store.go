@@ -0,0 +1,442 @@
+package keys
+
+import (
+	"bytes"
+	"crypto"
+	"eau-de-go/settings"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"sync"
+	"time"
+)
+
+type AwsS3KeyStoreConfig struct {
+	Region string
+	// Endpoint overrides the AWS S3 endpoint, e.g. to use MinIO or another S3 compatible storage.
+	Endpoint string
+	// ForcePathStyle addresses objects as endpoint/bucket/key instead of bucket.endpoint/key.
+	ForcePathStyle      bool
+	Bucket              string
+	SigningKeyPath      string
+	VerificationKeyPath string
+	// Algorithm is the signing algorithm new key pairs are created for.
+	Algorithm string
+	// GenerateMissingKeys creates the first key pair when the bucket holds no signing key.
+	GenerateMissingKeys bool
+	// RefreshInterval is how often keys are re-fetched, so that rotations by other instances are picked up.
+	RefreshInterval time.Duration
+}
+
+// AWS S3 key store, for distributed deployment.
+// The active private key is stored at SigningKeyPath, and the public keys of the active and retired key pairs
+// are stored as a PEM bundle at VerificationKeyPath. Keys are fetched from AWS S3 on first access, kept in memory,
+// and re-fetched in the background every RefreshInterval.
+type awsS3KeyStore struct {
+	mu          sync.Mutex
+	ring        *keyRing
+	loaded      bool
+	refreshing  bool
+	refreshedAt time.Time
+	// generation is incremented whenever the keys are loaded or rotated while holding the lock,
+	// so that a background refresh started before does not overwrite them with the keys it fetched.
+	generation int
+	config     AwsS3KeyStoreConfig
+	Client     s3iface.S3API
+}
+
+var awsS3KeyStoreInstance KeyStore
+var awsS3KeyStoreErr error
+var awsS3KeyStoreOnce sync.Once
+
+// GetAwsS3KeyStore returns the S3 key store configured by the settings, or the error creating its S3 client.
+func GetAwsS3KeyStore() (KeyStore, error) {
+	awsS3KeyStoreOnce.Do(func() {
+		awsS3KeyStoreInstance, awsS3KeyStoreErr = NewAwsS3KeyStore(AwsS3KeyStoreConfig{
+			Region:              settings.AwsS3KeyStoreRegion,
+			Endpoint:            settings.AwsS3KeyStoreEndpoint,
+			ForcePathStyle:      settings.AwsS3KeyStoreForcePathStyle,
+			Bucket:              settings.AwsS3KeyStoreBucket,
+			SigningKeyPath:      settings.JwtSigningKeyPath,
+			VerificationKeyPath: settings.JwtVerificationKeyPath,
+			Algorithm:           settings.JwtSigningAlgorithm,
+			GenerateMissingKeys: settings.JwtGenerateMissingKeys,
+			RefreshInterval:     settings.KeyStoreRefreshInterval,
+		})
+		if awsS3KeyStoreErr != nil {
+			log.Errorf("Failed to create AWS session: %s", awsS3KeyStoreErr)
+		}
+	})
+	return awsS3KeyStoreInstance, awsS3KeyStoreErr
+}
+
+func NewAwsS3KeyStore(config AwsS3KeyStoreConfig) (KeyStore, error) {
+	client, err := newS3Client(config)
+	if err != nil {
+		return nil, err
+	}
+	return &awsS3KeyStore{
+		ring:   newKeyRing(settings.RefreshTokenLife, config.Algorithm),
+		config: config,
+		Client: client,
+	}, nil
+}
+
+// LoadAwsS3AesKeyRing reads the AES key ring file stored at path in the bucket of the config.
+func LoadAwsS3AesKeyRing(config AwsS3KeyStoreConfig, path string) (*AesKeyRing, error) {
+	client, err := newS3Client(config)
+	if err != nil {
+		return nil, err
+	}
+	data, err := getS3Object(client, config.Bucket, path)
+	if err != nil {
+		return nil, err
+	}
+	return parseAesKeyRingFile(data)
+}
+
+func newS3Client(config AwsS3KeyStoreConfig) (s3iface.S3API, error) {
+	awsConfig := &aws.Config{
+		Region:           aws.String(config.Region),
+		S3ForcePathStyle: aws.Bool(config.ForcePathStyle),
+	}
+	if config.Endpoint != "" {
+		awsConfig.Endpoint = aws.String(config.Endpoint)
+	}
+	session, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return s3.New(session), nil
+}
+
+// maxRotationAttempts bounds the retries of a rotation that raced with rotations by other instances.
+const maxRotationAttempts = 5
+
+// RotateKeyPair creates a new key pair and pushes it to S3 as the active key pair,
+// keeping the previous public keys in the verification key bundle.
+// Both objects are written conditionally on the versions the rotation started from, so that instances rotating
+// at the same time do not overwrite each other's keys, and a rotation that lost the race is retried on top of the keys
+// of the winner.
+func (keyStore *awsS3KeyStore) RotateKeyPair() (string, error) {
+	keyStore.mu.Lock()
+	defer keyStore.mu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		kid, err := keyStore.rotateKeyPair()
+		if !isS3PreconditionFailed(err) || attempt == maxRotationAttempts {
+			return kid, err
+		}
+		log.Infof("Keys were changed by another instance while rotating, retrying the rotation")
+	}
+}
+
+func (keyStore *awsS3KeyStore) rotateKeyPair() (string, error) {
+	storedKeys, err := keyStore.downloadKeys()
+	if err != nil && !isS3NotFound(err) {
+		return "", err
+	}
+	// The new key pair is staged in a separate ring, so that the cached keys are left untouched if the rotation fails.
+	ring := newKeyRing(keyStore.ring.retention, keyStore.config.Algorithm)
+	if storedKeys.signingKey != nil {
+		if err := ring.load(storedKeys.signingKey, storedKeys.verificationKeys); err != nil {
+			return "", err
+		}
+	}
+
+	signingKey, err := GenerateSigningKey(keyStore.config.Algorithm)
+	if err != nil {
+		log.Errorf("Failed to create private key: %s", err)
+		return "", err
+	}
+	kid, err := ring.activate(signingKey, keyStore.config.Algorithm)
+	if err != nil {
+		return "", err
+	}
+	// The verification key bundle is pushed first, so that other instances trust the new key before it is used.
+	if err := keyStore.pushVerificationKeysToS3(ring, ifUnchanged(storedKeys.verificationKeysETag)); err != nil {
+		return "", err
+	}
+	if err := keyStore.pushSigningKeyToS3(ring, ifUnchanged(storedKeys.signingKeyETag)); err != nil {
+		return "", err
+	}
+
+	verificationKeys := ring.getVerificationKeys()
+	verificationKeyList := make([]*verificationKeyEntry, 0, len(verificationKeys))
+	for _, verificationKey := range verificationKeys {
+		verificationKeyList = append(verificationKeyList, verificationKey)
+	}
+	if err := keyStore.ring.load(signingKey, verificationKeyList); err != nil {
+		return "", err
+	}
+	keyStore.markRefreshed()
+	log.Infof("Created new key pair %s", kid)
+	return kid, nil
+}
+
+// bootstrap creates the first key pair. The signing key is written conditionally, so when several instances
+// start against an empty bucket only one key pair is created, and the others load it instead.
+func (keyStore *awsS3KeyStore) bootstrap() error {
+	signingKey, err := GenerateSigningKey(keyStore.config.Algorithm)
+	if err != nil {
+		log.Errorf("Failed to create private key: %s", err)
+		return err
+	}
+	kid, err := keyStore.ring.activate(signingKey, keyStore.config.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	err = keyStore.pushSigningKeyToS3(keyStore.ring, ifUnchanged(""))
+	if isS3PreconditionFailed(err) {
+		log.Infof("Signing key was created by another instance, loading it from S3")
+		if err := keyStore.fetchFromS3(); err != nil {
+			return err
+		}
+		keyStore.markRefreshed()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := keyStore.pushVerificationKeysToS3(keyStore.ring, nil); err != nil {
+		return err
+	}
+	keyStore.markRefreshed()
+	log.Infof("Created first key pair %s", kid)
+	return nil
+}
+
+// ifUnchanged returns the headers making a write conditional on the object still having the ETag,
+// or on the object not existing yet for an empty ETag.
+func ifUnchanged(etag string) map[string]string {
+	if etag == "" {
+		return map[string]string{"If-None-Match": "*"}
+	}
+	return map[string]string{"If-Match": etag}
+}
+
+func (keyStore *awsS3KeyStore) pushSigningKeyToS3(ring *keyRing, preconditions map[string]string) error {
+	_, signingKey, err := ring.getSigningKey()
+	if err != nil {
+		return err
+	}
+	privateKeyPem, err := encodePrivateKeyPem(signingKey)
+	if err != nil {
+		log.Errorf("Failed to marshal private key: %s", err)
+		return err
+	}
+
+	if err := keyStore.putObject(keyStore.config.SigningKeyPath, privateKeyPem, preconditions); err != nil {
+		if !isS3PreconditionFailed(err) {
+			log.Errorf("Failed to upload private key to S3: %s", err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (keyStore *awsS3KeyStore) pushVerificationKeysToS3(ring *keyRing, preconditions map[string]string) error {
+	kid, _, err := ring.getSigningKey()
+	if err != nil {
+		return err
+	}
+	publicKeyBundle, err := encodeVerificationKeyBundlePem(kid, ring.getVerificationKeys())
+	if err != nil {
+		log.Errorf("Failed to marshal public keys: %s", err)
+		return err
+	}
+
+	if err := keyStore.putObject(keyStore.config.VerificationKeyPath, publicKeyBundle, preconditions); err != nil {
+		if !isS3PreconditionFailed(err) {
+			log.Errorf("Failed to upload public key to S3: %s", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// putObject uploads the object, provided that the precondition headers, e.g. If-Match, are met.
+func (keyStore *awsS3KeyStore) putObject(key string, body []byte, preconditions map[string]string) error {
+	req, _ := keyStore.Client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String(keyStore.config.Bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(body),
+	})
+	for header, value := range preconditions {
+		req.HTTPRequest.Header.Set(header, value)
+	}
+	return req.Send()
+}
+
+func (keyStore *awsS3KeyStore) markRefreshed() {
+	keyStore.loaded = true
+	keyStore.refreshedAt = NowFunc()
+	keyStore.generation++
+}
+
+// ensureLoaded fetches the keys on first access. Once loaded, the keys are re-fetched in the background
+// when RefreshInterval has passed, so that requests do not wait on S3 and keep using the cached keys meanwhile.
+func (keyStore *awsS3KeyStore) ensureLoaded() error {
+	keyStore.mu.Lock()
+	defer keyStore.mu.Unlock()
+
+	if keyStore.loaded {
+		if keyStore.refreshing || keyStore.config.RefreshInterval <= 0 || NowFunc().Sub(keyStore.refreshedAt) < keyStore.config.RefreshInterval {
+			return nil
+		}
+		keyStore.refreshing = true
+		go keyStore.refresh(keyStore.generation)
+		return nil
+	}
+
+	err := keyStore.fetchFromS3()
+	if isS3NotFound(err) && keyStore.config.GenerateMissingKeys {
+		log.Warnf("No signing key found in S3, creating the first key pair")
+		return keyStore.bootstrap()
+	}
+	if err != nil {
+		return err
+	}
+	keyStore.markRefreshed()
+	return nil
+}
+
+func (keyStore *awsS3KeyStore) GetSigningKey() (string, crypto.Signer, error) {
+	if err := keyStore.ensureLoaded(); err != nil {
+		return "", nil, err
+	}
+	return keyStore.ring.getSigningKey()
+}
+
+func (keyStore *awsS3KeyStore) GetVerificationKey(kid string) (VerificationKey, error) {
+	if err := keyStore.ensureLoaded(); err != nil {
+		return VerificationKey{}, err
+	}
+	return keyStore.ring.getVerificationKey(kid)
+}
+
+func (keyStore *awsS3KeyStore) GetVerificationKeys() (map[string]VerificationKey, error) {
+	if err := keyStore.ensureLoaded(); err != nil {
+		return nil, err
+	}
+	return verificationKeysOf(keyStore.ring.getVerificationKeys()), nil
+}
+
+// refresh re-fetches the keys without holding the lock, and loads them unless the keys were loaded or rotated
+// since generation. A failed re-fetch keeps the cached keys in use until the next refresh.
+func (keyStore *awsS3KeyStore) refresh(generation int) {
+	storedKeys, err := keyStore.downloadKeys()
+
+	keyStore.mu.Lock()
+	defer keyStore.mu.Unlock()
+	keyStore.refreshing = false
+	keyStore.refreshedAt = NowFunc()
+	if err == nil && generation == keyStore.generation {
+		err = keyStore.ring.load(storedKeys.signingKey, storedKeys.verificationKeys)
+	}
+	if err != nil {
+		log.Warnf("Failed to refresh keys from S3, using cached keys: %s", err)
+	}
+}
+
+// fetchFromS3 downloads the keys and loads them into the key ring.
+func (keyStore *awsS3KeyStore) fetchFromS3() error {
+	storedKeys, err := keyStore.downloadKeys()
+	if err != nil {
+		return err
+	}
+	return keyStore.ring.load(storedKeys.signingKey, storedKeys.verificationKeys)
+}
+
+// s3Keys are the keys stored in S3, along with the ETags of the objects they were read from,
+// which are empty for missing objects.
+type s3Keys struct {
+	signingKey           crypto.Signer
+	signingKeyETag       string
+	verificationKeys     []*verificationKeyEntry
+	verificationKeysETag string
+}
+
+// downloadKeys downloads the signing key and the verification key bundle. The bundle is optional, as it is written
+// after the signing key when bootstrapping, and the public key of the signing key is always trusted.
+func (keyStore *awsS3KeyStore) downloadKeys() (s3Keys, error) {
+	var storedKeys s3Keys
+	privateKeyPem, etag, err := keyStore.getObject(keyStore.config.SigningKeyPath)
+	if err != nil {
+		if !isS3NotFound(err) {
+			log.Errorf("Failed to download private key from S3: %s", err)
+		}
+		return s3Keys{}, err
+	}
+	storedKeys.signingKeyETag = etag
+	storedKeys.signingKey, err = decodePrivateKeyPem(privateKeyPem)
+	if err != nil {
+		log.Errorf("Failed to parse private key: %s", err)
+		return s3Keys{}, err
+	}
+
+	publicKeyPem, etag, err := keyStore.getObject(keyStore.config.VerificationKeyPath)
+	if err == nil {
+		storedKeys.verificationKeysETag = etag
+		storedKeys.verificationKeys, err = decodeVerificationKeyBundlePem(publicKeyPem)
+		if err != nil {
+			log.Errorf("Failed to parse public key: %s", err)
+			return s3Keys{}, err
+		}
+	} else if !isS3NotFound(err) {
+		log.Errorf("Failed to download public key from S3: %s", err)
+		return s3Keys{}, err
+	}
+	return storedKeys, nil
+}
+
+// getObject downloads the object along with its ETag.
+func (keyStore *awsS3KeyStore) getObject(key string) ([]byte, string, error) {
+	output, err := keyStore.Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(keyStore.config.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	defer output.Body.Close()
+	data, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, aws.StringValue(output.ETag), nil
+}
+
+func getS3Object(client s3iface.S3API, bucket string, key string) ([]byte, error) {
+	output, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+	return io.ReadAll(output.Body)
+}
+
+func isS3NotFound(err error) bool {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		return awsErr.Code() == s3.ErrCodeNoSuchKey || awsErr.Code() == "NotFound"
+	}
+	return false
+}
+
+func isS3PreconditionFailed(err error) bool {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		return awsErr.Code() == "PreconditionFailed"
+	}
+	return false
+}
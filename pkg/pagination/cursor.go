@@ -0,0 +1,67 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/google/uuid"
+)
+
+// Cursor is the position of a row in a keyset paginated listing, the next page starts after the row
+// whose sort key and id are Key and ID. Sort is the sort option of the listing, so that a cursor
+// cannot be used with another sort option.
+type Cursor struct {
+	Sort string    `json:"s"`
+	Key  string    `json:"k"`
+	ID   uuid.UUID `json:"i"`
+}
+
+// Cipher encrypts cursors, e.g. *keys.AesKeyRing.
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// CursorCodec encodes cursors to opaque url-safe strings, encrypted when a Cipher is set.
+// Clients must pass cursors back as is, any other value is rejected with an InvalidCursorError.
+type CursorCodec struct {
+	Cipher Cipher
+}
+
+func NewCursorCodec(cipher Cipher) *CursorCodec {
+	return &CursorCodec{Cipher: cipher}
+}
+
+func (codec *CursorCodec) Encode(cursor Cursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	if codec.Cipher != nil {
+		data, err = codec.Cipher.Encrypt(data)
+		if err != nil {
+			return "", err
+		}
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Decode returns the cursor encoded by Encode, provided it was created for the sort option.
+func (codec *CursorCodec) Decode(encoded string, sort string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, &InvalidCursorError{}
+	}
+	if codec.Cipher != nil {
+		data, err = codec.Cipher.Decrypt(data)
+		if err != nil {
+			return Cursor{}, &InvalidCursorError{}
+		}
+	}
+
+	var cursor Cursor
+	err = json.Unmarshal(data, &cursor)
+	if err != nil || cursor.Sort != sort || cursor.ID == uuid.Nil {
+		return Cursor{}, &InvalidCursorError{}
+	}
+	return cursor, nil
+}
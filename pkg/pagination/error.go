@@ -0,0 +1,17 @@
+package pagination
+
+import "fmt"
+
+type InvalidCursorError struct{}
+
+func (e *InvalidCursorError) Error() string {
+	return "Invalid cursor"
+}
+
+type InvalidPageRequestError struct {
+	Key string
+}
+
+func (e *InvalidPageRequestError) Error() string {
+	return fmt.Sprintf("Invalid page request: %v", e.Key)
+}
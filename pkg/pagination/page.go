@@ -0,0 +1,90 @@
+package pagination
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// PageRequest is the page of a listing requested by a client.
+type PageRequest struct {
+	// Cursor is the next_cursor of the previous page, empty for the first page.
+	Cursor string
+	Limit  int
+	// Sort is the sort option, a field name optionally prefixed with - for descending order.
+	Sort string
+}
+
+// ParsePageRequest reads the cursor, limit and sort query parameters,
+// the limit defaults to DefaultLimit and is capped at MaxLimit.
+func ParsePageRequest(query url.Values) (PageRequest, error) {
+	pageRequest := PageRequest{
+		Cursor: query.Get("cursor"),
+		Limit:  DefaultLimit,
+		Sort:   query.Get("sort"),
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return PageRequest{}, &InvalidPageRequestError{Key: "limit must be a positive integer"}
+		}
+		pageRequest.Limit = ClampLimit(limit)
+	}
+	return pageRequest, nil
+}
+
+// ClampLimit returns DefaultLimit for unset limits, and caps limits at MaxLimit.
+func ClampLimit(limit int) int {
+	if limit < 1 {
+		return DefaultLimit
+	}
+	return min(limit, MaxLimit)
+}
+
+// ParseSort splits the sort option into the field and the direction, the field must be one of the allowed fields.
+func ParseSort(sort string, allowedFields ...string) (string, bool, error) {
+	field, descending := strings.CutPrefix(sort, "-")
+	if !slices.Contains(allowedFields, field) {
+		return "", false, &InvalidPageRequestError{Key: "sort must be one of " + strings.Join(allowedFields, ", ") + ", optionally prefixed with -"}
+	}
+	return field, descending, nil
+}
+
+// Page is a page of a keyset paginated listing, NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPage makes a page from rows queried with a limit of limit+1, the extra row only tells that there is a next page,
+// which starts after the cursor of the last row of the page.
+func NewPage[T any](codec *CursorCodec, rows []T, limit int, cursorOf func(T) Cursor) (Page[T], error) {
+	if len(rows) <= limit {
+		if rows == nil {
+			rows = []T{}
+		}
+		return Page[T]{Items: rows}, nil
+	}
+
+	items := rows[:limit]
+	nextCursor, err := codec.Encode(cursorOf(items[len(items)-1]))
+	if err != nil {
+		return Page[T]{}, err
+	}
+	return Page[T]{Items: items, NextCursor: nextCursor}, nil
+}
+
+// MapPage converts the items of the page, e.g. database rows to response DTOs.
+func MapPage[T any, U any](page Page[T], convert func(T) U) Page[U] {
+	items := make([]U, 0, len(page.Items))
+	for _, item := range page.Items {
+		items = append(items, convert(item))
+	}
+	return Page[U]{Items: items, NextCursor: page.NextCursor}
+}
@@ -0,0 +1,101 @@
+package pagination_test
+
+import (
+	"eau-de-go/pkg/keys"
+	"eau-de-go/pkg/pagination"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestCursorCodecRoundTrip(t *testing.T) {
+	codec := pagination.NewCursorCodec(nil)
+	cursor := pagination.Cursor{Sort: "-date_joined", Key: "2026-01-01T00:00:00Z", ID: uuid.New()}
+
+	encoded, err := codec.Encode(cursor)
+	assert.NoError(t, err)
+
+	decoded, err := codec.Decode(encoded, "-date_joined")
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+}
+
+func TestCursorCodecEncrypted(t *testing.T) {
+	key, _ := keys.GenerateAesKey()
+	ring, err := keys.NewAesKeyRing("k1", map[string][]byte{"k1": key})
+	assert.NoError(t, err)
+	codec := pagination.NewCursorCodec(ring)
+	cursor := pagination.Cursor{Sort: "username", Key: "john", ID: uuid.New()}
+
+	encoded, err := codec.Encode(cursor)
+	assert.NoError(t, err)
+	assert.NotContains(t, encoded, "am9obg")
+
+	decoded, err := codec.Decode(encoded, "username")
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	_, err = pagination.NewCursorCodec(nil).Decode(encoded, "username")
+	assert.IsType(t, &pagination.InvalidCursorError{}, err)
+}
+
+func TestCursorCodecRejectsInvalidCursors(t *testing.T) {
+	codec := pagination.NewCursorCodec(nil)
+	encoded, _ := codec.Encode(pagination.Cursor{Sort: "username", Key: "john", ID: uuid.New()})
+
+	for _, invalid := range []string{"not base64!", "e30", encoded[:len(encoded)-2]} {
+		_, err := codec.Decode(invalid, "username")
+		assert.IsType(t, &pagination.InvalidCursorError{}, err, invalid)
+	}
+
+	_, err := codec.Decode(encoded, "-username")
+	assert.IsType(t, &pagination.InvalidCursorError{}, err)
+}
+
+func TestParsePageRequest(t *testing.T) {
+	pageRequest, err := pagination.ParsePageRequest(url.Values{"cursor": {"abc"}, "limit": {"1000"}, "sort": {"-username"}})
+	assert.NoError(t, err)
+	assert.Equal(t, pagination.PageRequest{Cursor: "abc", Limit: pagination.MaxLimit, Sort: "-username"}, pageRequest)
+
+	pageRequest, err = pagination.ParsePageRequest(url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, pagination.DefaultLimit, pageRequest.Limit)
+
+	_, err = pagination.ParsePageRequest(url.Values{"limit": {"0"}})
+	assert.IsType(t, &pagination.InvalidPageRequestError{}, err)
+}
+
+func TestParseSort(t *testing.T) {
+	field, descending, err := pagination.ParseSort("-date_joined", "date_joined", "username")
+	assert.NoError(t, err)
+	assert.Equal(t, "date_joined", field)
+	assert.True(t, descending)
+
+	_, _, err = pagination.ParseSort("password", "date_joined", "username")
+	assert.IsType(t, &pagination.InvalidPageRequestError{}, err)
+}
+
+func TestNewPage(t *testing.T) {
+	codec := pagination.NewCursorCodec(nil)
+	cursorOf := func(id uuid.UUID) pagination.Cursor {
+		return pagination.Cursor{Sort: "id", ID: id}
+	}
+	rows := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+
+	page, err := pagination.NewPage(codec, rows, 2, cursorOf)
+	assert.NoError(t, err)
+	assert.Equal(t, rows[:2], page.Items)
+	next, err := codec.Decode(page.NextCursor, "id")
+	assert.NoError(t, err)
+	assert.Equal(t, rows[1], next.ID)
+
+	page, err = pagination.NewPage(codec, rows, 3, cursorOf)
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 3)
+	assert.Empty(t, page.NextCursor)
+
+	page, err = pagination.NewPage[uuid.UUID](codec, nil, 3, cursorOf)
+	assert.NoError(t, err)
+	assert.NotNil(t, page.Items)
+}
@@ -0,0 +1,11 @@
+package username_util
+
+import "fmt"
+
+type InvalidUsernameError struct {
+	Key string
+}
+
+func (e *InvalidUsernameError) Error() string {
+	return fmt.Sprintf("Invalid username: %v", e.Key)
+}
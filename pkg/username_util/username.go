@@ -0,0 +1,60 @@
+package username_util
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	MinUsernameLength = 3
+	// MaxUsernameLength is the length of the app_user.username column.
+	MaxUsernameLength = 150
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ReservedUsernames cannot be used as usernames, regardless of case,
+// as they could be mistaken for the application itself or collide with routes.
+var ReservedUsernames = []string{
+	"admin",
+	"administrator",
+	"api",
+	"auth",
+	"help",
+	"me",
+	"mod",
+	"moderator",
+	"noreply",
+	"no-reply",
+	"null",
+	"postmaster",
+	"root",
+	"security",
+	"staff",
+	"support",
+	"system",
+	"undefined",
+	"webmaster",
+}
+
+// ValidateUsername checks that the username is 3 to 150 characters long, made of ASCII letters, digits,
+// periods, underscores and hyphens starting with a letter or digit, and is not reserved.
+func ValidateUsername(username string) error {
+	if len(username) < MinUsernameLength || len(username) > MaxUsernameLength {
+		return &InvalidUsernameError{Key: "must be between 3 and 150 characters long"}
+	}
+	if !usernamePattern.MatchString(username) {
+		return &InvalidUsernameError{Key: "must contain only letters, digits, periods, underscores and hyphens, and start with a letter or digit"}
+	}
+	return ValidateUsernameNotReserved(username)
+}
+
+// ValidateUsernameNotReserved checks that the username is not one of the ReservedUsernames, regardless of case.
+// It is the only check applied to usernames chosen at sign-up, the other rules of ValidateUsername only apply to username changes.
+func ValidateUsernameNotReserved(username string) error {
+	if slices.Contains(ReservedUsernames, strings.ToLower(username)) {
+		return &InvalidUsernameError{Key: "is reserved"}
+	}
+	return nil
+}
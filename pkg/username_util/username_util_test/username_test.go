@@ -0,0 +1,42 @@
+package username_util_test
+
+import (
+	"eau-de-go/pkg/username_util"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestValidateUsername(t *testing.T) {
+	for _, username := range []string{"abc", "john.doe", "john_doe-42", "J0hn", strings.Repeat("a", 150)} {
+		assert.NoError(t, username_util.ValidateUsername(username), username)
+	}
+}
+
+func TestValidateUsernameInvalid(t *testing.T) {
+	for _, username := range []string{
+		"",
+		"ab",
+		strings.Repeat("a", 151),
+		"john doe",
+		".john",
+		"-john",
+		"john@example.com",
+		"jöhn",
+		"Admin",
+		"ROOT",
+	} {
+		var invalidUsernameError *username_util.InvalidUsernameError
+		assert.ErrorAs(t, username_util.ValidateUsername(username), &invalidUsernameError, username)
+	}
+}
+
+func TestValidateUsernameNotReserved(t *testing.T) {
+	for _, username := range []string{"john doe", "john@example.com", "ab", "administrators"} {
+		assert.NoError(t, username_util.ValidateUsernameNotReserved(username), username)
+	}
+	for _, username := range []string{"admin", "Admin", "ROOT", "no-reply"} {
+		var invalidUsernameError *username_util.InvalidUsernameError
+		assert.ErrorAs(t, username_util.ValidateUsernameNotReserved(username), &invalidUsernameError, username)
+	}
+}
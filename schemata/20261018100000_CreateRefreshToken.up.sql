@@ -0,0 +1,11 @@
+CREATE TABLE "refresh_token" (
+                                 "jti" uuid NOT NULL PRIMARY KEY,
+                                 "user_id" uuid NOT NULL REFERENCES "app_user" ("id") ON DELETE CASCADE,
+                                 "family_id" uuid NOT NULL,
+                                 "issued_at" timestamp with time zone NOT NULL,
+                                 "expires_at" timestamp with time zone NOT NULL,
+                                 "revoked_at" timestamp with time zone NULL
+);
+
+CREATE INDEX "refresh_token_user_id_idx" ON "refresh_token" ("user_id");
+CREATE INDEX "refresh_token_family_id_idx" ON "refresh_token" ("family_id");
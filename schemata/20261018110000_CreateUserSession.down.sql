@@ -0,0 +1,2 @@
+ALTER TABLE "refresh_token" DROP CONSTRAINT IF EXISTS "refresh_token_family_id_fkey";
+DROP TABLE IF EXISTS "user_session";
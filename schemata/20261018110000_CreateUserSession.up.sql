@@ -0,0 +1,20 @@
+CREATE TABLE "user_session" (
+                                "id" uuid NOT NULL PRIMARY KEY,
+                                "user_id" uuid NOT NULL REFERENCES "app_user" ("id") ON DELETE CASCADE,
+                                "user_agent" text NOT NULL DEFAULT '',
+                                "ip_address" varchar(45) NOT NULL DEFAULT '',
+                                "created_at" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                "last_refreshed_at" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                "revoked_at" timestamp with time zone NULL
+);
+
+CREATE INDEX "user_session_user_id_idx" ON "user_session" ("user_id");
+
+INSERT INTO "user_session" ("id", "user_id", "created_at", "last_refreshed_at", "revoked_at")
+SELECT "family_id", "user_id", min("issued_at"), max("issued_at"),
+       CASE WHEN bool_and("revoked_at" IS NOT NULL) THEN max("revoked_at") END
+FROM "refresh_token"
+GROUP BY "family_id", "user_id";
+
+ALTER TABLE "refresh_token"
+    ADD CONSTRAINT "refresh_token_family_id_fkey" FOREIGN KEY ("family_id") REFERENCES "user_session" ("id") ON DELETE CASCADE;
@@ -0,0 +1,11 @@
+CREATE TABLE "verification_token" (
+                                      "token_hash" varchar(64) NOT NULL PRIMARY KEY,
+                                      "user_id" uuid NOT NULL REFERENCES "app_user" ("id") ON DELETE CASCADE,
+                                      "purpose" varchar(32) NOT NULL,
+                                      "email" varchar(254) NOT NULL,
+                                      "created_at" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                      "expires_at" timestamp with time zone NOT NULL,
+                                      "consumed_at" timestamp with time zone NULL
+);
+
+CREATE INDEX "verification_token_user_id_purpose_idx" ON "verification_token" ("user_id", "purpose");
@@ -0,0 +1,13 @@
+CREATE TABLE "email_outbox" (
+                                "id" uuid NOT NULL DEFAULT uuid_generate_v4() PRIMARY KEY,
+                                "message" jsonb NOT NULL,
+                                "status" varchar(16) NOT NULL DEFAULT 'pending',
+                                "attempts" integer NOT NULL DEFAULT 0,
+                                "last_error" text NOT NULL DEFAULT '',
+                                "created_at" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                "next_attempt_at" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                "locked_until" timestamp with time zone NULL,
+                                "sent_at" timestamp with time zone NULL
+);
+
+CREATE INDEX "email_outbox_pending_idx" ON "email_outbox" ("next_attempt_at") WHERE "status" = 'pending';
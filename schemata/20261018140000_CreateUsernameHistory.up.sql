@@ -0,0 +1,9 @@
+CREATE TABLE "username_history" (
+                                    "id" uuid NOT NULL DEFAULT uuid_generate_v4() PRIMARY KEY,
+                                    "user_id" uuid NOT NULL REFERENCES "app_user" ("id") ON DELETE CASCADE,
+                                    "username" varchar(150) COLLATE "case_insensitive" NOT NULL,
+                                    "changed_at" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX "username_history_user_id_idx" ON "username_history" ("user_id", "changed_at");
+CREATE INDEX "username_history_username_idx" ON "username_history" ("username");
@@ -0,0 +1,36 @@
+CREATE TABLE "permission" (
+                              "codename" varchar(100) NOT NULL PRIMARY KEY,
+                              "description" text NOT NULL DEFAULT ''
+);
+
+CREATE TABLE "role" (
+                        "id" uuid NOT NULL DEFAULT uuid_generate_v4() PRIMARY KEY,
+                        "name" varchar(64) COLLATE "case_insensitive" NOT NULL UNIQUE,
+                        "description" text NOT NULL DEFAULT '',
+                        "created_at" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE "role_permission" (
+                                   "role_id" uuid NOT NULL REFERENCES "role" ("id") ON DELETE CASCADE,
+                                   "permission" varchar(100) NOT NULL REFERENCES "permission" ("codename") ON DELETE CASCADE,
+                                   PRIMARY KEY ("role_id", "permission")
+);
+
+CREATE TABLE "user_role" (
+                             "user_id" uuid NOT NULL REFERENCES "app_user" ("id") ON DELETE CASCADE,
+                             "role_id" uuid NOT NULL REFERENCES "role" ("id") ON DELETE CASCADE,
+                             "created_at" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                             PRIMARY KEY ("user_id", "role_id")
+);
+
+CREATE INDEX "user_role_role_id_idx" ON "user_role" ("role_id");
+
+INSERT INTO "permission" ("codename", "description") VALUES
+    ('users.read', 'View user accounts'),
+    ('users.write', 'Manage user accounts'),
+    ('roles.manage', 'Manage roles and assign them to users');
+
+INSERT INTO "role" ("name", "description") VALUES ('admin', 'Full access to user and role management');
+
+INSERT INTO "role_permission" ("role_id", "permission")
+SELECT "role"."id", "permission"."codename" FROM "role", "permission" WHERE "role"."name" = 'admin';
@@ -0,0 +1,13 @@
+CREATE TABLE "audit_event" (
+                               "id" uuid NOT NULL DEFAULT uuid_generate_v4() PRIMARY KEY,
+                               "actor_id" uuid NULL,
+                               "target_id" uuid NULL,
+                               "action" varchar(64) NOT NULL,
+                               "ip_address" varchar(45) NOT NULL DEFAULT '',
+                               "user_agent" varchar(512) NOT NULL DEFAULT '',
+                               "metadata" jsonb NOT NULL DEFAULT '{}',
+                               "created_at" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX "audit_event_actor_id_idx" ON "audit_event" ("actor_id", "created_at");
+CREATE INDEX "audit_event_target_id_idx" ON "audit_event" ("target_id", "created_at");
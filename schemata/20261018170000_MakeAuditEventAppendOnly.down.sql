@@ -0,0 +1,7 @@
+DELETE FROM "permission" WHERE "codename" = 'audit.read';
+
+DROP INDEX IF EXISTS "audit_event_created_at_idx";
+
+DROP TRIGGER IF EXISTS "audit_event_no_truncate" ON "audit_event";
+DROP TRIGGER IF EXISTS "audit_event_append_only" ON "audit_event";
+DROP FUNCTION IF EXISTS "audit_event_append_only"();
@@ -0,0 +1,21 @@
+CREATE FUNCTION "audit_event_append_only"() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_event is append-only, % is not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_event_append_only"
+    BEFORE UPDATE OR DELETE ON "audit_event"
+    FOR EACH ROW EXECUTE FUNCTION "audit_event_append_only"();
+
+CREATE TRIGGER "audit_event_no_truncate"
+    BEFORE TRUNCATE ON "audit_event"
+    FOR EACH STATEMENT EXECUTE FUNCTION "audit_event_append_only"();
+
+CREATE INDEX "audit_event_created_at_idx" ON "audit_event" ("created_at");
+
+INSERT INTO "permission" ("codename", "description") VALUES
+    ('audit.read', 'View the security audit log');
+
+INSERT INTO "role_permission" ("role_id", "permission")
+SELECT "role"."id", 'audit.read' FROM "role" WHERE "role"."name" = 'admin';
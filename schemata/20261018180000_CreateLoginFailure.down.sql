@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "ip_login_failure";
+DROP TABLE IF EXISTS "account_login_failure";
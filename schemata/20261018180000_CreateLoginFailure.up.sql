@@ -0,0 +1,12 @@
+CREATE TABLE "account_login_failure" (
+                                         "user_id" uuid NOT NULL PRIMARY KEY REFERENCES "app_user" ("id") ON DELETE CASCADE,
+                                         "failed_attempts" integer NOT NULL DEFAULT 0,
+                                         "last_failed_at" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                         "locked_until" timestamp with time zone NULL
+);
+
+CREATE TABLE "ip_login_failure" (
+                                    "ip_address" varchar(45) NOT NULL PRIMARY KEY,
+                                    "failed_attempts" integer NOT NULL DEFAULT 0,
+                                    "window_started_at" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "email_outbox_created_at_idx";
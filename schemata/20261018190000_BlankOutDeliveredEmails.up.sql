@@ -0,0 +1,3 @@
+UPDATE "email_outbox" SET "message" = '{}'::jsonb WHERE "status" <> 'pending';
+
+CREATE INDEX "email_outbox_created_at_idx" ON "email_outbox" ("created_at") WHERE "status" <> 'pending';
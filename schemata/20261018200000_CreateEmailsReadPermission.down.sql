@@ -0,0 +1 @@
+DELETE FROM "permission" WHERE "codename" = 'emails.read';
@@ -0,0 +1,5 @@
+INSERT INTO "permission" ("codename", "description") VALUES
+    ('emails.read', 'View the delivery status of emails');
+
+INSERT INTO "role_permission" ("role_id", "permission")
+SELECT "role"."id", 'emails.read' FROM "role" WHERE "role"."name" = 'admin';
@@ -0,0 +1 @@
+ALTER TABLE "email_outbox" ADD COLUMN "locked_by" uuid NULL;
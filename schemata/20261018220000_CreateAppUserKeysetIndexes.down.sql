@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "app_user_username_id_idx";
+DROP INDEX IF EXISTS "app_user_date_joined_id_idx";
@@ -0,0 +1,2 @@
+CREATE INDEX "app_user_date_joined_id_idx" ON "app_user" ("date_joined", "id");
+CREATE INDEX "app_user_username_id_idx" ON "app_user" ("username", "id");
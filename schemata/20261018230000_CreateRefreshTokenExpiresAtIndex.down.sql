@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "refresh_token_expires_at_idx";
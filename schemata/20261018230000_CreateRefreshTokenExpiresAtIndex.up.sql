@@ -0,0 +1 @@
+CREATE INDEX "refresh_token_expires_at_idx" ON "refresh_token" ("expires_at");
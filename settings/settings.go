@@ -5,28 +5,69 @@ import (
 	log "github.com/sirupsen/logrus"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 var (
-	DbHost                 string
-	DbPort                 string
-	DbSslMode              string
-	DbUsername             string
-	DbName                 string
-	DbPassword             string
-	RefreshTokenLife       time.Duration
-	AccessTokenLife        time.Duration
-	RefreshCookieSecure    bool
-	ServerPort             string
-	EmailHost              string
-	EmailPort              string
-	EmailHostUser          string
-	EmailHostPassword      string
-	AwsS3KeyStoreRegion    string
-	AwsS3KeyStoreBucket    string
-	JwtSigningKeyPath      string
-	JwtVerificationKeyPath string
+	DbHost                      string
+	DbPort                      string
+	DbSslMode                   string
+	DbUsername                  string
+	DbName                      string
+	DbPassword                  string
+	RefreshTokenLife            time.Duration
+	AccessTokenLife             time.Duration
+	RefreshCookieSecure         bool
+	ServerPort                  string
+	FrontendBaseUrl             string
+	TrustProxyHeaders           bool
+	TrustedProxyCount           int
+	EmailBackend                string
+	EmailHost                   string
+	EmailPort                   string
+	EmailHostUser               string
+	EmailHostPassword           string
+	DefaultFromEmail            string
+	EmailSecurity               string
+	EmailFilePath               string
+	EmailVerificationTokenLife  time.Duration
+	PasswordResetTokenLife      time.Duration
+	UsernameChangeCooldown      time.Duration
+	UsernameReservationPeriod   time.Duration
+	LoginMaxFailedAttempts      int
+	LoginFailureWindow          time.Duration
+	LoginLockoutDuration        time.Duration
+	LoginDelayBase              time.Duration
+	LoginDelayMax               time.Duration
+	LoginIpMaxFailedAttempts    int
+	LoginIpWindow               time.Duration
+	EmailOutboxEnabled          bool
+	EmailOutboxMaxAttempts      int
+	EmailOutboxBatchSize        int
+	EmailOutboxPollInterval     time.Duration
+	EmailOutboxRetryBaseDelay   time.Duration
+	EmailOutboxRetryMaxDelay    time.Duration
+	EmailOutboxRetention        time.Duration
+	KeyStoreBackend             string
+	AwsS3KeyStoreRegion         string
+	AwsS3KeyStoreBucket         string
+	AwsS3KeyStoreEndpoint       string
+	AwsS3KeyStoreForcePathStyle bool
+	KeyStoreRefreshInterval     time.Duration
+	JwtSigningKeyPath           string
+	JwtVerificationKeyPath      string
+	JwtGenerateMissingKeys      bool
+	JwtSigningAlgorithm         string
+	JwtAllowedAlgorithms        []string
+	JwtIssuer                   string
+	JwtAudience                 string
+	JwtLeeway                   time.Duration
+	JwtKeyRotationInterval      time.Duration
+	AesKeys                     []string
+	AesKeyRingPath              string
+	EncryptPaginationCursors    bool
+	EncryptEmailOutbox          bool
 )
 
 func init() {
@@ -43,16 +84,46 @@ func init() {
 	DbName = getEnv("DB_NAME", "eau-de-go")
 	DbPassword = getEnv("DB_PASSWORD", "")
 	ServerPort = getEnv("SERVER_PORT", "8080")
+	FrontendBaseUrl = getEnv("FRONTEND_BASE_URL", "http://localhost:3000")
 
+	EmailBackend = getEnv("EMAIL_BACKEND", "smtp")
 	EmailHost = getEnv("EMAIL_HOST", "")
 	EmailPort = getEnv("EMAIL_PORT", "587")
 	EmailHostUser = getEnv("EMAIL_HOST_USER", "")
 	EmailHostPassword = getEnv("EMAIL_HOST_PASSWORD", "")
+	EmailSecurity = getEnv("EMAIL_SECURITY", "starttls")
+	EmailFilePath = getEnv("EMAIL_FILE_PATH", "tmp/emails")
+	if DefaultFromEmail = getEnv("DEFAULT_FROM_EMAIL", ""); DefaultFromEmail == "" {
+		DefaultFromEmail = EmailHostUser
+	}
 
+	KeyStoreBackend = getEnv("KEY_STORE_BACKEND", "memory")
 	AwsS3KeyStoreRegion = getEnv("AWS_S3_KEY_STORE_REGION", "ca-central-1")
 	AwsS3KeyStoreBucket = getEnv("AWS_S3_KEY_STORE_BUCKET", "")
+	AwsS3KeyStoreEndpoint = getEnv("AWS_S3_KEY_STORE_ENDPOINT", "")
+	AwsS3KeyStoreForcePathStyle, _ = strconv.ParseBool(getEnv("AWS_S3_KEY_STORE_FORCE_PATH_STYLE", "false"))
 	JwtSigningKeyPath = getEnv("JWT_SIGNING_KEY_PATH", "rsa/jwt.pem")
 	JwtVerificationKeyPath = getEnv("JWT_VERIFICATION_KEY_PATH", "rsa/jwt.pub")
+	JwtSigningAlgorithm = getEnv("JWT_SIGNING_ALGORITHM", "PS256")
+	JwtAllowedAlgorithms = getEnvList("JWT_ALLOWED_ALGORITHMS", "")
+	JwtIssuer = getEnv("JWT_ISSUER", "eau-de-go")
+	JwtAudience = getEnv("JWT_AUDIENCE", "eau-de-go")
+	if jwtLeewaySeconds, err := strconv.Atoi(getEnv("JWT_LEEWAY_SECONDS", "30")); err == nil {
+		JwtLeeway = time.Second * time.Duration(jwtLeewaySeconds)
+	}
+	AesKeys = getEnvList("AES_KEYS", "")
+	AesKeyRingPath = getEnv("AES_KEY_RING_PATH", "rsa/aes.keys")
+	EncryptPaginationCursors, _ = strconv.ParseBool(getEnv("ENCRYPT_PAGINATION_CURSORS", "false"))
+	EncryptEmailOutbox, _ = strconv.ParseBool(getEnv("ENCRYPT_EMAIL_OUTBOX", "false"))
+	JwtGenerateMissingKeys, _ = strconv.ParseBool(getEnv("JWT_GENERATE_MISSING_KEYS", "false"))
+
+	if keyStoreRefreshIntervalMinutes, err := strconv.Atoi(getEnv("KEY_STORE_REFRESH_INTERVAL_MINUTES", "5")); err == nil {
+		KeyStoreRefreshInterval = time.Minute * time.Duration(keyStoreRefreshIntervalMinutes)
+	}
+
+	if jwtKeyRotationIntervalMinutes, err := strconv.Atoi(getEnv("JWT_KEY_ROTATION_INTERVAL_MINUTES", "0")); err == nil {
+		JwtKeyRotationInterval = time.Minute * time.Duration(jwtKeyRotationIntervalMinutes)
+	}
 
 	if refreshTokenLifeMinutes, err := strconv.Atoi(getEnv("REFRESH_TOKEN_LIFE_MINUTES", "10080")); err == nil {
 		RefreshTokenLife = time.Minute * time.Duration(refreshTokenLifeMinutes)
@@ -68,7 +139,117 @@ func init() {
 		AccessTokenLife = time.Minute * time.Duration(defaultAccessTokenLifeMinutes)
 	}
 
+	if emailVerificationTokenLifeMinutes, err := strconv.Atoi(getEnv("EMAIL_VERIFICATION_TOKEN_LIFE_MINUTES", "720")); err == nil {
+		EmailVerificationTokenLife = time.Minute * time.Duration(emailVerificationTokenLifeMinutes)
+	} else {
+		defaultEmailVerificationTokenLifeMinutes := 720
+		EmailVerificationTokenLife = time.Minute * time.Duration(defaultEmailVerificationTokenLifeMinutes)
+	}
+
+	if passwordResetTokenLifeMinutes, err := strconv.Atoi(getEnv("PASSWORD_RESET_TOKEN_LIFE_MINUTES", "60")); err == nil {
+		PasswordResetTokenLife = time.Minute * time.Duration(passwordResetTokenLifeMinutes)
+	} else {
+		defaultPasswordResetTokenLifeMinutes := 60
+		PasswordResetTokenLife = time.Minute * time.Duration(defaultPasswordResetTokenLifeMinutes)
+	}
+
+	if usernameChangeCooldownDays, err := strconv.Atoi(getEnv("USERNAME_CHANGE_COOLDOWN_DAYS", "30")); err == nil {
+		UsernameChangeCooldown = 24 * time.Hour * time.Duration(usernameChangeCooldownDays)
+	} else {
+		defaultUsernameChangeCooldownDays := 30
+		UsernameChangeCooldown = 24 * time.Hour * time.Duration(defaultUsernameChangeCooldownDays)
+	}
+	if usernameReservationDays, err := strconv.Atoi(getEnv("USERNAME_RESERVATION_DAYS", "90")); err == nil {
+		UsernameReservationPeriod = 24 * time.Hour * time.Duration(usernameReservationDays)
+	} else {
+		defaultUsernameReservationDays := 90
+		UsernameReservationPeriod = 24 * time.Hour * time.Duration(defaultUsernameReservationDays)
+	}
+
+	if loginMaxFailedAttempts, err := strconv.Atoi(getEnv("LOGIN_MAX_FAILED_ATTEMPTS", "5")); err == nil {
+		LoginMaxFailedAttempts = loginMaxFailedAttempts
+	} else {
+		LoginMaxFailedAttempts = 5
+	}
+	if loginFailureWindowMinutes, err := strconv.Atoi(getEnv("LOGIN_FAILURE_WINDOW_MINUTES", "15")); err == nil {
+		LoginFailureWindow = time.Minute * time.Duration(loginFailureWindowMinutes)
+	} else {
+		defaultLoginFailureWindowMinutes := 15
+		LoginFailureWindow = time.Minute * time.Duration(defaultLoginFailureWindowMinutes)
+	}
+	if loginLockoutMinutes, err := strconv.Atoi(getEnv("LOGIN_LOCKOUT_MINUTES", "15")); err == nil {
+		LoginLockoutDuration = time.Minute * time.Duration(loginLockoutMinutes)
+	} else {
+		defaultLoginLockoutMinutes := 15
+		LoginLockoutDuration = time.Minute * time.Duration(defaultLoginLockoutMinutes)
+	}
+	if loginDelayBaseSeconds, err := strconv.Atoi(getEnv("LOGIN_DELAY_BASE_SECONDS", "1")); err == nil {
+		LoginDelayBase = time.Second * time.Duration(loginDelayBaseSeconds)
+	} else {
+		defaultLoginDelayBaseSeconds := 1
+		LoginDelayBase = time.Second * time.Duration(defaultLoginDelayBaseSeconds)
+	}
+	if loginDelayMaxSeconds, err := strconv.Atoi(getEnv("LOGIN_DELAY_MAX_SECONDS", "30")); err == nil {
+		LoginDelayMax = time.Second * time.Duration(loginDelayMaxSeconds)
+	} else {
+		defaultLoginDelayMaxSeconds := 30
+		LoginDelayMax = time.Second * time.Duration(defaultLoginDelayMaxSeconds)
+	}
+	if loginIpMaxFailedAttempts, err := strconv.Atoi(getEnv("LOGIN_IP_MAX_FAILED_ATTEMPTS", "50")); err == nil {
+		LoginIpMaxFailedAttempts = loginIpMaxFailedAttempts
+	} else {
+		LoginIpMaxFailedAttempts = 50
+	}
+	if loginIpWindowMinutes, err := strconv.Atoi(getEnv("LOGIN_IP_WINDOW_MINUTES", "15")); err == nil {
+		LoginIpWindow = time.Minute * time.Duration(loginIpWindowMinutes)
+	} else {
+		defaultLoginIpWindowMinutes := 15
+		LoginIpWindow = time.Minute * time.Duration(defaultLoginIpWindowMinutes)
+	}
+
+	EmailOutboxEnabled, _ = strconv.ParseBool(getEnv("EMAIL_OUTBOX_ENABLED", "true"))
+	if emailOutboxMaxAttempts, err := strconv.Atoi(getEnv("EMAIL_OUTBOX_MAX_ATTEMPTS", "8")); err == nil {
+		EmailOutboxMaxAttempts = emailOutboxMaxAttempts
+	} else {
+		EmailOutboxMaxAttempts = 8
+	}
+	if emailOutboxBatchSize, err := strconv.Atoi(getEnv("EMAIL_OUTBOX_BATCH_SIZE", "20")); err == nil {
+		EmailOutboxBatchSize = emailOutboxBatchSize
+	} else {
+		EmailOutboxBatchSize = 20
+	}
+	if emailOutboxPollIntervalSeconds, err := strconv.Atoi(getEnv("EMAIL_OUTBOX_POLL_INTERVAL_SECONDS", "5")); err == nil {
+		EmailOutboxPollInterval = time.Second * time.Duration(emailOutboxPollIntervalSeconds)
+	} else {
+		defaultEmailOutboxPollIntervalSeconds := 5
+		EmailOutboxPollInterval = time.Second * time.Duration(defaultEmailOutboxPollIntervalSeconds)
+	}
+	if emailOutboxRetryBaseDelaySeconds, err := strconv.Atoi(getEnv("EMAIL_OUTBOX_RETRY_BASE_DELAY_SECONDS", "30")); err == nil {
+		EmailOutboxRetryBaseDelay = time.Second * time.Duration(emailOutboxRetryBaseDelaySeconds)
+	} else {
+		defaultEmailOutboxRetryBaseDelaySeconds := 30
+		EmailOutboxRetryBaseDelay = time.Second * time.Duration(defaultEmailOutboxRetryBaseDelaySeconds)
+	}
+	if emailOutboxRetryMaxDelayMinutes, err := strconv.Atoi(getEnv("EMAIL_OUTBOX_RETRY_MAX_DELAY_MINUTES", "60")); err == nil {
+		EmailOutboxRetryMaxDelay = time.Minute * time.Duration(emailOutboxRetryMaxDelayMinutes)
+	} else {
+		defaultEmailOutboxRetryMaxDelayMinutes := 60
+		EmailOutboxRetryMaxDelay = time.Minute * time.Duration(defaultEmailOutboxRetryMaxDelayMinutes)
+	}
+	if emailOutboxRetentionDays, err := strconv.Atoi(getEnv("EMAIL_OUTBOX_RETENTION_DAYS", "30")); err == nil {
+		EmailOutboxRetention = time.Hour * 24 * time.Duration(emailOutboxRetentionDays)
+	} else {
+		defaultEmailOutboxRetentionDays := 30
+		EmailOutboxRetention = time.Hour * 24 * time.Duration(defaultEmailOutboxRetentionDays)
+	}
+
 	RefreshCookieSecure, _ = strconv.ParseBool(getEnv("REFRESH_COOKIE_SECURE", "true"))
+	TrustProxyHeaders, _ = strconv.ParseBool(getEnv("TRUST_PROXY_HEADERS", "false"))
+	if trustedProxyCount, err := strconv.Atoi(getEnv("TRUSTED_PROXY_COUNT", "1")); err == nil && trustedProxyCount > 0 {
+		TrustedProxyCount = trustedProxyCount
+	} else {
+		TrustedProxyCount = 1
+	}
 }
 
 func getEnv(key string, defaultValue string) string {
@@ -79,3 +260,14 @@ func getEnv(key string, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvList returns the comma separated values of the variable, ignoring empty values.
+func getEnvList(key string, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -13,6 +13,74 @@ WHERE username = $1 LIMIT 1;
 -- name: ListAppUser :many
 SELECT * FROM app_user;
 
+-- name: ListAppUserPageByDateJoinedAsc :many
+SELECT * FROM app_user
+WHERE (sqlc.narg('is_active')::boolean IS NULL OR is_active = sqlc.narg('is_active'))
+  AND (sqlc.narg('is_staff')::boolean IS NULL OR is_staff = sqlc.narg('is_staff'))
+  AND (sqlc.narg('email_verified')::boolean IS NULL OR email_verified = sqlc.narg('email_verified'))
+  AND (sqlc.narg('joined_after')::timestamptz IS NULL OR date_joined >= sqlc.narg('joined_after'))
+  AND (sqlc.narg('joined_before')::timestamptz IS NULL OR date_joined < sqlc.narg('joined_before'))
+  AND (sqlc.narg('search')::text IS NULL
+    OR username COLLATE "default" ILIKE sqlc.narg('search')
+    OR email COLLATE "default" ILIKE sqlc.narg('search')
+    OR first_name ILIKE sqlc.narg('search')
+    OR last_name ILIKE sqlc.narg('search'))
+  AND (sqlc.narg('after_id')::uuid IS NULL
+    OR (date_joined, id) > (sqlc.narg('after_date_joined')::timestamptz, sqlc.narg('after_id')))
+ORDER BY date_joined, id
+LIMIT sqlc.arg('page_size')::int;
+
+-- name: ListAppUserPageByDateJoinedDesc :many
+SELECT * FROM app_user
+WHERE (sqlc.narg('is_active')::boolean IS NULL OR is_active = sqlc.narg('is_active'))
+  AND (sqlc.narg('is_staff')::boolean IS NULL OR is_staff = sqlc.narg('is_staff'))
+  AND (sqlc.narg('email_verified')::boolean IS NULL OR email_verified = sqlc.narg('email_verified'))
+  AND (sqlc.narg('joined_after')::timestamptz IS NULL OR date_joined >= sqlc.narg('joined_after'))
+  AND (sqlc.narg('joined_before')::timestamptz IS NULL OR date_joined < sqlc.narg('joined_before'))
+  AND (sqlc.narg('search')::text IS NULL
+    OR username COLLATE "default" ILIKE sqlc.narg('search')
+    OR email COLLATE "default" ILIKE sqlc.narg('search')
+    OR first_name ILIKE sqlc.narg('search')
+    OR last_name ILIKE sqlc.narg('search'))
+  AND (sqlc.narg('after_id')::uuid IS NULL
+    OR (date_joined, id) < (sqlc.narg('after_date_joined')::timestamptz, sqlc.narg('after_id')))
+ORDER BY date_joined DESC, id DESC
+LIMIT sqlc.arg('page_size')::int;
+
+-- name: ListAppUserPageByUsernameAsc :many
+SELECT * FROM app_user
+WHERE (sqlc.narg('is_active')::boolean IS NULL OR is_active = sqlc.narg('is_active'))
+  AND (sqlc.narg('is_staff')::boolean IS NULL OR is_staff = sqlc.narg('is_staff'))
+  AND (sqlc.narg('email_verified')::boolean IS NULL OR email_verified = sqlc.narg('email_verified'))
+  AND (sqlc.narg('joined_after')::timestamptz IS NULL OR date_joined >= sqlc.narg('joined_after'))
+  AND (sqlc.narg('joined_before')::timestamptz IS NULL OR date_joined < sqlc.narg('joined_before'))
+  AND (sqlc.narg('search')::text IS NULL
+    OR username COLLATE "default" ILIKE sqlc.narg('search')
+    OR email COLLATE "default" ILIKE sqlc.narg('search')
+    OR first_name ILIKE sqlc.narg('search')
+    OR last_name ILIKE sqlc.narg('search'))
+  AND (sqlc.narg('after_id')::uuid IS NULL
+    OR (username, id) > (sqlc.narg('after_username')::text, sqlc.narg('after_id')))
+ORDER BY username, id
+LIMIT sqlc.arg('page_size')::int;
+
+-- name: ListAppUserPageByUsernameDesc :many
+SELECT * FROM app_user
+WHERE (sqlc.narg('is_active')::boolean IS NULL OR is_active = sqlc.narg('is_active'))
+  AND (sqlc.narg('is_staff')::boolean IS NULL OR is_staff = sqlc.narg('is_staff'))
+  AND (sqlc.narg('email_verified')::boolean IS NULL OR email_verified = sqlc.narg('email_verified'))
+  AND (sqlc.narg('joined_after')::timestamptz IS NULL OR date_joined >= sqlc.narg('joined_after'))
+  AND (sqlc.narg('joined_before')::timestamptz IS NULL OR date_joined < sqlc.narg('joined_before'))
+  AND (sqlc.narg('search')::text IS NULL
+    OR username COLLATE "default" ILIKE sqlc.narg('search')
+    OR email COLLATE "default" ILIKE sqlc.narg('search')
+    OR first_name ILIKE sqlc.narg('search')
+    OR last_name ILIKE sqlc.narg('search'))
+  AND (sqlc.narg('after_id')::uuid IS NULL
+    OR (username, id) < (sqlc.narg('after_username')::text, sqlc.narg('after_id')))
+ORDER BY username DESC, id DESC
+LIMIT sqlc.arg('page_size')::int;
+
 -- name: CreateAppUser :one
 INSERT INTO app_user (
     username,
@@ -32,6 +100,19 @@ SET first_name = coalesce(sqlc.narg('first_name'), first_name),
 WHERE id = sqlc.arg('id')
     RETURNING *;
 
+-- name: UpdateAppUserEmail :one
+UPDATE app_user
+SET email = sqlc.arg('email'),
+    email_verified = false
+WHERE id = sqlc.arg('id')
+    RETURNING *;
+
+-- name: UpdateAppUserUsername :one
+UPDATE app_user
+SET username = sqlc.arg('username')
+WHERE id = sqlc.arg('id')
+    RETURNING *;
+
 -- name: UpdateAppUserPassword :one
 UPDATE app_user
 SET password = sqlc.arg('password')
@@ -67,3 +148,9 @@ UPDATE app_user
 SET email_verified = false
 WHERE id = $1
     RETURNING *;
+
+-- name: SetUserStaffStatus :one
+UPDATE app_user
+SET is_staff = sqlc.arg('is_staff')
+WHERE id = sqlc.arg('id')
+    RETURNING *;
@@ -0,0 +1,23 @@
+-- name: CreateAuditEvent :one
+INSERT INTO audit_event (
+    actor_id,
+    target_id,
+    action,
+    ip_address,
+    user_agent,
+    metadata
+) VALUES (
+             $1, $2, $3, $4, $5, $6
+         )
+    RETURNING *;
+
+-- name: ListAuditEventPage :many
+SELECT * FROM audit_event
+WHERE (sqlc.narg('user_id')::uuid IS NULL OR actor_id = sqlc.narg('user_id') OR target_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
+  AND (sqlc.narg('since')::timestamptz IS NULL OR created_at >= sqlc.narg('since'))
+  AND (sqlc.narg('until')::timestamptz IS NULL OR created_at < sqlc.narg('until'))
+  AND (sqlc.narg('after_id')::uuid IS NULL
+    OR (created_at, id) < (sqlc.narg('after_created_at')::timestamptz, sqlc.narg('after_id')))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size')::int;
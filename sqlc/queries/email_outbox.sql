@@ -0,0 +1,56 @@
+-- name: EnqueueEmail :one
+INSERT INTO email_outbox (
+    message
+) VALUES (
+             $1
+         )
+    RETURNING *;
+
+-- name: DeleteEmailOutboxBefore :execrows
+DELETE FROM email_outbox
+WHERE status <> 'pending'
+  AND created_at < $1;
+
+-- name: GetEmailOutboxById :one
+SELECT * FROM email_outbox
+WHERE id = $1 LIMIT 1;
+
+-- name: ClaimDueEmails :many
+UPDATE email_outbox
+SET locked_until = sqlc.arg('locked_until'),
+    locked_by = sqlc.arg('locked_by')
+WHERE id IN (
+    SELECT id FROM email_outbox
+    WHERE status = 'pending'
+      AND next_attempt_at <= current_timestamp
+      AND (locked_until IS NULL OR locked_until < current_timestamp)
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+)
+    RETURNING *;
+
+-- name: MarkEmailSent :one
+UPDATE email_outbox
+SET status = 'sent',
+    message = '{}'::jsonb,
+    attempts = attempts + 1,
+    sent_at = current_timestamp(0),
+    locked_until = NULL,
+    locked_by = NULL
+WHERE id = sqlc.arg('id')
+  AND locked_by = sqlc.arg('locked_by')
+    RETURNING *;
+
+-- name: MarkEmailFailed :one
+UPDATE email_outbox
+SET status = sqlc.arg('status'),
+    message = CASE WHEN sqlc.arg('status') = 'dead' THEN '{}'::jsonb ELSE message END,
+    attempts = attempts + 1,
+    last_error = sqlc.arg('last_error'),
+    next_attempt_at = sqlc.arg('next_attempt_at'),
+    locked_until = NULL,
+    locked_by = NULL
+WHERE id = sqlc.arg('id')
+  AND locked_by = sqlc.arg('locked_by')
+    RETURNING *;
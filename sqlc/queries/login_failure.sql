@@ -0,0 +1,69 @@
+-- name: GetAccountLoginFailure :one
+SELECT * FROM account_login_failure
+WHERE user_id = $1;
+
+-- name: BeginAccountLoginAttempt :one
+INSERT INTO account_login_failure (
+    user_id,
+    failed_attempts,
+    last_failed_at
+) VALUES (
+             sqlc.arg('user_id'), 1, sqlc.arg('attempted_at')
+         )
+ON CONFLICT (user_id) DO UPDATE
+SET failed_attempts = CASE
+        WHEN account_login_failure.last_failed_at < sqlc.arg('window_start')::timestamptz
+            OR account_login_failure.locked_until IS NOT NULL THEN 1
+        ELSE account_login_failure.failed_attempts + 1
+    END,
+    last_failed_at = EXCLUDED.last_failed_at,
+    locked_until = NULL
+WHERE CASE
+        WHEN account_login_failure.locked_until IS NOT NULL THEN account_login_failure.locked_until <= EXCLUDED.last_failed_at
+        WHEN account_login_failure.last_failed_at < sqlc.arg('window_start')::timestamptz THEN TRUE
+        ELSE account_login_failure.last_failed_at + make_interval(secs => LEAST(
+            sqlc.arg('delay_base_seconds')::float8 * power(2, LEAST(account_login_failure.failed_attempts - 1, 30)),
+            sqlc.arg('delay_max_seconds')::float8
+        )) <= EXCLUDED.last_failed_at
+    END
+    RETURNING *;
+
+-- name: LockAccount :one
+UPDATE account_login_failure
+SET locked_until = sqlc.arg('locked_until')
+WHERE user_id = sqlc.arg('user_id')
+    RETURNING *;
+
+-- name: DeleteAccountLoginFailure :exec
+DELETE FROM account_login_failure
+WHERE user_id = $1;
+
+-- name: GetIpLoginFailure :one
+SELECT * FROM ip_login_failure
+WHERE ip_address = $1;
+
+-- name: BeginIpLoginAttempt :one
+INSERT INTO ip_login_failure (
+    ip_address,
+    failed_attempts,
+    window_started_at
+) VALUES (
+             sqlc.arg('ip_address'), 1, sqlc.arg('attempted_at')
+         )
+ON CONFLICT (ip_address) DO UPDATE
+SET failed_attempts = CASE
+        WHEN ip_login_failure.window_started_at < sqlc.arg('window_start')::timestamptz THEN 1
+        ELSE ip_login_failure.failed_attempts + 1
+    END,
+    window_started_at = CASE
+        WHEN ip_login_failure.window_started_at < sqlc.arg('window_start')::timestamptz THEN EXCLUDED.window_started_at
+        ELSE ip_login_failure.window_started_at
+    END
+WHERE ip_login_failure.window_started_at < sqlc.arg('window_start')::timestamptz
+    OR ip_login_failure.failed_attempts < sqlc.arg('max_attempts')::int
+    RETURNING *;
+
+-- name: ReleaseIpLoginAttempt :exec
+UPDATE ip_login_failure
+SET failed_attempts = GREATEST(failed_attempts - 1, 0)
+WHERE ip_address = $1;
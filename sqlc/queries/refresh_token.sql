@@ -0,0 +1,40 @@
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_token (
+    jti,
+    user_id,
+    family_id,
+    issued_at,
+    expires_at
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+    RETURNING *;
+
+-- name: GetRefreshTokenByJti :one
+SELECT * FROM refresh_token
+WHERE jti = $1 LIMIT 1;
+
+-- name: RevokeRefreshToken :one
+UPDATE refresh_token
+SET revoked_at = current_timestamp(0)
+WHERE jti = $1 AND revoked_at IS NULL
+    RETURNING *;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_token
+SET revoked_at = current_timestamp(0)
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeAllUserRefreshTokens :exec
+UPDATE refresh_token
+SET revoked_at = current_timestamp(0)
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: DeleteStaleRefreshTokens :execrows
+DELETE FROM refresh_token
+WHERE expires_at < $1
+   OR (revoked_at IS NOT NULL AND EXISTS (
+    SELECT 1 FROM user_session
+    WHERE user_session.id = refresh_token.family_id
+      AND user_session.revoked_at IS NOT NULL
+));
@@ -0,0 +1,63 @@
+-- name: CreateRole :one
+INSERT INTO role (
+    name,
+    description
+) VALUES (
+             $1, $2
+         )
+    RETURNING *;
+
+-- name: GetRoleByName :one
+SELECT * FROM role
+WHERE name = $1 LIMIT 1;
+
+-- name: ListRoles :many
+SELECT * FROM role
+ORDER BY name;
+
+-- name: DeleteRole :exec
+DELETE FROM role
+WHERE id = $1;
+
+-- name: ListPermissions :many
+SELECT * FROM permission
+ORDER BY codename;
+
+-- name: AddRolePermission :exec
+INSERT INTO role_permission (
+    role_id,
+    permission
+) VALUES (
+             $1, $2
+         )
+ON CONFLICT DO NOTHING;
+
+-- name: ListRolePermissions :many
+SELECT permission FROM role_permission
+WHERE role_id = $1
+ORDER BY permission;
+
+-- name: AssignUserRole :exec
+INSERT INTO user_role (
+    user_id,
+    role_id
+) VALUES (
+             $1, $2
+         )
+ON CONFLICT DO NOTHING;
+
+-- name: RevokeUserRole :exec
+DELETE FROM user_role
+WHERE user_id = $1 AND role_id = $2;
+
+-- name: ListUserRoles :many
+SELECT role.* FROM role
+JOIN user_role ON user_role.role_id = role.id
+WHERE user_role.user_id = $1
+ORDER BY role.name;
+
+-- name: ListUserPermissions :many
+SELECT DISTINCT role_permission.permission FROM role_permission
+JOIN user_role ON user_role.role_id = role_permission.role_id
+WHERE user_role.user_id = $1
+ORDER BY role_permission.permission;
@@ -0,0 +1,41 @@
+-- name: CreateUserSession :one
+INSERT INTO user_session (
+    id,
+    user_id,
+    user_agent,
+    ip_address
+) VALUES (
+             $1, $2, $3, $4
+         )
+    RETURNING *;
+
+-- name: TouchUserSession :one
+UPDATE user_session
+SET last_refreshed_at = current_timestamp(0),
+    user_agent = sqlc.arg('user_agent'),
+    ip_address = sqlc.arg('ip_address')
+WHERE id = sqlc.arg('id')
+    RETURNING *;
+
+-- name: ListActiveUserSessions :many
+SELECT * FROM user_session
+WHERE user_session.user_id = $1
+  AND user_session.revoked_at IS NULL
+  AND EXISTS (
+    SELECT 1 FROM refresh_token
+    WHERE refresh_token.family_id = user_session.id
+      AND refresh_token.revoked_at IS NULL
+      AND refresh_token.expires_at > current_timestamp
+)
+ORDER BY user_session.last_refreshed_at DESC;
+
+-- name: RevokeUserSession :one
+UPDATE user_session
+SET revoked_at = current_timestamp(0)
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+    RETURNING *;
+
+-- name: RevokeAllUserSessions :exec
+UPDATE user_session
+SET revoked_at = current_timestamp(0)
+WHERE user_id = $1 AND revoked_at IS NULL;
@@ -0,0 +1,22 @@
+-- name: CreateUsernameHistory :one
+INSERT INTO username_history (
+    user_id,
+    username
+) VALUES (
+             $1, $2
+         )
+    RETURNING *;
+
+-- name: GetLatestUsernameChange :one
+SELECT * FROM username_history
+WHERE user_id = $1
+ORDER BY changed_at DESC
+LIMIT 1;
+
+-- name: IsUsernameReserved :one
+SELECT EXISTS (
+    SELECT 1 FROM username_history
+    WHERE username = sqlc.arg('username')
+      AND user_id <> sqlc.arg('user_id')
+      AND changed_at > sqlc.arg('reserved_since')
+);
@@ -0,0 +1,25 @@
+-- name: CreateVerificationToken :one
+INSERT INTO verification_token (
+    token_hash,
+    user_id,
+    purpose,
+    email,
+    expires_at
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+    RETURNING *;
+
+-- name: ConsumeVerificationToken :one
+UPDATE verification_token
+SET consumed_at = current_timestamp(0)
+WHERE token_hash = $1
+  AND purpose = $2
+  AND consumed_at IS NULL
+  AND expires_at > current_timestamp
+    RETURNING *;
+
+-- name: InvalidateUserVerificationTokens :exec
+UPDATE verification_token
+SET consumed_at = current_timestamp(0)
+WHERE user_id = $1 AND purpose = $2 AND consumed_at IS NULL;